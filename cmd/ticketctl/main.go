@@ -0,0 +1,294 @@
+// Command ticketctl performs maintenance on a reservation store.
+//
+//	ticketctl backup  -store tickets.db [-out backup.json]
+//	ticketctl restore -store tickets.db [-in backup.json] [-replace]
+//	ticketctl verify  [-in backup.json]
+//	ticketctl reconcile -store tickets.db [-keys keys.json]
+//	ticketctl reencrypt -store tickets.db -keys keys.json
+//	ticketctl export -store tickets.db [-keys keys.json] [-out bookings.csv]
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"ticketing-app/pkg/backup"
+	"ticketing-app/pkg/encryption"
+	"ticketing-app/pkg/redact"
+	"ticketing-app/pkg/reservation"
+	"ticketing-app/pkg/store"
+	"ticketing-app/pkg/store/boltstore"
+	"time"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "backup":
+		err = runBackup(os.Args[2:])
+	case "restore":
+		err = runRestore(os.Args[2:])
+	case "verify":
+		err = runVerify(os.Args[2:])
+	case "reconcile":
+		err = runReconcile(os.Args[2:])
+	case "reencrypt":
+		err = runReencrypt(os.Args[2:])
+	case "export":
+		err = runExport(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ticketctl %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ticketctl backup|restore|verify|reconcile|reencrypt|export [flags]")
+}
+
+func runBackup(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	path := flags.String("store", "", "store file to back up")
+	out := flags.String("out", "", "backup file to write (default stdout)")
+	flags.Parse(args)
+	if *path == "" {
+		return fmt.Errorf("-store is required")
+	}
+	if _, err := os.Stat(*path); err != nil {
+		return err
+	}
+
+	st, err := boltstore.Open(*path, boltstore.Options{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	summary, err := backup.Create(st, w, time.Now())
+	if err != nil {
+		return err
+	}
+	report(summary)
+	return nil
+}
+
+func runRestore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	path := flags.String("store", "", "store file to restore into")
+	in := flags.String("in", "", "backup file to read (default stdin)")
+	replace := flags.Bool("replace", false, "overwrite a store that is not empty")
+	flags.Parse(args)
+	if *path == "" {
+		return fmt.Errorf("-store is required")
+	}
+
+	r, err := input(*in)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	st, err := boltstore.Open(*path, boltstore.Options{})
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	summary, err := backup.Restore(st, r, *replace)
+	if err != nil {
+		return err
+	}
+	report(summary)
+	return nil
+}
+
+func runVerify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	in := flags.String("in", "", "backup file to check (default stdin)")
+	flags.Parse(args)
+
+	r, err := input(*in)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	b, _, err := backup.Read(r)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "backup taken %s is intact\n", b.CreatedAt.Format(time.RFC3339))
+	report(b.Summary)
+	return nil
+}
+
+// runReconcile checks every booking in a store against its checksum and
+// fails when any does not match. The System it reconciles is loaded from
+// the same store it compares against, so only checksum mismatches and
+// unsealed bookings can show up here: MISSING_FROM_STORE and
+// DIFFERS_FROM_STORE need a running system whose working copy has drifted
+// from its store.
+func runReconcile(args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	path := flags.String("store", "", "store file to check")
+	keys := flags.String("keys", "", "key file, for a store with encrypted passenger data")
+	flags.Parse(args)
+	if *path == "" {
+		return fmt.Errorf("-store is required")
+	}
+	if _, err := os.Stat(*path); err != nil {
+		return err
+	}
+
+	bolt, err := boltstore.Open(*path, boltstore.Options{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer bolt.Close()
+
+	// Checksums cover plaintext, so encrypted bookings are checked
+	// decrypted
+	var st store.Store = bolt
+	if *keys != "" {
+		provider, err := encryption.LoadLocalKeys(*keys)
+		if err != nil {
+			return err
+		}
+		st = encryption.WrapStore(bolt, encryption.NewCipher(provider))
+	}
+
+	rs, err := reservation.NewSystemWithStore(st)
+	if err != nil {
+		return err
+	}
+	report, err := rs.Reconcile()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d bookings checked, %d without checksum\n", report.Checked, report.Unsealed)
+	for _, issue := range report.Issues {
+		fmt.Printf("%s\t%s\t%s\n", issue.BookingID, issue.Code, issue.Detail)
+	}
+	if len(report.Issues) > 0 {
+		return fmt.Errorf("%d bookings failed integrity checks", len(report.Issues))
+	}
+	return nil
+}
+
+// runReencrypt rewrites passenger data under the key file's current key,
+// after a rotation or to encrypt a store written before encryption.
+func runReencrypt(args []string) error {
+	flags := flag.NewFlagSet("reencrypt", flag.ExitOnError)
+	path := flags.String("store", "", "store file to re-encrypt")
+	keys := flags.String("keys", "", "key file naming the current key")
+	flags.Parse(args)
+	if *path == "" || *keys == "" {
+		return fmt.Errorf("-store and -keys are required")
+	}
+
+	provider, err := encryption.LoadLocalKeys(*keys)
+	if err != nil {
+		return err
+	}
+	bolt, err := boltstore.Open(*path, boltstore.Options{})
+	if err != nil {
+		return err
+	}
+	defer bolt.Close()
+
+	rewritten, err := encryption.WrapStore(bolt, encryption.NewCipher(provider)).Reencrypt()
+	fmt.Fprintf(os.Stderr, "%d bookings and distribution records re-encrypted with key %s\n", rewritten, provider.CurrentKeyID())
+	return err
+}
+
+// runExport writes one CSV row per ticket for analytics, with passenger
+// names, contact details and document numbers masked.
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	path := flags.String("store", "", "store file to export")
+	keys := flags.String("keys", "", "key file, for a store with encrypted passenger data")
+	out := flags.String("out", "", "CSV file to write (default stdout)")
+	flags.Parse(args)
+	if *path == "" {
+		return fmt.Errorf("-store is required")
+	}
+	if _, err := os.Stat(*path); err != nil {
+		return err
+	}
+
+	bolt, err := boltstore.Open(*path, boltstore.Options{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer bolt.Close()
+
+	// Masking works on plaintext; without keys, encrypted values would be
+	// exported as ciphertext
+	var st store.Store = bolt
+	if *keys != "" {
+		provider, err := encryption.LoadLocalKeys(*keys)
+		if err != nil {
+			return err
+		}
+		st = encryption.WrapStore(bolt, encryption.NewCipher(provider))
+	}
+	// Bookings that cannot be decrypted are left out and listed, rather
+	// than holding back the whole export
+	bookings, err := st.Bookings()
+	var unreadable *store.UnreadableError
+	if errors.As(err, &unreadable) {
+		fmt.Fprintf(os.Stderr, "skipping %v\n", err)
+	} else if err != nil {
+		return err
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := redact.WriteBookingsCSV(w, bookings, redact.Policy{}); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d bookings exported\n", len(bookings))
+	return nil
+}
+
+func input(path string) (io.ReadCloser, error) {
+	if path == "" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// report goes to stderr so a backup written to stdout stays clean.
+func report(summary backup.Summary) {
+	fmt.Fprintf(os.Stderr, "%d routes, %d services, %d departures, %d bookings\n",
+		summary.Routes, summary.Services, len(summary.Departures), summary.Bookings)
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"ticketing-app/pkg/audit"
 	"ticketing-app/pkg/domain"
 	"ticketing-app/pkg/reservation"
+	"ticketing-app/pkg/scheduler"
 	"ticketing-app/pkg/testdata"
 	"time"
 )
@@ -13,6 +16,14 @@ func main() {
 	
 	rs := testdata.SetupTestData()
 	
+	// Housekeeping runs for as long as the system is up, as in a server
+	jobs := scheduler.New()
+	jobs.SetLogger(rs.Logger())
+	rs.ScheduleJobs(jobs)
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go jobs.Run(ctx)
+	
 	runTestScenarios(rs)
 	
 	runConductorQueries(rs)
@@ -136,38 +147,67 @@ func runTestScenarios(rs *reservation.System) {
 func runConductorQueries(rs *reservation.System) {
 	fmt.Println("\n=== Conductor Queries ===")
 	
+	conductor := rs.QueryAs(audit.Caller{UserID: "conductor-demo", Role: audit.Conductor})
+	
 	fmt.Println("\n1. Passengers boarding at London:")
-	passengers := rs.GetPassengersBoardingAt("5160", "London", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
-	fmt.Printf("   Passengers boarding at London on service 5160: %d\n", len(passengers))
+	passengers, err := conductor.GetPassengersBoardingAt("5160", "London", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		fmt.Printf("   Lookup failed: %v\n", err)
+	} else {
+		fmt.Printf("   Passengers boarding at London on service 5160: %d\n", len(passengers))
+	}
 	
-	passengers = rs.GetPassengersBoardingAt("5160", "Paris", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
-	fmt.Printf("   Passengers boarding at Paris on service 5160: %d\n", len(passengers))
-	for _, p := range passengers {
-		fmt.Printf("     - %s\n", p.Name)
+	passengers, err = conductor.GetPassengersBoardingAt("5160", "Paris", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		fmt.Printf("   Lookup failed: %v\n", err)
+	} else {
+		fmt.Printf("   Passengers boarding at Paris on service 5160: %d\n", len(passengers))
+		for _, p := range passengers {
+			fmt.Printf("     - %s\n", p.Name)
+		}
 	}
 	
 	fmt.Println("\n2. Passengers leaving at Paris:")
-	passengers = rs.GetPassengersAlightingAt("5160", "Paris", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
-	fmt.Printf("   Passengers leaving at Paris on service 5160: %d\n", len(passengers))
+	passengers, err = conductor.GetPassengersAlightingAt("5160", "Paris", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		fmt.Printf("   Lookup failed: %v\n", err)
+	} else {
+		fmt.Printf("   Passengers leaving at Paris on service 5160: %d\n", len(passengers))
+	}
 	
-	passengers = rs.GetPassengersAlightingAt("5160", "Amsterdam", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
-	fmt.Printf("   Passengers leaving at Amsterdam on service 5160: %d\n", len(passengers))
-	for _, p := range passengers {
-		fmt.Printf("     - %s\n", p.Name)
+	passengers, err = conductor.GetPassengersAlightingAt("5160", "Amsterdam", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		fmt.Printf("   Lookup failed: %v\n", err)
+	} else {
+		fmt.Printf("   Passengers leaving at Amsterdam on service 5160: %d\n", len(passengers))
+		for _, p := range passengers {
+			fmt.Printf("     - %s\n", p.Name)
+		}
 	}
 	
 	fmt.Println("\n3. Passengers traveling between Calais and Paris:")
-	passengers = rs.GetPassengersBetweenStations("5160", "Calais", "Amsterdam", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
-	fmt.Printf("   Passengers traveling between Calais and Amsterdam on service 5160: %d\n", len(passengers))
-	for _, p := range passengers {
-		fmt.Printf("     - %s\n", p.Name)
+	passengers, err = conductor.GetPassengersBetweenStations("5160", "Calais", "Amsterdam", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		fmt.Printf("   Lookup failed: %v\n", err)
+	} else {
+		fmt.Printf("   Passengers traveling between Calais and Amsterdam on service 5160: %d\n", len(passengers))
+		for _, p := range passengers {
+			fmt.Printf("     - %s\n", p.Name)
+		}
 	}
 	
 	fmt.Println("\n4. Passenger on seat A11 in service 5161 on December 20th:")
-	passenger, found := rs.GetPassengerOnSeat("5161", "A", "A11", time.Date(2021, 12, 20, 0, 0, 0, 0, time.UTC))
-	if found {
+	passenger, found, err := conductor.GetPassengerOnSeat("5161", "A", "A11", time.Date(2021, 12, 20, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		fmt.Printf("   Lookup failed: %v\n", err)
+	} else if found {
 		fmt.Printf("   Passenger on seat A11: %s\n", passenger.Name)
 	} else {
 		fmt.Printf("   No passenger found on seat A11\n")
 	}
+	
+	fmt.Println("\n5. Audit trail of service 5160 on April 1st:")
+	for _, entry := range rs.AuditLog().ByDeparture("5160", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		fmt.Printf("   %s %s: %s %s (%d results)\n", entry.UserID, entry.Role, entry.Query, entry.Detail, entry.Results)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type AuditError struct {
+	Message string
+	Code    string
+}
+
+func (e AuditError) Error() string {
+	return e.Message
+}
+
+type Role string
+
+const (
+	Conductor    Role = "conductor"
+	Agent        Role = "agent"
+	StationStaff Role = "station-staff"
+	Analytics    Role = "analytics"
+)
+
+// Caller is whoever looks up passenger data: a conductor on board, an
+// agent in a contact centre, station staff or an analytics consumer.
+type Caller struct {
+	UserID string
+	Role   Role
+}
+
+// Entry records one lookup of passenger data on a departure.
+type Entry struct {
+	Time      time.Time
+	UserID    string
+	Role      Role
+	Query     string
+	ServiceID string
+	Date      string // the departure's date, 2006-01-02; empty for lookups across departures
+	Detail    string `json:",omitempty"` // station, seat or section queried
+	Results   int
+}
+
+// DefaultMemoryLimit is how many entries a Log keeps in memory unless
+// SetMemoryLimit says otherwise.
+const DefaultMemoryLimit = 100000
+
+// Log keeps the most recent entries in memory for queries and, when a
+// writer is set, appends each one to it as a JSON line. Retention is the
+// writer's job: once more entries than the memory limit have been
+// recorded, the oldest are only in the writer, and ByDeparture and ByUser
+// no longer return them.
+type Log struct {
+	mu      sync.RWMutex
+	entries []Entry
+	limit   int
+	w       io.Writer
+}
+
+func NewLog() *Log {
+	return &Log{limit: DefaultMemoryLimit}
+}
+
+// SetMemoryLimit bounds how many entries are kept in memory, dropping the
+// oldest beyond it.
+func (l *Log) SetMemoryLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = limit
+	l.trim()
+}
+
+func (l *Log) SetWriter(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.w = w
+}
+
+// Record adds an entry. An entry that cannot be written is not kept, and
+// the caller should withhold the data it was about to return.
+func (l *Log) Record(entry Entry) error {
+	if entry.UserID == "" {
+		return AuditError{Message: "Lookups of passenger data need an identified caller", Code: "UNKNOWN_CALLER"}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.w != nil {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit entry: %w", err)
+		}
+		if _, err := l.w.Write(append(line, '\n')); err != nil {
+			return AuditError{Message: fmt.Sprintf("Audit log unavailable: %v", err), Code: "AUDIT_UNAVAILABLE"}
+		}
+	}
+	l.entries = append(l.entries, entry)
+	l.trim()
+	return nil
+}
+
+// trim reslices rather than copies, so append reallocates once the
+// dropped entries fill the backing array and they are released then.
+func (l *Log) trim() {
+	if len(l.entries) > l.limit {
+		l.entries = l.entries[len(l.entries)-l.limit:]
+	}
+}
+
+// ByDeparture lists who looked up a departure, oldest first.
+func (l *Log) ByDeparture(serviceID string, date time.Time) []Entry {
+	day := date.Format("2006-01-02")
+	return l.filter(func(e Entry) bool { return e.ServiceID == serviceID && e.Date == day })
+}
+
+// ByUser lists a user's lookups made in [from, to), oldest first.
+func (l *Log) ByUser(userID string, from, to time.Time) []Entry {
+	return l.filter(func(e Entry) bool {
+		return e.UserID == userID && !e.Time.Before(from) && e.Time.Before(to)
+	})
+}
+
+func (l *Log) filter(match func(Entry) bool) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var entries []Entry
+	for _, entry := range l.entries {
+		if match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLog_WritesJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLog()
+	log.SetWriter(&buf)
+
+	at := time.Date(2021, 4, 1, 7, 30, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: at, UserID: "c-042", Role: Conductor, Query: "manifest", ServiceID: "5160", Date: "2021-04-01", Results: 12},
+		{Time: at.Add(time.Minute), UserID: "a-007", Role: Agent, Query: "seat", ServiceID: "5160", Date: "2021-04-01", Detail: "A/A1", Results: 1},
+	}
+	for _, entry := range entries {
+		if err := log.Record(entry); err != nil {
+			t.Fatalf("Failed to record: %v", err)
+		}
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	var written []Entry
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Expected a JSON entry per line, got %q: %v", scanner.Text(), err)
+		}
+		written = append(written, entry)
+	}
+	if len(written) != 2 || written[1] != entries[1] {
+		t.Errorf("Expected both entries written, got %+v", written)
+	}
+
+	if byDeparture := log.ByDeparture("5160", at); len(byDeparture) != 2 {
+		t.Errorf("Expected 2 entries for the departure, got %d", len(byDeparture))
+	}
+}
+
+func TestLog_KeepsOnlyTheMostRecentEntriesInMemory(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLog()
+	log.SetWriter(&buf)
+	log.SetMemoryLimit(3)
+
+	at := time.Date(2021, 4, 1, 7, 30, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		entry := Entry{Time: at.Add(time.Duration(i) * time.Minute), UserID: "c-042", Role: Conductor, Query: "manifest", ServiceID: "5160", Date: "2021-04-01"}
+		if err := log.Record(entry); err != nil {
+			t.Fatalf("Failed to record: %v", err)
+		}
+	}
+
+	kept := log.ByDeparture("5160", at)
+	if len(kept) != 3 || !kept[0].Time.Equal(at.Add(2*time.Minute)) {
+		t.Errorf("Expected the 3 most recent entries in memory, got %+v", kept)
+	}
+	if written := bytes.Count(buf.Bytes(), []byte("\n")); written != 5 {
+		t.Errorf("Expected every entry written, got %d", written)
+	}
+}
@@ -0,0 +1,375 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"ticketing-app/pkg/store"
+	"time"
+)
+
+// Format identifies backup files; Version is bumped whenever the layout of
+// Data changes, and Read refuses versions it does not know. Version 2 added
+// couplings and version 3 stations; older backups read as having none.
+const (
+	Format  = "ticketing-backup"
+	Version = 3
+)
+
+type BackupError struct {
+	Message string
+	Code    string
+}
+
+func (e BackupError) Error() string {
+	return e.Message
+}
+
+// Departure is one service on one date with at least one booked ticket.
+// Departures are not stored separately; they are listed in the backup so
+// a restore can check none went missing.
+type Departure struct {
+	ServiceID string
+	Date      string
+}
+
+type Summary struct {
+	Routes     int
+	Stations   int
+	Services   int
+	Couplings  int
+	Bookings   int
+	Departures []Departure
+}
+
+// Backup is the file layout. Checksum is the SHA-256 of Data in compact
+// JSON, so reformatting the file does not invalidate it.
+type Backup struct {
+	Format    string
+	Version   int
+	CreatedAt time.Time
+	Summary   Summary
+	Checksum  string
+	Data      json.RawMessage
+}
+
+// Create writes a snapshot of the store. Stores implementing
+// store.Snapshotter are read in one consistent view; others are read one
+// collection at a time, so they should not be written to meanwhile.
+func Create(st store.Store, w io.Writer, now time.Time) (Summary, error) {
+	snapshot, err := read(st)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return Summary{}, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	backup := Backup{
+		Format:    Format,
+		Version:   Version,
+		CreatedAt: now.UTC(),
+		Summary:   summarize(snapshot),
+		Checksum:  checksum(data),
+		Data:      data,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(backup); err != nil {
+		return Summary{}, fmt.Errorf("failed to write backup: %w", err)
+	}
+	return backup.Summary, nil
+}
+
+// Read decodes a backup and verifies it: format and version, checksum,
+// the recorded summary, and that every ticket refers to a service in it.
+func Read(r io.Reader) (Backup, store.Snapshot, error) {
+	var backup Backup
+	if err := json.NewDecoder(r).Decode(&backup); err != nil {
+		return Backup{}, store.Snapshot{}, BackupError{
+			Message: fmt.Sprintf("Backup is not readable: %v", err),
+			Code:    "BACKUP_UNREADABLE",
+		}
+	}
+	if backup.Format != Format {
+		return Backup{}, store.Snapshot{}, BackupError{
+			Message: fmt.Sprintf("Not a %s file", Format),
+			Code:    "BACKUP_UNREADABLE",
+		}
+	}
+	if backup.Version < 1 || backup.Version > Version {
+		return Backup{}, store.Snapshot{}, BackupError{
+			Message: fmt.Sprintf("Backup version %d is not supported, expected %d", backup.Version, Version),
+			Code:    "UNSUPPORTED_VERSION",
+		}
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, backup.Data); err != nil {
+		return Backup{}, store.Snapshot{}, BackupError{
+			Message: fmt.Sprintf("Backup data is not readable: %v", err),
+			Code:    "BACKUP_UNREADABLE",
+		}
+	}
+	if sum := checksum(compact.Bytes()); sum != backup.Checksum {
+		return Backup{}, store.Snapshot{}, BackupError{
+			Message: fmt.Sprintf("Backup checksum %s does not match its data (%s)", backup.Checksum, sum),
+			Code:    "CHECKSUM_MISMATCH",
+		}
+	}
+
+	var snapshot store.Snapshot
+	if err := json.Unmarshal(compact.Bytes(), &snapshot); err != nil {
+		return Backup{}, store.Snapshot{}, BackupError{
+			Message: fmt.Sprintf("Backup data is not readable: %v", err),
+			Code:    "BACKUP_UNREADABLE",
+		}
+	}
+	if err := verify(backup.Summary, snapshot); err != nil {
+		return Backup{}, store.Snapshot{}, err
+	}
+	return backup, snapshot, nil
+}
+
+// Restore verifies a backup and writes it to the store. The store must be
+// empty unless replace is set, in which case whatever is missing from the
+// backup is deleted. Stores implementing store.Restorer are replaced in
+// one transaction, so a failed restore leaves them unchanged. Others are
+// written one record at a time; if that fails part way, the error is a
+// PARTIAL_RESTORE telling how far it got, and restoring again with replace
+// completes it.
+//
+// Point-in-time restore is not implemented: restores go back to the moment
+// the backup was taken, as no store keeps a log of changes that could be
+// replayed to a later point.
+func Restore(st store.Store, r io.Reader, replace bool) (Summary, error) {
+	backup, snapshot, err := Read(r)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	existing, err := read(st)
+	if err != nil {
+		return Summary{}, err
+	}
+	if !replace && (len(existing.Routes) > 0 || len(existing.Stations) > 0 || len(existing.Services) > 0 || len(existing.Couplings) > 0 || len(existing.Bookings) > 0) {
+		return Summary{}, BackupError{
+			Message: "Target store is not empty; restore with replace to overwrite it",
+			Code:    "STORE_NOT_EMPTY",
+		}
+	}
+
+	if restorer, ok := st.(store.Restorer); ok {
+		if err := restorer.Restore(snapshot); err != nil {
+			return Summary{}, BackupError{
+				Message: fmt.Sprintf("Restore failed and the store was left unchanged: %v", err),
+				Code:    "RESTORE_FAILED",
+			}
+		}
+		return backup.Summary, nil
+	}
+
+	var written Summary
+	if err := restoreRecords(st, snapshot, existing, &written); err != nil {
+		return Summary{}, BackupError{
+			Message: fmt.Sprintf("Restore stopped after writing %s of %s: %v; the store holds part of the backup, restore again with replace to complete it",
+				describe(written), describe(backup.Summary), err),
+			Code: "PARTIAL_RESTORE",
+		}
+	}
+	return backup.Summary, nil
+}
+
+// restoreRecords writes a snapshot record by record, counting what it
+// wrote, then deletes existing records the snapshot does not have.
+func restoreRecords(st store.Store, snapshot, existing store.Snapshot, written *Summary) error {
+	for _, route := range snapshot.Routes {
+		if err := st.SaveRoute(route); err != nil {
+			return err
+		}
+		written.Routes++
+	}
+	for _, station := range snapshot.Stations {
+		if err := st.SaveStation(station); err != nil {
+			return err
+		}
+		written.Stations++
+	}
+	for _, service := range snapshot.Services {
+		if err := st.SaveService(service); err != nil {
+			return err
+		}
+		written.Services++
+	}
+	for _, coupling := range snapshot.Couplings {
+		if err := st.SaveCoupling(coupling); err != nil {
+			return err
+		}
+		written.Couplings++
+	}
+	for _, booking := range snapshot.Bookings {
+		if err := st.SaveBooking(booking); err != nil {
+			return err
+		}
+		written.Bookings++
+	}
+
+	keep := make(map[string]bool)
+	for _, booking := range snapshot.Bookings {
+		keep["booking/"+booking.ID] = true
+	}
+	for _, coupling := range snapshot.Couplings {
+		keep["coupling/"+coupling.Key()] = true
+	}
+	for _, service := range snapshot.Services {
+		keep["service/"+service.ID] = true
+	}
+	for _, station := range snapshot.Stations {
+		keep["station/"+station.Name] = true
+	}
+	for _, route := range snapshot.Routes {
+		keep["route/"+route.ID] = true
+	}
+	// Dependents go first, so nothing is left referring to a deleted record
+	for _, booking := range existing.Bookings {
+		if !keep["booking/"+booking.ID] {
+			if err := st.DeleteBooking(booking.ID); err != nil {
+				return err
+			}
+		}
+	}
+	for _, coupling := range existing.Couplings {
+		if !keep["coupling/"+coupling.Key()] {
+			if err := st.DeleteCoupling(coupling.Key()); err != nil {
+				return err
+			}
+		}
+	}
+	for _, service := range existing.Services {
+		if !keep["service/"+service.ID] {
+			if err := st.DeleteService(service.ID); err != nil {
+				return err
+			}
+		}
+	}
+	for _, station := range existing.Stations {
+		if !keep["station/"+station.Name] {
+			if err := st.DeleteStation(station.Name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, route := range existing.Routes {
+		if !keep["route/"+route.ID] {
+			if err := st.DeleteRoute(route.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func read(st store.Store) (store.Snapshot, error) {
+	if snapshotter, ok := st.(store.Snapshotter); ok {
+		return snapshotter.Snapshot()
+	}
+
+	var snapshot store.Snapshot
+	var err error
+	if snapshot.Routes, err = st.Routes(); err != nil {
+		return store.Snapshot{}, err
+	}
+	if snapshot.Stations, err = st.Stations(); err != nil {
+		return store.Snapshot{}, err
+	}
+	if snapshot.Services, err = st.Services(); err != nil {
+		return store.Snapshot{}, err
+	}
+	if snapshot.Couplings, err = st.Couplings(); err != nil {
+		return store.Snapshot{}, err
+	}
+	if snapshot.Bookings, err = st.Bookings(); err != nil {
+		return store.Snapshot{}, err
+	}
+	return snapshot, nil
+}
+
+func summarize(snapshot store.Snapshot) Summary {
+	seen := make(map[Departure]bool)
+	var departures []Departure
+	for _, booking := range snapshot.Bookings {
+		for _, ticket := range booking.Tickets {
+			departure := Departure{ServiceID: ticket.Service.ID, Date: ticket.Service.DateTime.Format("2006-01-02")}
+			if !seen[departure] {
+				seen[departure] = true
+				departures = append(departures, departure)
+			}
+		}
+	}
+	sort.Slice(departures, func(i, j int) bool {
+		if departures[i].Date != departures[j].Date {
+			return departures[i].Date < departures[j].Date
+		}
+		return departures[i].ServiceID < departures[j].ServiceID
+	})
+
+	return Summary{
+		Routes:     len(snapshot.Routes),
+		Stations:   len(snapshot.Stations),
+		Services:   len(snapshot.Services),
+		Couplings:  len(snapshot.Couplings),
+		Bookings:   len(snapshot.Bookings),
+		Departures: departures,
+	}
+}
+
+func verify(recorded Summary, snapshot store.Snapshot) error {
+	actual := summarize(snapshot)
+	if actual.Routes != recorded.Routes || actual.Stations != recorded.Stations || actual.Services != recorded.Services || actual.Couplings != recorded.Couplings ||
+		actual.Bookings != recorded.Bookings || len(actual.Departures) != len(recorded.Departures) {
+		return BackupError{
+			Message: fmt.Sprintf("Backup contents %s do not match its summary %s", describe(actual), describe(recorded)),
+			Code:    "SUMMARY_MISMATCH",
+		}
+	}
+	for i := range actual.Departures {
+		if actual.Departures[i] != recorded.Departures[i] {
+			return BackupError{
+				Message: fmt.Sprintf("Departure %s on %s is not in the backup", recorded.Departures[i].ServiceID, recorded.Departures[i].Date),
+				Code:    "SUMMARY_MISMATCH",
+			}
+		}
+	}
+
+	services := make(map[string]bool, len(snapshot.Services))
+	for _, service := range snapshot.Services {
+		services[service.ID] = true
+	}
+	for _, booking := range snapshot.Bookings {
+		for _, ticket := range booking.Tickets {
+			if !services[ticket.Service.ID] {
+				return BackupError{
+					Message: fmt.Sprintf("Booking %s refers to service %s, which is not in the backup", booking.ID, ticket.Service.ID),
+					Code:    "DANGLING_REFERENCE",
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func describe(s Summary) string {
+	return fmt.Sprintf("(%d routes, %d stations, %d services, %d couplings, %d bookings, %d departures)",
+		s.Routes, s.Stations, s.Services, s.Couplings, s.Bookings, len(s.Departures))
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,221 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"ticketing-app/pkg/audit"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/reservation"
+	"ticketing-app/pkg/store"
+	"ticketing-app/pkg/store/boltstore"
+	"ticketing-app/pkg/testdata"
+	"time"
+)
+
+// collections hides the Snapshot method, to exercise stores read one
+// collection at a time.
+type collections struct {
+	store.Store
+}
+
+func setupStore(t *testing.T) *store.Memory {
+	st := store.NewMemory()
+	rs, err := testdata.SetupTestDataWithStore(st)
+	if err != nil {
+		t.Fatalf("Failed to set up system: %v", err)
+	}
+	for _, seat := range []string{"A1", "A2"} {
+		_, err := rs.MakeReservation(domain.ReservationRequest{
+			ServiceID:    "5160",
+			Origin:       "Paris",
+			Destination:  "Amsterdam",
+			Passengers:   []domain.Passenger{{Name: "John Doe"}},
+			SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: seat}},
+			Date:         time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test booking: %v", err)
+		}
+	}
+	// Written straight to the store: the test data's services share
+	// carriage IDs, which AddCoupling would refuse
+	if err := st.SaveCoupling(domain.Coupling{ServiceIDs: []string{"5160", "5161"}, From: "Paris", To: "Calais"}); err != nil {
+		t.Fatalf("Failed to save coupling: %v", err)
+	}
+	return st
+}
+
+func createBackup(t *testing.T, st store.Store) []byte {
+	var buf bytes.Buffer
+	if _, err := Create(st, &buf, time.Date(2021, 3, 31, 23, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Failed to create backup: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	source := setupStore(t)
+
+	targets := []struct {
+		name   string
+		source store.Store
+		open   func(t *testing.T) store.Store
+	}{
+		{"memory", source, func(t *testing.T) store.Store { return store.NewMemory() }},
+		{"store without snapshots", collections{source}, func(t *testing.T) store.Store { return collections{store.NewMemory()} }},
+		{"bolt", source, func(t *testing.T) store.Store {
+			st, err := boltstore.Open(filepath.Join(t.TempDir(), "tickets.db"), boltstore.Options{})
+			if err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+			t.Cleanup(func() { st.Close() })
+			return st
+		}},
+	}
+
+	for _, tt := range targets {
+		t.Run(tt.name, func(t *testing.T) {
+			data := createBackup(t, tt.source)
+			target := tt.open(t)
+
+			summary, err := Restore(target, bytes.NewReader(data), false)
+			if err != nil {
+				t.Fatalf("Failed to restore: %v", err)
+			}
+			if summary.Bookings != 2 || len(summary.Departures) != 1 {
+				t.Errorf("Expected 2 bookings on 1 departure, got %+v", summary)
+			}
+
+			rs, err := reservation.NewSystemWithStore(target)
+			if err != nil {
+				t.Fatalf("Failed to load restored store: %v", err)
+			}
+			agent := rs.QueryAs(audit.Caller{UserID: "a-test", Role: audit.Agent})
+			if bookings, err := agent.GetAllBookings(); err != nil || len(bookings) != 2 {
+				t.Errorf("Expected 2 restored bookings, got %d (err %v)", len(bookings), err)
+			}
+			if _, found, _ := agent.GetPassengerOnSeat("5160", "A", "A2", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)); !found {
+				t.Error("Expected the restored booking to hold seat A2")
+			}
+			if _, found := rs.GetCoupling("5161"); !found {
+				t.Error("Expected the coupling to be restored")
+			}
+		})
+	}
+}
+
+func TestRestoreReplace(t *testing.T) {
+	data := createBackup(t, setupStore(t))
+	
+	targets := []struct {
+		name string
+		wrap func(st *store.Memory) store.Store
+	}{
+		{"in one transaction", func(st *store.Memory) store.Store { return st }},
+		{"record by record", func(st *store.Memory) store.Store { return collections{st} }},
+	}
+	
+	for _, tt := range targets {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := setupStore(t)
+			memory.SaveBooking(domain.Booking{ID: "B9999"})
+			memory.SaveRoute(domain.Route{ID: "R999"})
+			memory.SaveService(domain.Service{ID: "9999"})
+			target := tt.wrap(memory)
+			
+			_, err := Restore(target, bytes.NewReader(data), false)
+			var backupErr BackupError
+			if !errors.As(err, &backupErr) || backupErr.Code != "STORE_NOT_EMPTY" {
+				t.Fatalf("Expected STORE_NOT_EMPTY, got %v", err)
+			}
+			
+			if _, err := Restore(target, bytes.NewReader(data), true); err != nil {
+				t.Fatalf("Failed to restore: %v", err)
+			}
+			if _, found, _ := target.GetBooking("B9999"); found {
+				t.Error("Expected a booking missing from the backup to be deleted")
+			}
+			routes, _ := target.Routes()
+			services, _ := target.Services()
+			if len(routes) != 3 || len(services) != 3 {
+				t.Errorf("Expected routes and services missing from the backup to be deleted, got %d routes and %d services", len(routes), len(services))
+			}
+		})
+	}
+}
+
+// failingBookings fails every booking write after the first while broken.
+type failingBookings struct {
+	store.Store
+	broken bool
+	saved  int
+}
+
+func (s *failingBookings) SaveBooking(booking domain.Booking) error {
+	if s.broken && s.saved > 0 {
+		return errors.New("disk full")
+	}
+	s.saved++
+	return s.Store.SaveBooking(booking)
+}
+
+func TestRestoreReportsPartialRestore(t *testing.T) {
+	data := createBackup(t, setupStore(t))
+	target := &failingBookings{Store: store.NewMemory(), broken: true}
+	
+	_, err := Restore(target, bytes.NewReader(data), false)
+	var backupErr BackupError
+	if !errors.As(err, &backupErr) || backupErr.Code != "PARTIAL_RESTORE" {
+		t.Fatalf("Expected PARTIAL_RESTORE, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "1 bookings") || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Expected the error to tell how far the restore got and why it stopped, got %v", err)
+	}
+	
+	target.broken = false
+	if _, err := Restore(target, bytes.NewReader(data), true); err != nil {
+		t.Fatalf("Expected restoring again with replace to complete the restore, got %v", err)
+	}
+	if bookings, _ := target.Bookings(); len(bookings) != 2 {
+		t.Errorf("Expected both bookings after completing the restore, got %d", len(bookings))
+	}
+}
+
+func TestReadRejectsDamagedBackups(t *testing.T) {
+	data := string(createBackup(t, setupStore(t)))
+
+	tests := []struct {
+		name   string
+		damage func(string) string
+		code   string
+	}{
+		{"truncated", func(s string) string { return s[:len(s)/2] }, "BACKUP_UNREADABLE"},
+		{"other format", func(s string) string { return strings.Replace(s, Format, "something-else", 1) }, "BACKUP_UNREADABLE"},
+		{"newer version", func(s string) string { return strings.Replace(s, fmt.Sprintf(`"Version": %d`, Version), `"Version": 99`, 1) }, "UNSUPPORTED_VERSION"},
+		{"edited data", func(s string) string { return strings.Replace(s, "John Doe", "Jane Doe", 1) }, "CHECKSUM_MISMATCH"},
+		{"edited summary", func(s string) string { return strings.Replace(s, `"Bookings": 2`, `"Bookings": 3`, 1) }, "SUMMARY_MISMATCH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Read(strings.NewReader(tt.damage(data)))
+			var backupErr BackupError
+			if !errors.As(err, &backupErr) || backupErr.Code != tt.code {
+				t.Errorf("Expected %s, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestReadIgnoresFormatting(t *testing.T) {
+	data := createBackup(t, setupStore(t))
+	reformatted := strings.ReplaceAll(string(data), "\n    ", "\n\t")
+
+	if _, _, err := Read(strings.NewReader(reformatted)); err != nil {
+		t.Errorf("Expected a reindented backup to verify, got %v", err)
+	}
+}
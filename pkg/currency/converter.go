@@ -0,0 +1,66 @@
+package currency
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+// Conversion is the audit record of one conversion: the amount as sold, the
+// amount shown or refunded, and the rate used with its timestamps. Callers
+// keep it alongside the quote, refund or report that needed it.
+type Conversion struct {
+	Original  domain.Money
+	Converted domain.Money
+	Rate      Rate
+}
+
+// Converter expresses amounts in another currency than the fare was sold
+// in. A zero MaxAge accepts rates of any age.
+type Converter struct {
+	provider RateProvider
+	MaxAge   time.Duration
+	now      func() time.Time
+}
+
+func NewConverter(provider RateProvider) *Converter {
+	return &Converter{provider: provider, now: time.Now}
+}
+
+func (c *Converter) SetClock(now func() time.Time) {
+	c.now = now
+}
+
+func (c *Converter) Convert(amount domain.Money, currency string) (Conversion, error) {
+	from, to := strings.ToUpper(amount.Currency), strings.ToUpper(currency)
+	if from == to {
+		return Conversion{
+			Original:  amount,
+			Converted: amount,
+			Rate:      Rate{From: from, To: to, Value: 1, Source: "identity"},
+		}, nil
+	}
+
+	rate, err := c.provider.Rate(from, to)
+	if err != nil {
+		return Conversion{}, err
+	}
+	if c.MaxAge > 0 && c.now().Sub(rate.AsOf) > c.MaxAge {
+		return Conversion{}, CurrencyError{
+			Message: fmt.Sprintf("%s rate %s/%s from %s is older than %s", rate.Source, from, to, rate.AsOf.Format(time.RFC3339), c.MaxAge),
+			Code:    "STALE_RATE",
+		}
+	}
+
+	// Scale between minor units, then round half away from zero
+	scale := math.Pow10(domain.MinorUnits(to) - domain.MinorUnits(from))
+	converted := math.Round(float64(amount.Amount) * rate.Value * scale)
+
+	return Conversion{
+		Original:  amount,
+		Converted: domain.Money{Amount: int64(converted), Currency: to},
+		Rate:      rate,
+	}, nil
+}
@@ -0,0 +1,71 @@
+package currency
+
+import (
+	"errors"
+	"testing"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+func TestConverter_Convert(t *testing.T) {
+	asOf := time.Date(2021, 3, 31, 16, 0, 0, 0, time.UTC)
+	provider := NewStaticProvider("EUR", asOf, map[string]float64{"GBP": 0.85, "USD": 1.25, "JPY": 130})
+	converter := NewConverter(provider)
+	
+	tests := []struct {
+		amount   domain.Money
+		to       string
+		expected domain.Money
+	}{
+		{domain.Money{Amount: 10000, Currency: "EUR"}, "GBP", domain.Money{Amount: 8500, Currency: "GBP"}},
+		{domain.Money{Amount: 8500, Currency: "GBP"}, "EUR", domain.Money{Amount: 10000, Currency: "EUR"}},
+		{domain.Money{Amount: 1000, Currency: "GBP"}, "USD", domain.Money{Amount: 1471, Currency: "USD"}},
+		{domain.Money{Amount: 1999, Currency: "EUR"}, "JPY", domain.Money{Amount: 2599, Currency: "JPY"}},
+		{domain.Money{Amount: 4200, Currency: "EUR"}, "eur", domain.Money{Amount: 4200, Currency: "EUR"}},
+	}
+	
+	for _, test := range tests {
+		conversion, err := converter.Convert(test.amount, test.to)
+		if err != nil {
+			t.Fatalf("Convert(%s, %s) failed: %v", test.amount, test.to, err)
+		}
+		if conversion.Converted != test.expected {
+			t.Errorf("Convert(%s, %s) = %s, expected %s", test.amount, test.to, conversion.Converted, test.expected)
+		}
+	}
+}
+
+func TestConverter_RecordsRateForAudit(t *testing.T) {
+	asOf := time.Date(2021, 3, 31, 16, 0, 0, 0, time.UTC)
+	converter := NewConverter(NewStaticProvider("EUR", asOf, map[string]float64{"GBP": 0.85}))
+	
+	conversion, err := converter.Convert(domain.Money{Amount: 100, Currency: "EUR"}, "GBP")
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if conversion.Rate.Value != 0.85 || conversion.Rate.Source != "static" || !conversion.Rate.AsOf.Equal(asOf) {
+		t.Errorf("Expected the static 0.85 rate as of %s, got %+v", asOf, conversion.Rate)
+	}
+	if conversion.Original.Amount != 100 {
+		t.Errorf("Expected the original amount to be kept, got %s", conversion.Original)
+	}
+}
+
+func TestConverter_Errors(t *testing.T) {
+	asOf := time.Date(2021, 3, 31, 16, 0, 0, 0, time.UTC)
+	converter := NewConverter(NewStaticProvider("EUR", asOf, map[string]float64{"GBP": 0.85}))
+	converter.MaxAge = 48 * time.Hour
+	
+	converter.SetClock(func() time.Time { return asOf.Add(24 * time.Hour) })
+	_, err := converter.Convert(domain.Money{Amount: 100, Currency: "EUR"}, "CHF")
+	var currencyErr CurrencyError
+	if !errors.As(err, &currencyErr) || currencyErr.Code != "RATE_UNAVAILABLE" {
+		t.Errorf("Expected RATE_UNAVAILABLE, got %v", err)
+	}
+	
+	converter.SetClock(func() time.Time { return asOf.Add(72 * time.Hour) })
+	_, err = converter.Convert(domain.Money{Amount: 100, Currency: "EUR"}, "GBP")
+	if !errors.As(err, &currencyErr) || currencyErr.Code != "STALE_RATE" {
+		t.Errorf("Expected STALE_RATE, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package currency
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ECBDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ecbEnvelope matches the reference rate feed:
+//
+//	<gesmes:Envelope>
+//	  <Cube><Cube time="2024-01-02"><Cube currency="USD" rate="1.0956"/>...
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECB reads the most recent day of an ECB euro reference rate feed.
+func ParseECB(r io.Reader) (Table, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return Table{}, fmt.Errorf("failed to parse ECB feed: %w", err)
+	}
+	if len(envelope.Days) == 0 {
+		return Table{}, fmt.Errorf("failed to parse ECB feed: no rates published")
+	}
+
+	day := envelope.Days[0]
+	asOf, err := time.Parse("2006-01-02", day.Time)
+	if err != nil {
+		return Table{}, fmt.Errorf("failed to parse ECB feed date %q: %w", day.Time, err)
+	}
+
+	table := Table{Base: "EUR", Source: "ECB", AsOf: asOf, Rates: make(map[string]float64)}
+	for _, rate := range day.Rates {
+		value, err := strconv.ParseFloat(rate.Rate, 64)
+		if err != nil {
+			return Table{}, fmt.Errorf("failed to parse ECB rate for %s: %w", rate.Currency, err)
+		}
+		table.Rates[strings.ToUpper(rate.Currency)] = value
+	}
+	return table, nil
+}
+
+// ECBProvider serves the ECB's daily reference rates. The feed changes once
+// per working day, so it is fetched at most once per refresh interval; if a
+// refresh fails the previous rates keep being served, the feed is not tried
+// again before the retry interval, and the converter's maximum age decides
+// whether the rates are still acceptable. The feed is fetched without
+// holding the provider's lock, so a slow feed only delays the caller that
+// fetches it, and callers that have no rates yet.
+type ECBProvider struct {
+	URL     string
+	Client  *http.Client
+	Refresh time.Duration
+	Retry   time.Duration
+
+	mu        sync.Mutex
+	now       func() time.Time
+	table     Table
+	attempted time.Time
+	lastErr   error
+	fetching  chan struct{} // closed when the fetch in flight completes
+}
+
+func NewECBProvider() *ECBProvider {
+	return &ECBProvider{
+		URL:     ECBDailyURL,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		Refresh: time.Hour,
+		Retry:   5 * time.Minute,
+		now:     time.Now,
+	}
+}
+
+func (p *ECBProvider) SetClock(now func() time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.now = now
+}
+
+func (p *ECBProvider) Rate(from, to string) (Rate, error) {
+	p.mu.Lock()
+	now := p.now()
+	due := p.table.Rates == nil || now.Sub(p.table.FetchedAt) >= p.Refresh
+	switch {
+	case due && p.fetching == nil && now.Sub(p.attempted) >= p.Retry:
+		done := make(chan struct{})
+		p.fetching = done
+		p.attempted = now
+		p.mu.Unlock()
+
+		table, err := p.fetch(now)
+
+		p.mu.Lock()
+		if err == nil {
+			p.table = table
+		}
+		p.lastErr = err
+		p.fetching = nil
+		close(done)
+	case p.table.Rates == nil && p.fetching != nil:
+		// Nothing to serve until the fetch in flight completes
+		done := p.fetching
+		p.mu.Unlock()
+		<-done
+		p.mu.Lock()
+	}
+	table, lastErr := p.table, p.lastErr
+	p.mu.Unlock()
+
+	if table.Rates == nil {
+		return Rate{}, CurrencyError{
+			Message: fmt.Sprintf("Exchange rates are unavailable: %v", lastErr),
+			Code:    "RATE_UNAVAILABLE",
+		}
+	}
+	return table.Rate(from, to)
+}
+
+func (p *ECBProvider) fetch(now time.Time) (Table, error) {
+	resp, err := p.Client.Get(p.URL)
+	if err != nil {
+		return Table{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Table{}, fmt.Errorf("ECB feed returned %s", resp.Status)
+	}
+
+	table, err := ParseECB(resp.Body)
+	if err != nil {
+		return Table{}, err
+	}
+	table.FetchedAt = now
+	return table, nil
+}
@@ -0,0 +1,112 @@
+package currency
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const ecbFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender><gesmes:name>European Central Bank</gesmes:name></gesmes:Sender>
+	<Cube>
+		<Cube time="2021-03-31">
+			<Cube currency="USD" rate="1.1725"/>
+			<Cube currency="GBP" rate="0.85209"/>
+			<Cube currency="CHF" rate="1.1070"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestParseECB(t *testing.T) {
+	table, err := ParseECB(strings.NewReader(ecbFeed))
+	if err != nil {
+		t.Fatalf("Failed to parse feed: %v", err)
+	}
+	
+	if table.Base != "EUR" || !table.AsOf.Equal(time.Date(2021, 3, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected EUR rates for 2021-03-31, got %s for %s", table.Base, table.AsOf)
+	}
+	if len(table.Rates) != 3 || table.Rates["GBP"] != 0.85209 {
+		t.Errorf("Expected 3 rates with GBP 0.85209, got %v", table.Rates)
+	}
+	
+	if _, err := ParseECB(strings.NewReader("<gesmes:Envelope/>")); err == nil {
+		t.Error("Expected an error for a feed without rates")
+	}
+}
+
+func TestECBProvider_CachesAndKeepsLastRates(t *testing.T) {
+	requests := 0
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(ecbFeed))
+	}))
+	defer server.Close()
+	
+	now := time.Date(2021, 4, 1, 9, 0, 0, 0, time.UTC)
+	provider := NewECBProvider()
+	provider.URL = server.URL
+	provider.SetClock(func() time.Time { return now })
+	
+	rate, err := provider.Rate("GBP", "USD")
+	if err != nil {
+		t.Fatalf("Failed to get rate: %v", err)
+	}
+	if rate.Source != "ECB" || !rate.FetchedAt.Equal(now) || rate.Value < 1.376 || rate.Value > 1.377 {
+		t.Errorf("Unexpected cross rate %+v", rate)
+	}
+	
+	provider.Rate("EUR", "CHF")
+	if requests != 1 {
+		t.Errorf("Expected the feed to be fetched once within the refresh interval, got %d", requests)
+	}
+	
+	failing = true
+	now = now.Add(2 * time.Hour)
+	if _, err := provider.Rate("EUR", "CHF"); err != nil {
+		t.Errorf("Expected cached rates to be served when the feed is down, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected a refresh attempt after the interval, got %d requests", requests)
+	}
+}
+
+func TestECBProvider_BacksOffAfterAFailedFetch(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	
+	now := time.Date(2021, 4, 1, 9, 0, 0, 0, time.UTC)
+	provider := NewECBProvider()
+	provider.URL = server.URL
+	provider.SetClock(func() time.Time { return now })
+	
+	var currencyErr CurrencyError
+	if _, err := provider.Rate("EUR", "GBP"); !errors.As(err, &currencyErr) || currencyErr.Code != "RATE_UNAVAILABLE" {
+		t.Errorf("Expected RATE_UNAVAILABLE, got %v", err)
+	}
+	now = now.Add(time.Minute)
+	provider.Rate("EUR", "GBP")
+	if requests != 1 {
+		t.Errorf("Expected no new attempt within the retry interval, got %d requests", requests)
+	}
+	
+	now = now.Add(provider.Retry)
+	provider.Rate("EUR", "GBP")
+	if requests != 2 {
+		t.Errorf("Expected a new attempt after the retry interval, got %d requests", requests)
+	}
+}
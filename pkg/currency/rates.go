@@ -0,0 +1,99 @@
+package currency
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type CurrencyError struct {
+	Message string
+	Code    string
+}
+
+func (e CurrencyError) Error() string {
+	return e.Message
+}
+
+// Rate converts one unit of From into Value units of To. AsOf is when the
+// source published the rate, which is what auditors need to reproduce a
+// conversion; FetchedAt is when this process obtained it.
+type Rate struct {
+	From      string
+	To        string
+	Value     float64
+	Source    string
+	AsOf      time.Time
+	FetchedAt time.Time
+}
+
+type RateProvider interface {
+	Rate(from, to string) (Rate, error)
+}
+
+// Table holds rates against a single base currency, which is how both
+// operator rate sheets and the ECB publish them. Other pairs are crossed
+// through the base.
+type Table struct {
+	Base      string
+	Source    string
+	AsOf      time.Time
+	FetchedAt time.Time
+	Rates     map[string]float64 // units of currency per one unit of Base
+}
+
+func (t Table) Rate(from, to string) (Rate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	fromRate, err := t.perBase(from)
+	if err != nil {
+		return Rate{}, err
+	}
+	toRate, err := t.perBase(to)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	return Rate{
+		From:      from,
+		To:        to,
+		Value:     toRate / fromRate,
+		Source:    t.Source,
+		AsOf:      t.AsOf,
+		FetchedAt: t.FetchedAt,
+	}, nil
+}
+
+func (t Table) perBase(currency string) (float64, error) {
+	if currency == t.Base {
+		return 1, nil
+	}
+	rate, exists := t.Rates[currency]
+	if !exists || rate <= 0 {
+		return 0, CurrencyError{
+			Message: fmt.Sprintf("No %s rate for %s", t.Source, currency),
+			Code:    "RATE_UNAVAILABLE",
+		}
+	}
+	return rate, nil
+}
+
+// StaticProvider serves a fixed rate sheet, for operators that set their
+// own rates or for tests.
+type StaticProvider struct {
+	table Table
+}
+
+func NewStaticProvider(base string, asOf time.Time, rates map[string]float64) *StaticProvider {
+	return &StaticProvider{table: Table{
+		Base:      strings.ToUpper(base),
+		Source:    "static",
+		AsOf:      asOf,
+		FetchedAt: asOf,
+		Rates:     rates,
+	}}
+}
+
+func (p *StaticProvider) Rate(from, to string) (Rate, error) {
+	return p.table.Rate(from, to)
+}
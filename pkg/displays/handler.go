@@ -0,0 +1,187 @@
+package displays
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"ticketing-app/pkg/i18n"
+	"ticketing-app/pkg/reservation"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+type Segment struct {
+	Carriage  string `json:"carriage"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	FromName  string `json:"fromName"`
+	ToName    string `json:"toName"`
+	Seats     int    `json:"seats"`
+	CheckedIn int    `json:"checkedIn"`
+	Expected  int    `json:"expected"`
+	NoShows   int    `json:"noShows"`
+	Level     string `json:"level"`
+}
+
+type OccupancyResponse struct {
+	ServiceID string    `json:"serviceId"`
+	Date      string    `json:"date"`
+	Segments  []Segment `json:"segments"`
+}
+
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handler serves live carriage occupancy to station displays, both as a
+// snapshot and as a server-sent event stream fed by bookings,
+// cancellations and conductor check-ins.
+//
+//	GET /services/{id}/occupancy?date=YYYY-MM-DD
+//	GET /services/{id}/occupancy/stream?date=YYYY-MM-DD
+type Handler struct {
+	system  *reservation.System
+	catalog *i18n.Catalog
+}
+
+func NewHandler(system *reservation.System) *Handler {
+	return &Handler{system: system, catalog: i18n.DefaultCatalog()}
+}
+
+// SetCatalog sets the catalog station names are translated with.
+func (h *Handler) SetCatalog(catalog *i18n.Catalog) {
+	h.catalog = catalog
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if r.Method != http.MethodGet || len(parts) < 3 || parts[0] != "services" || parts[2] != "occupancy" {
+		writeJSON(w, http.StatusNotFound, errorResponse{Code: "NOT_FOUND", Message: "Unknown resource"})
+		return
+	}
+	serviceID := parts[1]
+
+	switch {
+	case len(parts) == 3:
+		h.snapshot(w, r, serviceID)
+	case len(parts) == 4 && parts[3] == "stream":
+		h.stream(w, r, serviceID)
+	default:
+		writeJSON(w, http.StatusNotFound, errorResponse{Code: "NOT_FOUND", Message: "Unknown resource"})
+	}
+}
+
+func (h *Handler) snapshot(w http.ResponseWriter, r *http.Request, serviceID string) {
+	date, err := time.Parse(dateLayout, r.URL.Query().Get("date"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Code: "INVALID_DATE", Message: "date must be formatted as YYYY-MM-DD"})
+		return
+	}
+
+	occupancy := h.system.GetOccupancy(serviceID, date)
+
+	if len(occupancy) == 0 {
+		writeJSON(w, http.StatusNotFound, errorResponse{Code: "SERVICE_NOT_FOUND", Message: fmt.Sprintf("Service %s not found", serviceID)})
+		return
+	}
+	writeJSON(w, http.StatusOK, OccupancyResponse{
+		ServiceID: serviceID,
+		Date:      date.Format(dateLayout),
+		Segments:  h.toSegments(serviceID, occupancy, locale(r)),
+	})
+}
+
+func (h *Handler) stream(w http.ResponseWriter, r *http.Request, serviceID string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Code: "STREAMING_UNSUPPORTED", Message: "Streaming is not supported"})
+		return
+	}
+	date, err := time.Parse(dateLayout, r.URL.Query().Get("date"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Code: "INVALID_DATE", Message: "date must be formatted as YYYY-MM-DD"})
+		return
+	}
+
+	// Drop updates for a display that cannot keep up rather than stalling
+	// the conductor's check-in. Displays are outside the system, so they
+	// are fed masked events.
+	updates := make(chan reservation.OccupancyChanged, 16)
+	unsubscribe := h.system.ForwardEvents(func(e events.Event) {
+		changed, ok := e.Data.(reservation.OccupancyChanged)
+		if !ok || e.Type != reservation.EventOccupancyChanged || changed.ServiceID != serviceID ||
+			changed.Date.Format(dateLayout) != date.Format(dateLayout) {
+			return
+		}
+		select {
+		case updates <- changed:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case changed := <-updates:
+			data, _ := json.Marshal(OccupancyResponse{
+				ServiceID: changed.ServiceID,
+				Date:      changed.Date.Format(dateLayout),
+				Segments:  h.toSegments(serviceID, changed.Segments, locale(r)),
+			})
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", reservation.EventOccupancyChanged, data)
+			flusher.Flush()
+		}
+	}
+}
+
+// toSegments names stations in the display's language, taken from its
+// Accept-Language header.
+func (h *Handler) toSegments(serviceID string, occupancy []domain.SegmentOccupancy, locale string) []Segment {
+	service, _ := h.system.GetService(serviceID)
+	translator := h.catalog.Translator(locale)
+	name := func(station string) string {
+		if s, found := service.GetStation(station); found {
+			return translator.Station(s)
+		}
+		return translator.Station(station)
+	}
+
+	segments := make([]Segment, len(occupancy))
+	for i, o := range occupancy {
+		segments[i] = Segment{
+			Carriage:  o.CarriageID,
+			From:      o.From,
+			To:        o.To,
+			FromName:  name(o.From),
+			ToName:    name(o.To),
+			Seats:     o.Seats,
+			CheckedIn: o.CheckedIn,
+			Expected:  o.Expected,
+			NoShows:   o.NoShows,
+			Level:     string(o.Level),
+		}
+	}
+	return segments
+}
+
+func locale(r *http.Request) string {
+	return i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,148 @@
+package displays
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/reservation"
+	"ticketing-app/pkg/testdata"
+	"time"
+)
+
+func bookSeat(t *testing.T, rs *reservation.System, seat string) {
+	_, err := rs.MakeReservation(domain.ReservationRequest{
+		ServiceID: "5160",
+		Origin:    "Paris",
+		Destination: "Amsterdam",
+		Passengers: []domain.Passenger{{Name: "Passenger " + seat}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "H", SeatNumber: seat}},
+		Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+}
+
+func TestHandler_Snapshot(t *testing.T) {
+	rs := testdata.SetupTestData()
+	for _, seat := range []string{"H1", "H2", "H3", "H4", "H5", "H6", "H7", "H8", "H9"} {
+		bookSeat(t, rs, seat)
+	}
+	
+	rec := httptest.NewRecorder()
+	NewHandler(rs).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/services/5160/occupancy?date=2021-04-01", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	
+	var response OccupancyResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	
+	levels := make(map[string]string)
+	for _, segment := range response.Segments {
+		if segment.From == "Paris" {
+			levels[segment.Carriage] = segment.Level
+		}
+	}
+	if levels["H"] != "busy" || levels["T"] != "seats available" {
+		t.Errorf("Expected carriage H busy and T with seats available, got %v", levels)
+	}
+}
+
+func TestHandler_StationNamesMatchDocuments(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/services/5160/occupancy?date=2021-04-01", nil)
+	req.Header.Set("Accept-Language", "fr-FR")
+	rec := httptest.NewRecorder()
+	NewHandler(testdata.SetupTestData()).ServeHTTP(rec, req)
+	
+	var response OccupancyResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	
+	names := make(map[string]string)
+	for _, segment := range response.Segments {
+		names[segment.From] = segment.FromName
+	}
+	// Paris has its own French name; Antwerp only has the catalog's
+	if names["Paris"] != "Paris Nord" || names["Antwerp"] != "Anvers" {
+		t.Errorf("Expected Paris Nord and Anvers, got %v", names)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	handler := NewHandler(testdata.SetupTestData())
+	
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/services/5160/occupancy", http.StatusBadRequest},
+		{"/services/9999/occupancy?date=2021-04-01", http.StatusNotFound},
+		{"/services/5160", http.StatusNotFound},
+		{"/services/5160/occupancy/stream", http.StatusBadRequest},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Errorf("Expected %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_Stream(t *testing.T) {
+	rs := testdata.SetupTestData()
+	bookSeat(t, rs, "H1")
+	
+	server := httptest.NewServer(NewHandler(rs))
+	defer server.Close()
+	
+	resp, err := http.Get(server.URL + "/services/5160/occupancy/stream?date=2021-04-01")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	
+	// A booking for another day is not streamed
+	_, err = rs.MakeReservation(domain.ReservationRequest{
+		ServiceID: "5160",
+		Origin:    "Paris",
+		Destination: "Amsterdam",
+		Passengers: []domain.Passenger{{Name: "Passenger T1"}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "T", SeatNumber: "T1"}},
+		Date: time.Date(2021, 4, 2, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+	if err := rs.CheckIn("5160", "H", "H1", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Failed to check in: %v", err)
+	}
+	
+	scanner := bufio.NewScanner(resp.Body)
+	var data string
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+			data = strings.TrimPrefix(line, "data: ")
+			break
+		}
+	}
+	
+	var update OccupancyResponse
+	if err := json.Unmarshal([]byte(data), &update); err != nil {
+		t.Fatalf("Failed to decode stream event %q: %v", data, err)
+	}
+	if update.ServiceID != "5160" || update.Segments[0].Carriage != "H" || update.Segments[0].CheckedIn != 1 {
+		t.Errorf("Unexpected stream update %+v", update)
+	}
+}
@@ -0,0 +1,298 @@
+package documents
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sync"
+	texttemplate "text/template"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/i18n"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+type Kind string
+
+const (
+	ConfirmationEmail Kind = "confirmation-email"
+	// ConfirmationPrint is the confirmation as a printable HTML page.
+	ConfirmationPrint Kind = "confirmation-print"
+	// ConfirmationPDF is the confirmation as a PDF file. Its templates are
+	// text templates; each line they output is set as a line of the PDF.
+	ConfirmationPDF  Kind = "confirmation-pdf"
+	Summary          Kind = "summary"
+	TicketDocument   Kind = "ticket"
+	ManifestDocument Kind = "manifest"
+)
+
+var builtinFiles = map[Kind]string{
+	ConfirmationEmail: "templates/confirmation-email.html.tmpl",
+	ConfirmationPrint: "templates/confirmation-print.html.tmpl",
+	ConfirmationPDF:   "templates/confirmation-pdf.txt.tmpl",
+	Summary:           "templates/summary.txt.tmpl",
+	TicketDocument:    "templates/ticket.txt.tmpl",
+	ManifestDocument:  "templates/manifest.txt.tmpl",
+}
+
+type Branding struct {
+	Name         string
+	LogoURL      string
+	SupportEmail string
+}
+
+type Data struct {
+	Booking  domain.Booking
+	Ticket   domain.Ticket
+	Manifest domain.Manifest
+	Branding Branding
+	Locale   string
+	T        i18n.Translator
+}
+
+type DocumentError struct {
+	Message string
+	Code    string
+}
+
+func (e DocumentError) Error() string {
+	return e.Message
+}
+
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// Engine renders booking documents from the built-in templates, or from
+// operator-supplied overrides registered per kind and locale.
+type Engine struct {
+	mu        sync.RWMutex
+	catalog   *i18n.Catalog
+	branding  Branding
+	builtin   map[Kind]executor
+	overrides map[Kind]map[string]executor
+}
+
+func NewEngine(catalog *i18n.Catalog, branding Branding) *Engine {
+	e := &Engine{
+		catalog:   catalog,
+		branding:  branding,
+		builtin:   make(map[Kind]executor),
+		overrides: make(map[Kind]map[string]executor),
+	}
+
+	for kind, file := range builtinFiles {
+		source, err := builtinTemplates.ReadFile(file)
+		if err != nil {
+			panic(fmt.Sprintf("missing built-in template %s: %v", file, err))
+		}
+		tmpl, err := parse(kind, string(source))
+		if err != nil {
+			panic(fmt.Sprintf("invalid built-in template %s: %v", file, err))
+		}
+		e.builtin[kind] = tmpl
+	}
+
+	return e
+}
+
+func (e *Engine) SetBranding(branding Branding) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.branding = branding
+}
+
+// Override installs an operator template for the given kind and locale.
+// An empty locale overrides the template for every locale without a more
+// specific override.
+func (e *Engine) Override(kind Kind, locale, source string) error {
+	tmpl, err := parse(kind, source)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.overrides[kind] == nil {
+		e.overrides[kind] = make(map[string]executor)
+	}
+	e.overrides[kind][i18n.Normalize(locale)] = tmpl
+	return nil
+}
+
+func (e *Engine) RemoveOverride(kind Kind, locale string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.overrides[kind], i18n.Normalize(locale))
+}
+
+// Render produces a booking-level document. Without an explicit locale
+// the lead passenger's language is used.
+func (e *Engine) Render(w io.Writer, kind Kind, locale string, booking domain.Booking) error {
+	if locale == "" && len(booking.Passengers) > 0 {
+		locale = booking.Passengers[0].Locale
+	}
+	return e.render(w, kind, Data{Booking: booking, Locale: locale})
+}
+
+// RenderTicket produces one passenger's ticket, in that passenger's
+// language unless a locale is given.
+func (e *Engine) RenderTicket(w io.Writer, locale string, ticket domain.Ticket) error {
+	if locale == "" {
+		locale = ticket.Passenger.Locale
+	}
+	return e.render(w, TicketDocument, Data{Ticket: ticket, Locale: locale})
+}
+
+// RenderManifest produces the conductor manifest in the crew's language.
+func (e *Engine) RenderManifest(w io.Writer, locale string, manifest domain.Manifest) error {
+	return e.render(w, ManifestDocument, Data{Manifest: manifest, Locale: locale})
+}
+
+func (e *Engine) render(w io.Writer, kind Kind, data Data) error {
+	e.mu.RLock()
+	tmpl, err := e.lookup(kind, data.Locale)
+	data.Branding = e.branding
+	e.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	data.T = e.catalog.Translator(data.Locale)
+	return execute(w, tmpl, data)
+}
+
+// Preview renders an operator's draft template against a sample booking
+// without installing it, so layout mistakes surface before go-live. A PDF
+// draft is previewed as the PDF file.
+func (e *Engine) Preview(kind Kind, locale, source string) (string, error) {
+	tmpl, err := parse(kind, source)
+	if err != nil {
+		return "", err
+	}
+
+	booking := sampleBooking()
+	data := Data{
+		Booking: booking,
+		Ticket:  booking.Tickets[0],
+		Manifest: domain.Manifest{
+			Service: booking.Tickets[0].Service,
+			Date:    booking.Tickets[0].Service.DateTime,
+			Entries: []domain.ManifestEntry{{
+				BookingID:   booking.ID,
+				Passenger:   booking.Tickets[0].Passenger,
+				Seat:        booking.Tickets[0].Seat,
+				Origin:      booking.Tickets[0].Origin,
+				Destination: booking.Tickets[0].Destination,
+			}},
+		},
+		Locale: locale,
+		T:      e.catalog.Translator(locale),
+	}
+	e.mu.RLock()
+	data.Branding = e.branding
+	e.mu.RUnlock()
+
+	var buf bytes.Buffer
+	if err := execute(&buf, tmpl, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// lookup walks the locale fallback chain (e.g. nl-BE, nl, default locale)
+// and falls back to the built-in template when no override matches.
+func (e *Engine) lookup(kind Kind, locale string) (executor, error) {
+	builtin, exists := e.builtin[kind]
+	if !exists {
+		return nil, DocumentError{
+			Message: fmt.Sprintf("Unknown document kind %s", kind),
+			Code:    "UNKNOWN_DOCUMENT_KIND",
+		}
+	}
+
+	overrides := e.overrides[kind]
+	for _, candidate := range i18n.Fallbacks(locale, e.catalog.DefaultLocale()) {
+		if tmpl, exists := overrides[candidate]; exists {
+			return tmpl, nil
+		}
+	}
+	return builtin, nil
+}
+
+func parse(kind Kind, source string) (executor, error) {
+	var (
+		tmpl executor
+		err  error
+	)
+	switch kind {
+	case ConfirmationEmail, ConfirmationPrint:
+		tmpl, err = htmltemplate.New(string(kind)).Option("missingkey=error").Parse(source)
+	case Summary, TicketDocument, ManifestDocument:
+		tmpl, err = texttemplate.New(string(kind)).Option("missingkey=error").Parse(source)
+	case ConfirmationPDF:
+		var text *texttemplate.Template
+		if text, err = texttemplate.New(string(kind)).Option("missingkey=error").Parse(source); err == nil {
+			tmpl = pdfTemplate{text: text}
+		}
+	default:
+		return nil, DocumentError{
+			Message: fmt.Sprintf("Unknown document kind %s", kind),
+			Code:    "UNKNOWN_DOCUMENT_KIND",
+		}
+	}
+	if err != nil {
+		return nil, DocumentError{
+			Message: fmt.Sprintf("Invalid %s template: %v", kind, err),
+			Code:    "INVALID_TEMPLATE",
+		}
+	}
+	return tmpl, nil
+}
+
+func execute(w io.Writer, tmpl executor, data Data) error {
+	if err := tmpl.Execute(w, data); err != nil {
+		return DocumentError{
+			Message: fmt.Sprintf("Failed to render document: %v", err),
+			Code:    "RENDER_FAILED",
+		}
+	}
+	return nil
+}
+
+// sampleBooking is the booking Preview renders drafts against.
+func sampleBooking() domain.Booking {
+	paris := domain.NewStation("Paris")
+	paris.Names = map[string]string{"fr": "Paris Nord", "nl": "Parijs-Noord"}
+	amsterdam := domain.NewStation("Amsterdam")
+	route := domain.NewRoute("R002", "Paris-Amsterdam",
+		[]domain.Station{paris, amsterdam}, []int{0, 520})
+	route.Names = map[string]string{"nl": "Parijs-Amsterdam"}
+	seat := domain.Seat{Number: "A11", ComfortZone: domain.FirstClass, CarriageID: "A"}
+	service := domain.NewService("5160", route,
+		time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC),
+		[]domain.Carriage{{ID: "A", Seats: []domain.Seat{seat}}})
+	passenger := domain.Passenger{Name: "John Doe", Locale: "en"}
+
+	booking := domain.NewBooking("B0000", []domain.Passenger{passenger}, []domain.Ticket{{
+		Seat:        seat,
+		Origin:      paris,
+		Destination: amsterdam,
+		Service:     service,
+		Passenger:   passenger,
+	}})
+	booking.Price = domain.PriceBreakdown{
+		Lines: []domain.PriceLine{
+			{Component: domain.FareComponent, Description: "first-class fare, 520 km", Passenger: 0, Amount: domain.Money{Amount: 13000, Currency: "EUR"}},
+			{Component: domain.TaxComponent, Description: "VAT", Passenger: 0, Amount: domain.Money{Amount: 1170, Currency: "EUR"}},
+		},
+		Total: domain.Money{Amount: 14170, Currency: "EUR"},
+	}
+	booking.Tickets[0].Price = booking.Price.Total
+	booking.CreatedAt = service.DateTime.AddDate(0, -1, 0)
+	return booking
+}
@@ -0,0 +1,208 @@
+package documents
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/i18n"
+)
+
+func TestEngine_RenderBuiltin(t *testing.T) {
+	engine := NewEngine(i18n.DefaultCatalog(), Branding{Name: "Eurorail"})
+	
+	for _, kind := range []Kind{ConfirmationEmail, ConfirmationPrint, Summary} {
+		var buf bytes.Buffer
+		if err := engine.Render(&buf, kind, "en", sampleBooking()); err != nil {
+			t.Fatalf("Failed to render %s: %v", kind, err)
+		}
+		if !strings.Contains(buf.String(), "B0000") || !strings.Contains(buf.String(), "Eurorail") {
+			t.Errorf("Expected %s to mention booking and brand, got:\n%s", kind, buf.String())
+		}
+	}
+}
+
+func TestEngine_LocaleOverrides(t *testing.T) {
+	engine := NewEngine(i18n.DefaultCatalog(), Branding{Name: "Eurorail"})
+	
+	if err := engine.Override(Summary, "nl", "Boeking {{.Booking.ID}}"); err != nil {
+		t.Fatalf("Failed to install override: %v", err)
+	}
+	if err := engine.Override(Summary, "", "Booking {{.Booking.ID}}"); err != nil {
+		t.Fatalf("Failed to install override: %v", err)
+	}
+	
+	tests := []struct {
+		locale   string
+		expected string
+	}{
+		{"nl-BE", "Boeking B0000"},
+		{"nl", "Boeking B0000"},
+		{"fr", "Booking B0000"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := engine.Render(&buf, Summary, tt.locale, sampleBooking()); err != nil {
+				t.Fatalf("Failed to render: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+	
+	engine.RemoveOverride(Summary, "nl")
+	var buf bytes.Buffer
+	engine.Render(&buf, Summary, "nl", sampleBooking())
+	if buf.String() != "Booking B0000" {
+		t.Errorf("Expected removed override to fall back, got %q", buf.String())
+	}
+}
+
+func TestEngine_HTMLIsEscaped(t *testing.T) {
+	engine := NewEngine(i18n.DefaultCatalog(), Branding{Name: "<b>Eurorail</b>"})
+	
+	var buf bytes.Buffer
+	if err := engine.Render(&buf, ConfirmationEmail, "en", sampleBooking()); err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	if strings.Contains(buf.String(), "<b>Eurorail</b>") {
+		t.Errorf("Expected brand name to be escaped in HTML output")
+	}
+}
+
+func TestEngine_Preview(t *testing.T) {
+	engine := NewEngine(i18n.DefaultCatalog(), Branding{Name: "Eurorail"})
+	
+	preview, err := engine.Preview(Summary, "en", "{{.Branding.Name}}: {{len .Booking.Tickets}} ticket(s)")
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if preview != "Eurorail: 1 ticket(s)" {
+		t.Errorf("Unexpected preview %q", preview)
+	}
+	
+	_, err = engine.Preview(Summary, "en", "{{.Booking.Missing}}")
+	if err == nil {
+		t.Errorf("Expected preview of a broken template to fail")
+	}
+	
+	_, err = engine.Preview(Summary, "en", "{{if}}")
+	if docErr, ok := err.(DocumentError); !ok || docErr.Code != "INVALID_TEMPLATE" {
+		t.Errorf("Expected INVALID_TEMPLATE, got %v", err)
+	}
+}
+
+func TestEngine_RenderPartyPlacement(t *testing.T) {
+	engine := NewEngine(i18n.DefaultCatalog(), Branding{Name: "Eurorail"})
+	
+	booking := sampleBooking()
+	booking.Placement = &domain.Placement{Strategy: domain.PlacedInBay}
+	
+	for _, kind := range []Kind{ConfirmationEmail, ConfirmationPrint, Summary} {
+		var buf bytes.Buffer
+		if err := engine.Render(&buf, kind, "fr", booking); err != nil {
+			t.Fatalf("Failed to render %s: %v", kind, err)
+		}
+		if !strings.Contains(buf.String(), "Votre groupe est placé dans le même carré.") {
+			t.Errorf("Expected %s to say how the party is seated, got:\n%s", kind, buf.String())
+		}
+	}
+}
+
+func TestEngine_RenderTicketInPassengerLanguage(t *testing.T) {
+	engine := NewEngine(i18n.DefaultCatalog(), Branding{Name: "Eurorail"})
+	
+	ticket := sampleBooking().Tickets[0]
+	ticket.Passenger.Locale = "nl-BE"
+	
+	var buf bytes.Buffer
+	if err := engine.RenderTicket(&buf, "", ticket); err != nil {
+		t.Fatalf("Failed to render ticket: %v", err)
+	}
+	
+	for _, expected := range []string{"Vervoerbewijs", "Van: Parijs-Noord", "Naar: Amsterdam", "Eerste klas", "Prijs: EUR 141.70"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected ticket to contain %q, got:\n%s", expected, buf.String())
+		}
+	}
+}
+
+func TestEngine_RenderManifestInCrewLanguage(t *testing.T) {
+	engine := NewEngine(i18n.DefaultCatalog(), Branding{Name: "Eurorail"})
+	
+	ticket := sampleBooking().Tickets[0]
+	manifest := domain.Manifest{
+		Service: ticket.Service,
+		Date:    ticket.Service.DateTime,
+		Entries: []domain.ManifestEntry{{
+			BookingID:   "B0001",
+			Passenger:   ticket.Passenger,
+			Seat:        ticket.Seat,
+			Origin:      ticket.Origin,
+			Destination: ticket.Destination,
+		}},
+	}
+	
+	var buf bytes.Buffer
+	if err := engine.RenderManifest(&buf, "fr", manifest); err != nil {
+		t.Fatalf("Failed to render manifest: %v", err)
+	}
+	
+	for _, expected := range []string{"Liste des passagers", "Passagers: 1", "Première classe", "John Doe", "Paris Nord -> Amsterdam"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected manifest to contain %q, got:\n%s", expected, buf.String())
+		}
+	}
+}
+
+func TestEngine_RenderPDF(t *testing.T) {
+	engine := NewEngine(i18n.DefaultCatalog(), Branding{Name: "Eurorail (Europe)"})
+	
+	var buf bytes.Buffer
+	if err := engine.Render(&buf, ConfirmationPDF, "fr", sampleBooking()); err != nil {
+		t.Fatalf("Failed to render PDF: %v", err)
+	}
+	pdf := buf.String()
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatalf("Expected a PDF file, got:\n%s", pdf)
+	}
+	for _, expected := range []string{"(Eurorail \\(Europe\\)) Tj", "(R\xe9servation B0000) Tj", "Premi\xe8re classe", "/Count 1"} {
+		if !strings.Contains(pdf, expected) {
+			t.Errorf("Expected PDF to contain %q, got:\n%s", expected, pdf)
+		}
+	}
+	
+	// Every object is where the cross-reference table says
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	xref, _ := strconv.Atoi(startxref[1])
+	if !strings.HasPrefix(pdf[xref:], "xref\n") {
+		t.Fatalf("Expected the cross-reference table at %d", xref)
+	}
+	for i, entry := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(pdf[xref:], -1) {
+		offset, _ := strconv.Atoi(entry[1])
+		if !strings.HasPrefix(pdf[offset:], strconv.Itoa(i+1)+" 0 obj") {
+			t.Errorf("Expected object %d at offset %d", i+1, offset)
+		}
+	}
+}
+
+func TestEngine_RenderPDFFlowsOntoPages(t *testing.T) {
+	engine := NewEngine(i18n.DefaultCatalog(), Branding{Name: "Eurorail"})
+	
+	booking := sampleBooking()
+	for len(booking.Tickets) < 30 {
+		booking.Tickets = append(booking.Tickets, booking.Tickets[0])
+	}
+	var buf bytes.Buffer
+	if err := engine.Render(&buf, ConfirmationPDF, "en", booking); err != nil {
+		t.Fatalf("Failed to render PDF: %v", err)
+	}
+	if !strings.Contains(buf.String(), "/Count 4") {
+		t.Errorf("Expected 30 tickets to take 4 pages")
+	}
+}
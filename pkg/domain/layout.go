@@ -0,0 +1,195 @@
+package domain
+
+import "sort"
+
+// Facing is the way a seat faces. Rows are numbered from one end of the
+// carriage; a forward seat faces lower-numbered rows.
+type Facing string
+
+const (
+	FacingForward  Facing = "forward"
+	FacingBackward Facing = "backward"
+)
+
+type SeatPosition string
+
+const (
+	Window SeatPosition = "window"
+	Aisle  SeatPosition = "aisle"
+	Middle SeatPosition = "middle"
+)
+
+// AdjacencyKind says how two seats are next to each other, from closest
+// to loosest.
+type AdjacencyKind string
+
+const (
+	SideBySide  AdjacencyKind = "side-by-side"
+	FacingSeats AdjacencyKind = "facing"
+	AcrossTable AdjacencyKind = "across-table"
+	AcrossAisle AdjacencyKind = "across-aisle"
+)
+
+type Adjacency struct {
+	Seat Seat
+	Kind AdjacencyKind
+}
+
+// SeatGraph links each seat of a carriage to the seats truly next to it,
+// according to the layout rather than the seat numbers: numbers often run
+// across the aisle or jump between bays.
+type SeatGraph struct {
+	edges map[string][]Adjacency
+}
+
+func NewSeatGraph(carriage Carriage) SeatGraph {
+	graph := SeatGraph{edges: make(map[string][]Adjacency)}
+	for i, a := range carriage.Seats {
+		for _, b := range carriage.Seats[i+1:] {
+			if kind, adjacent := carriage.adjacency(a, b); adjacent {
+				graph.edges[a.Number] = append(graph.edges[a.Number], Adjacency{Seat: b, Kind: kind})
+				graph.edges[b.Number] = append(graph.edges[b.Number], Adjacency{Seat: a, Kind: kind})
+			}
+		}
+	}
+	for number := range graph.edges {
+		edges := graph.edges[number]
+		sort.SliceStable(edges, func(i, j int) bool { return adjacencyRank[edges[i].Kind] < adjacencyRank[edges[j].Kind] })
+	}
+	return graph
+}
+
+var adjacencyRank = map[AdjacencyKind]int{SideBySide: 0, FacingSeats: 1, AcrossTable: 2, AcrossAisle: 3}
+
+// Neighbours lists the seats next to a seat, closest first.
+func (g SeatGraph) Neighbours(seatNumber string) []Adjacency {
+	return g.edges[seatNumber]
+}
+
+func (g SeatGraph) Adjacent(a, b string) (AdjacencyKind, bool) {
+	for _, edge := range g.edges[a] {
+		if edge.Seat.Number == b {
+			return edge.Kind, true
+		}
+	}
+	return "", false
+}
+
+// adjacency decides whether two seats are neighbours. Seats without a row
+// and column never are.
+func (c Carriage) adjacency(a, b Seat) (AdjacencyKind, bool) {
+	if a.Row == 0 || a.Column == 0 || b.Row == 0 || b.Column == 0 {
+		return "", false
+	}
+	sameSide := c.side(a.Column) == c.side(b.Column)
+
+	switch {
+	case a.Row == b.Row && abs(a.Column-b.Column) == 1:
+		if sameSide {
+			return SideBySide, true
+		}
+		return AcrossAisle, true
+	case a.Column == b.Column && abs(a.Row-b.Row) == 1 && facing(a, b):
+		return FacingSeats, true
+	case a.Table != "" && a.Table == b.Table && sameSide && a.Row != b.Row:
+		return AcrossTable, true
+	}
+	return "", false
+}
+
+// facing reports whether seats in neighbouring rows face each other rather
+// than sitting back to back.
+func facing(a, b Seat) bool {
+	if a.Row > b.Row {
+		a, b = b, a
+	}
+	return a.Facing == FacingBackward && b.Facing == FacingForward
+}
+
+// side is 0 for columns up to the aisle and 1 after it.
+func (c Carriage) side(column int) int {
+	if c.Aisle > 0 && column > c.Aisle {
+		return 1
+	}
+	return 0
+}
+
+// Position tells window seats from aisle seats using the carriage's
+// columns. It is empty for seats without a layout.
+func (c Carriage) Position(seat Seat) SeatPosition {
+	if seat.Column == 0 {
+		return ""
+	}
+	last := 0
+	for _, s := range c.Seats {
+		if s.Column > last {
+			last = s.Column
+		}
+	}
+	switch {
+	case seat.Column == 1 || seat.Column == last:
+		return Window
+	case c.Aisle > 0 && (seat.Column == c.Aisle || seat.Column == c.Aisle+1):
+		return Aisle
+	}
+	return Middle
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+type SeatPreference string
+
+const (
+	PreferWindow SeatPreference = "window"
+	PreferAisle  SeatPreference = "aisle"
+	PreferTable  SeatPreference = "table"
+)
+
+// Satisfies reports whether a seat meets a preference. Every seat meets an
+// empty one.
+func (c Carriage) Satisfies(seat Seat, preference SeatPreference) bool {
+	switch preference {
+	case "":
+		return true
+	case PreferWindow:
+		return c.Position(seat) == Window
+	case PreferAisle:
+		return c.Position(seat) == Aisle
+	case PreferTable:
+		return seat.Table != ""
+	}
+	return false
+}
+
+// PlacementStrategy says how closely a party could be seated, from
+// closest to loosest. Allocation tries them in this order.
+type PlacementStrategy string
+
+const (
+	PlacedTogether            PlacementStrategy = "together"
+	PlacedInPairs             PlacementStrategy = "pairs"
+	PlacedInBay               PlacementStrategy = "same-bay"
+	PlacedInCarriage          PlacementStrategy = "same-carriage"
+	PlacedInAdjacentCarriages PlacementStrategy = "adjacent-carriages"
+	PlacedApart               PlacementStrategy = "apart"
+)
+
+// Placement reports how a party was split across the train, for showing
+// alongside the tickets.
+type Placement struct {
+	Strategy PlacementStrategy
+	Clusters []SeatCluster
+}
+
+// SeatCluster is a run of seats next to each other, with the passengers
+// seated in it.
+type SeatCluster struct {
+	CarriageID string
+	Seats      []string
+	Passengers []int // indexes into the booking's passengers
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+)
+
+// tableCarriage is a 2+2 carriage whose first two rows face each other
+// across tables and whose last two rows all face forward. Seats are
+// numbered row then column, so consecutive numbers cross the aisle.
+func tableCarriage() Carriage {
+	carriage := Carriage{ID: "C", Aisle: 2}
+	for row := 1; row <= 4; row++ {
+		for column := 1; column <= 4; column++ {
+			seat := Seat{
+				Number:      fmt.Sprintf("%d%d", row, column),
+				ComfortZone: SecondClass,
+				CarriageID:  "C",
+				Row:         row,
+				Column:      column,
+				Facing:      FacingForward,
+			}
+			if row == 1 {
+				seat.Facing = FacingBackward
+			}
+			if row <= 2 {
+				seat.Table = fmt.Sprintf("T%d", carriage.side(column)+1)
+			}
+			carriage.Seats = append(carriage.Seats, seat)
+		}
+	}
+	carriage.Seats = append(carriage.Seats, Seat{Number: "99", ComfortZone: SecondClass, CarriageID: "C"})
+	return carriage
+}
+
+func TestSeatGraph_Adjacent(t *testing.T) {
+	graph := NewSeatGraph(tableCarriage())
+
+	tests := []struct {
+		a, b     string
+		kind     AdjacencyKind
+		adjacent bool
+	}{
+		{"11", "12", SideBySide, true},
+		{"12", "13", AcrossAisle, true},
+		{"11", "21", FacingSeats, true},
+		{"21", "11", FacingSeats, true},
+		{"11", "22", AcrossTable, true},
+		{"12", "23", "", false},
+		{"21", "31", "", false}, // back to back
+		{"31", "41", "", false}, // one behind the other
+		{"31", "32", SideBySide, true},
+		{"14", "21", "", false},
+		{"44", "99", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			kind, adjacent := graph.Adjacent(tt.a, tt.b)
+			if kind != tt.kind || adjacent != tt.adjacent {
+				t.Errorf("Expected %q %v, got %q %v", tt.kind, tt.adjacent, kind, adjacent)
+			}
+		})
+	}
+
+	neighbours := graph.Neighbours("12")
+	if len(neighbours) != 4 || neighbours[0].Kind != SideBySide || neighbours[3].Kind != AcrossAisle {
+		t.Errorf("Expected 4 neighbours of 12, closest first, got %+v", neighbours)
+	}
+}
+
+func TestCarriage_Satisfies(t *testing.T) {
+	carriage := tableCarriage()
+	seat := func(number string) Seat {
+		for _, s := range carriage.Seats {
+			if s.Number == number {
+				return s
+			}
+		}
+		t.Fatalf("No seat %s", number)
+		return Seat{}
+	}
+
+	tests := []struct {
+		seat       string
+		preference SeatPreference
+		expected   bool
+	}{
+		{"11", PreferWindow, true},
+		{"14", PreferWindow, true},
+		{"12", PreferWindow, false},
+		{"12", PreferAisle, true},
+		{"13", PreferAisle, true},
+		{"21", PreferTable, true},
+		{"31", PreferTable, false},
+		{"99", PreferWindow, false},
+		{"99", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.seat+"_"+string(tt.preference), func(t *testing.T) {
+			if got := carriage.Satisfies(seat(tt.seat), tt.preference); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -1,23 +1,43 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
+	_ "time/tzdata" // station time zones must resolve on devices without a zoneinfo database
 )
 
 type Station struct {
-	Name string
+	Name          string
+	Names         map[string]string // display names keyed by locale, e.g. "nl": "Parijs-Noord"
+	Accessibility Accessibility
+	TimeZone      string `json:",omitempty"` // IANA name such as "Europe/Paris"; empty means UTC
+}
+
+// Accessibility describes what help a station can offer. Staffed hours are
+// offsets from midnight in the station's time zone; a station with no
+// staffed hours cannot provide assistance.
+type Accessibility struct {
+	StepFree           bool
+	StaffedFrom        time.Duration
+	StaffedUntil       time.Duration
+	AssistanceLeadTime time.Duration
 }
 
 type Stop struct {
 	Station   Station
 	Distance  int 
 	StopOrder int 
+	Offset    time.Duration // time after the service's departure
 }
 
 type Route struct {
 	ID    string
 	Name  string
+	Names map[string]string // display names keyed by locale
 	Stops []Stop
 }
 
@@ -32,11 +52,35 @@ type Seat struct {
 	Number       string
 	ComfortZone  ComfortZone
 	CarriageID   string
+	// Layout places the seat in its carriage; see layout.go. Seats without
+	// one have no neighbours.
+	Row          int    `json:",omitempty"`
+	Column       int    `json:",omitempty"`
+	Facing       Facing `json:",omitempty"`
+	Table        string `json:",omitempty"`
+	Bay          string `json:",omitempty"` // seats grouped around a pair of facing rows or a short block of rows
 }
 
 type Carriage struct {
+	ID      string
+	Seats   []Seat
+	Portion string // empty for carriages running the service's own route
+	// Aisle is the column the aisle follows: 2 in a 2+2 layout. Zero means
+	// the carriage has no aisle between its seat columns.
+	Aisle   int `json:",omitempty"`
+	// PriceAdjustment raises or lowers fares in this carriage against other
+	// carriages of the same comfort zone, in basis points: 1500 for a 15%
+	// panoramic premium, -1000 for 10% off next to the bar.
+	PriceAdjustment int64  `json:",omitempty"`
+	PriceNote       string `json:",omitempty"` // names the adjustment on price lines, e.g. "Panoramic carriage"
+}
+
+// Portion is a part of the train that splits off at an intermediate
+// station. Its route covers the whole journey of its carriages, including
+// the section shared with the rest of the train.
+type Portion struct {
 	ID    string
-	Seats []Seat
+	Route Route
 }
 
 type Service struct {
@@ -44,10 +88,65 @@ type Service struct {
 	Route     Route
 	DateTime  time.Time
 	Carriages []Carriage
+	Portions  []Portion
 }
 
 type Passenger struct {
-	Name string
+	Name       string
+	Locale     string
+	Assistance AssistanceType
+	Category   PassengerCategory
+	// Contact details and documents are optional and left out of the
+	// encoding when unset, which keeps older booking checksums valid.
+	Email      string          `json:",omitempty"`
+	Phone      string          `json:",omitempty"`
+	Document   *TravelDocument `json:",omitempty"`
+}
+
+// TravelDocument is the identity document a passenger travels on, needed
+// for cross-border services.
+type TravelDocument struct {
+	Type           string // "passport" or "id-card"
+	Number         string
+	IssuingCountry string
+	Expires        time.Time
+}
+
+// PassengerCategory selects the fare discount a passenger is entitled to.
+type PassengerCategory string
+
+const (
+	Adult  PassengerCategory = ""
+	Child  PassengerCategory = "child"
+	Youth  PassengerCategory = "youth"
+	Senior PassengerCategory = "senior"
+)
+
+type AssistanceType string
+
+const (
+	NoAssistance         AssistanceType = ""
+	WheelchairAssistance AssistanceType = "wheelchair"
+	MobilityAssistance   AssistanceType = "mobility"
+	VisualAssistance     AssistanceType = "visual"
+)
+
+type AssistanceTaskKind string
+
+const (
+	AssistBoarding  AssistanceTaskKind = "boarding"
+	AssistAlighting AssistanceTaskKind = "alighting"
+)
+
+type AssistanceTask struct {
+	Time       time.Time
+	Kind       AssistanceTaskKind
+	Type       AssistanceType
+	ServiceID  string
+	BookingID  string
+	Passenger  Passenger
+	CarriageID string
+	SeatNumber string
 }
 
 type Ticket struct {
@@ -56,6 +155,79 @@ type Ticket struct {
 	Destination  Station
 	Service      Service
 	Passenger    Passenger
+	Boarding     BoardingStatus
+	Price        Money // this passenger's share, booking-wide fees excluded
+}
+
+type BoardingStatus string
+
+const (
+	BoardingExpected  BoardingStatus = ""
+	BoardingCheckedIn BoardingStatus = "checked-in"
+	BoardingNoShow    BoardingStatus = "no-show"
+)
+
+type OccupancyLevel string
+
+const (
+	SeatsAvailable OccupancyLevel = "seats available"
+	FillingUp      OccupancyLevel = "filling up"
+	Busy           OccupancyLevel = "busy"
+)
+
+// SegmentOccupancy is how full one carriage is between two consecutive
+// stops. Expected passengers hold a seat but have not been checked in yet;
+// no-shows have released theirs.
+type SegmentOccupancy struct {
+	CarriageID string
+	From       string
+	To         string
+	Seats      int
+	CheckedIn  int
+	Expected   int
+	NoShows    int
+	Level      OccupancyLevel
+}
+
+// Money is an amount in the currency's minor unit (cents for EUR) so fares
+// add up without rounding drift.
+type Money struct {
+	Amount   int64
+	Currency string // ISO 4217 code
+}
+
+// minorUnits lists the ISO 4217 currencies whose minor unit is not a
+// hundredth: the dinars have three decimals, the yen none.
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// MinorUnits is the number of decimals of a currency; every currency not
+// listed in ISO 4217 as otherwise has two.
+func MinorUnits(currency string) int {
+	if decimals, listed := minorUnits[currency]; listed {
+		return decimals
+	}
+	return 2
+}
+
+func (m Money) String() string {
+	decimals := MinorUnits(m.Currency)
+	if decimals == 0 {
+		return fmt.Sprintf("%s %d", m.Currency, m.Amount)
+	}
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	unit := int64(1)
+	for i := 0; i < decimals; i++ {
+		unit *= 10
+	}
+	return fmt.Sprintf("%s %s%d.%0*d", m.Currency, sign, amount/unit, decimals, amount%unit)
 }
 
 type Booking struct {
@@ -63,6 +235,98 @@ type Booking struct {
 	Passengers []Passenger
 	Tickets   []Ticket
 	CreatedAt time.Time
+	Price     PriceBreakdown
+	// Placement tells how a party of several passengers was seated.
+	Placement *Placement `json:",omitempty"`
+	// Checksum covers the rest of the booking; see Seal.
+	Checksum  string
+}
+
+type PriceComponent string
+
+const (
+	FareComponent     PriceComponent = "fare"
+	FeeComponent      PriceComponent = "fee"
+	DiscountComponent PriceComponent = "discount"
+	TaxComponent      PriceComponent = "tax"
+	// CarriageComponent is a carriage's premium or discount on the fare;
+	// see Carriage.PriceAdjustment.
+	CarriageComponent PriceComponent = "carriage"
+)
+
+// PriceLine is one item of a price breakdown. Discounts are negative.
+// Passenger indexes the request's passengers, or is -1 for booking-wide
+// items such as a booking fee.
+type PriceLine struct {
+	Component   PriceComponent
+	Description string
+	Passenger   int
+	Amount      Money
+}
+
+type PriceBreakdown struct {
+	Lines []PriceLine
+	Total Money
+}
+
+// Sum totals the lines of one component.
+func (p PriceBreakdown) Sum(component PriceComponent) Money {
+	sum := Money{Currency: p.Total.Currency}
+	for _, line := range p.Lines {
+		if line.Component == component {
+			sum.Amount += line.Amount.Amount
+		}
+	}
+	return sum
+}
+
+// PassengerTotal totals the lines charged to one passenger.
+func (p PriceBreakdown) PassengerTotal(passenger int) Money {
+	sum := Money{Currency: p.Total.Currency}
+	for _, line := range p.Lines {
+		if line.Passenger == passenger {
+			sum.Amount += line.Amount.Amount
+		}
+	}
+	return sum
+}
+
+type ManifestEntry struct {
+	ServiceID   string
+	BookingID   string
+	Passenger   Passenger
+	Seat        Seat
+	Origin      Station
+	Destination Station
+}
+
+type Manifest struct {
+	Service Service
+	Date    time.Time
+	Entries []ManifestEntry
+}
+
+// Coupling runs several services as one physical train between From and
+// To. Each service keeps its own seat inventory.
+type Coupling struct {
+	ServiceIDs []string
+	From       string
+	To         string
+	// Formation lists the carriage IDs of the coupled train from the
+	// front. Empty means each service's carriages in ServiceIDs order.
+	Formation []string `json:",omitempty"`
+}
+
+// Key identifies a coupling in a store. A service is in at most one
+// coupling, so the service IDs are enough.
+func (c Coupling) Key() string {
+	return strings.Join(c.ServiceIDs, "+")
+}
+
+type CombinedManifest struct {
+	Coupling Coupling
+	Date     time.Time
+	Entries  []ManifestEntry
 }
 
 type ReservationRequest struct {
@@ -72,11 +336,18 @@ type ReservationRequest struct {
 	Passengers   []Passenger
 	SeatRequests []SeatRequest
 	Date         time.Time
+	Currency     string // currency to quote in; empty for the tariff's
+	QuoteID      string // locked quote whose price the booking should keep
 }
 
+// SeatRequest asks for a specific seat, or, with an empty SeatNumber, for
+// any free seat matching the optional carriage and comfort zone. Preference
+// is honoured when such a seat is free.
 type SeatRequest struct {
-	CarriageID string
-	SeatNumber string
+	CarriageID  string
+	SeatNumber  string
+	ComfortZone ComfortZone
+	Preference  SeatPreference
 }
 
 func NewStation(name string) Station {
@@ -104,6 +375,20 @@ func NewRoute(id, name string, stations []Station, distances []int) Route {
 	}
 }
 
+// NewTimedRoute builds a route whose stops carry their time offset from
+// the service's departure, needed wherever station-local times matter.
+func NewTimedRoute(id, name string, stations []Station, distances []int, offsets []time.Duration) Route {
+	if len(stations) != len(offsets) {
+		panic("number of stations must equal number of offsets")
+	}
+	
+	route := NewRoute(id, name, stations, distances)
+	for i := range route.Stops {
+		route.Stops[i].Offset = offsets[i]
+	}
+	return route
+}
+
 func NewService(id string, route Route, dateTime time.Time, carriages []Carriage) Service {
 	return Service{
 		ID:        id,
@@ -122,6 +407,40 @@ func NewBooking(id string, passengers []Passenger, tickets []Ticket) Booking {
 	}
 }
 
+// ContentChecksum is the SHA-256 of the booking's canonical JSON encoding,
+// leaving out the stored checksum itself. Of each ticket's service only
+// the ID and departure count: the rest is shared by every booking on the
+// service, and stores may keep just that reference.
+func (b Booking) ContentChecksum() string {
+	b.Checksum = ""
+	tickets := make([]Ticket, len(b.Tickets))
+	for i, ticket := range b.Tickets {
+		ticket.Service = ticket.Service.Reference()
+		tickets[i] = ticket
+	}
+	b.Tickets = tickets
+	data, err := json.Marshal(b)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Seal returns the booking with its checksum set. Bookings are sealed
+// whenever they are written, so a later change made any other way, or
+// corruption in storage, no longer matches.
+func (b Booking) Seal() Booking {
+	b.Checksum = b.ContentChecksum()
+	return b
+}
+
+// ChecksumValid reports whether a sealed booking is unchanged. Bookings
+// written before checksums existed have none and are not valid.
+func (b Booking) ChecksumValid() bool {
+	return b.Checksum != "" && b.Checksum == b.ContentChecksum()
+}
+
 func (r Route) GetStationByName(name string) (Station, bool) {
 	for _, stop := range r.Stops {
 		if stop.Station.Name == name {
@@ -140,6 +459,16 @@ func (r Route) GetStopIndex(stationName string) (int, bool) {
 	return -1, false
 }
 
+// Distance is how far apart two stations are along the route, in km.
+func (r Route) Distance(origin, destination string) (int, bool) {
+	originIndex, originFound := r.GetStopIndex(origin)
+	destIndex, destFound := r.GetStopIndex(destination)
+	if !originFound || !destFound {
+		return 0, false
+	}
+	return r.Stops[destIndex].Distance - r.Stops[originIndex].Distance, true
+}
+
 func (r Route) IsValidOriginDestination(origin, destination string) bool {
 	originIndex, originFound := r.GetStopIndex(origin)
 	destIndex, destFound := r.GetStopIndex(destination)
@@ -151,6 +480,120 @@ func (r Route) IsValidOriginDestination(origin, destination string) bool {
 	return originIndex < destIndex
 }
 
+// DisplayNames are the station's own names per locale. Translating them,
+// and falling back to the operator's catalog, is up to i18n.Translator.
+func (s Station) DisplayNames() map[string]string {
+	return s.Names
+}
+
+func (s Station) String() string {
+	return s.Name
+}
+
+func (r Route) DisplayNames() map[string]string {
+	return r.Names
+}
+
+func (r Route) String() string {
+	return r.Name
+}
+
+// LocalTime converts t to the station's time zone.
+func (s Station) LocalTime(t time.Time) (time.Time, error) {
+	if s.TimeZone == "" {
+		return t.UTC(), nil
+	}
+	location, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("station %s has an unknown time zone %q: %w", s.Name, s.TimeZone, err)
+	}
+	return t.In(location), nil
+}
+
+// IsStaffedAt checks t against the staffed hours in t's own location; see
+// Station.LocalTime. Hours are read off the local clock, so they hold on
+// days the clocks change.
+func (a Accessibility) IsStaffedAt(t time.Time) bool {
+	if a.StaffedUntil <= a.StaffedFrom {
+		return false
+	}
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+	return clock >= a.StaffedFrom && clock <= a.StaffedUntil
+}
+
+func (s Service) TimeAt(stationName string) (time.Time, bool) {
+	for _, route := range s.Routes() {
+		if index, found := route.GetStopIndex(stationName); found {
+			return s.DateTime.Add(route.Stops[index].Offset), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Reference keeps only what identifies the departure: the service ID and
+// its date and time.
+func (s Service) Reference() Service {
+	return Service{ID: s.ID, DateTime: s.DateTime}
+}
+
+// Routes returns the service's own route followed by the routes of any
+// portions that split off from it.
+func (s Service) Routes() []Route {
+	routes := []Route{s.Route}
+	for _, portion := range s.Portions {
+		routes = append(routes, portion.Route)
+	}
+	return routes
+}
+
+// GetStation finds a station on the main route or any portion's route.
+func (s Service) GetStation(name string) (Station, bool) {
+	for _, route := range s.Routes() {
+		if station, found := route.GetStationByName(name); found {
+			return station, true
+		}
+	}
+	return Station{}, false
+}
+
+func (s Service) GetCarriage(carriageID string) (Carriage, bool) {
+	for _, carriage := range s.Carriages {
+		if carriage.ID == carriageID {
+			return carriage, true
+		}
+	}
+	return Carriage{}, false
+}
+
+// RouteForCarriage returns the route a carriage actually runs, which for
+// carriages in a portion ends at the portion's own destination.
+func (s Service) RouteForCarriage(carriageID string) Route {
+	carriage, found := s.GetCarriage(carriageID)
+	if !found || carriage.Portion == "" {
+		return s.Route
+	}
+	for _, portion := range s.Portions {
+		if portion.ID == carriage.Portion {
+			return portion.Route
+		}
+	}
+	return s.Route
+}
+
+func (s Service) CarriageServes(carriageID, origin, destination string) bool {
+	return s.RouteForCarriage(carriageID).IsValidOriginDestination(origin, destination)
+}
+
+func (s Service) IsValidOriginDestination(origin, destination string) bool {
+	for _, route := range s.Routes() {
+		if route.IsValidOriginDestination(origin, destination) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s Service) GetSeatByID(carriageID, seatNumber string) (Seat, bool) {
 	for _, carriage := range s.Carriages {
 		if carriage.ID == carriageID {
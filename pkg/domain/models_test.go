@@ -124,3 +124,95 @@ func TestNewRoute_PanicOnMismatch(t *testing.T) {
 	
 	NewRoute("R001", "Test Route", stations, distances)
 }
+
+func TestAccessibility_IsStaffedAt(t *testing.T) {
+	accessibility := Accessibility{StaffedFrom: 6 * time.Hour, StaffedUntil: 22 * time.Hour}
+	
+	tests := []struct {
+		at       time.Time
+		expected bool
+	}{
+		{time.Date(2021, 4, 1, 5, 59, 0, 0, time.UTC), false},
+		{time.Date(2021, 4, 1, 6, 0, 0, 0, time.UTC), true},
+		{time.Date(2021, 4, 1, 22, 0, 0, 0, time.UTC), true},
+		{time.Date(2021, 4, 1, 22, 1, 0, 0, time.UTC), false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.at.Format("15:04"), func(t *testing.T) {
+			if result := accessibility.IsStaffedAt(tt.at); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+	
+	if (Accessibility{}).IsStaffedAt(time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected station without staffed hours to be unstaffed")
+	}
+	
+	// Clocks in Paris went forward at 02:00 on 28 March 2021
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+	if !accessibility.IsStaffedAt(time.Date(2021, 3, 28, 6, 30, 0, 0, paris)) {
+		t.Errorf("Expected 06:30 on the day clocks change to be staffed")
+	}
+}
+
+func TestService_TimeAt(t *testing.T) {
+	route := NewTimedRoute("R001", "Test Route",
+		[]Station{NewStation("A"), NewStation("B")},
+		[]int{0, 100},
+		[]time.Duration{0, 45 * time.Minute})
+	service := NewService("S001", route, time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC), nil)
+	
+	at, found := service.TimeAt("B")
+	if !found || !at.Equal(time.Date(2021, 4, 1, 8, 45, 0, 0, time.UTC)) {
+		t.Errorf("Expected 08:45 at B, got %v (found %v)", at, found)
+	}
+	
+	if _, found := service.TimeAt("Z"); found {
+		t.Errorf("Expected no time for station not on route")
+	}
+}
+
+func TestService_RouteForCarriage(t *testing.T) {
+	mainRoute := NewRoute("R001", "Main", []Station{NewStation("A"), NewStation("B"), NewStation("C")}, []int{0, 100, 200})
+	portionRoute := NewRoute("R002", "Portion", []Station{NewStation("A"), NewStation("B"), NewStation("D")}, []int{0, 100, 180})
+	service := NewService("S001", mainRoute, time.Now(), []Carriage{
+		{ID: "1"},
+		{ID: "2", Portion: "P"},
+	})
+	service.Portions = []Portion{{ID: "P", Route: portionRoute}}
+	
+	if !service.CarriageServes("1", "A", "C") || service.CarriageServes("1", "A", "D") {
+		t.Errorf("Expected carriage 1 to serve only the main route")
+	}
+	if !service.CarriageServes("2", "B", "D") || service.CarriageServes("2", "B", "C") {
+		t.Errorf("Expected carriage 2 to serve only the portion route")
+	}
+	if !service.IsValidOriginDestination("A", "D") || service.IsValidOriginDestination("C", "D") {
+		t.Errorf("Expected service to accept journeys on any of its portions")
+	}
+}
+
+func TestMoney_StringFollowsISO4217MinorUnits(t *testing.T) {
+	tests := []struct {
+		money    Money
+		expected string
+	}{
+		{Money{Amount: 12345, Currency: "EUR"}, "EUR 123.45"},
+		{Money{Amount: -5, Currency: "GBP"}, "GBP -0.05"},
+		{Money{Amount: 2599, Currency: "JPY"}, "JPY 2599"},
+		{Money{Amount: 12345, Currency: "KWD"}, "KWD 12.345"},
+		{Money{Amount: 1500, Currency: "BHD"}, "BHD 1.500"},
+		{Money{Amount: 10001, Currency: "CLF"}, "CLF 1.0001"},
+	}
+	
+	for _, test := range tests {
+		if result := test.money.String(); result != test.expected {
+			t.Errorf("String() = %s, expected %s", result, test.expected)
+		}
+	}
+}
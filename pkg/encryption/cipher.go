@@ -0,0 +1,265 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"ticketing-app/pkg/domain"
+)
+
+// prefix marks encrypted values. A value is the prefix followed by the
+// key ID, the wrapped data key and the sealed plaintext, each base64url
+// encoded and separated by dots. Values without the prefix are plaintext
+// written before encryption was enabled and are read as they are.
+const prefix = "enc:v1:"
+
+// dataKeyUses bounds how many values one data key encrypts, well below
+// the limit for random AES-GCM nonces.
+const dataKeyUses = 1 << 20
+
+type dataKey struct {
+	keyID   string
+	key     []byte
+	wrapped []byte
+	uses    int
+}
+
+// Cipher encrypts individual field values with data keys wrapped by a
+// KeyProvider. It is safe for concurrent use.
+type Cipher struct {
+	provider KeyProvider
+
+	mu        sync.Mutex
+	current   *dataKey
+	unwrapped map[string][]byte
+}
+
+func NewCipher(provider KeyProvider) *Cipher {
+	return &Cipher{provider: provider, unwrapped: make(map[string][]byte)}
+}
+
+// Encrypt seals a value bound to its context, the booking and field it
+// belongs to, so a value copied into another booking or field fails to
+// decrypt rather than being read as that field.
+func (c *Cipher) Encrypt(plaintext, context string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	dk, err := c.dataKey()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dk.key, []byte(plaintext), []byte(context))
+	if err != nil {
+		return "", err
+	}
+	return prefix + strings.Join([]string{
+		encode([]byte(dk.keyID)),
+		encode(dk.wrapped),
+		encode(sealed),
+	}, "."), nil
+}
+
+func (c *Cipher) Decrypt(value, context string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+	keyID, wrapped, sealed, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+	key, err := c.unwrap(keyID, wrapped)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(key, sealed, []byte(context))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Current reports whether a value is encrypted under the provider's
+// current key, and so needs no re-encryption after a rotation.
+func (c *Cipher) Current(value string) bool {
+	if value == "" {
+		return true
+	}
+	if !strings.HasPrefix(value, prefix) {
+		return false
+	}
+	keyID, _, _, err := parse(value)
+	return err == nil && keyID == c.provider.CurrentKeyID()
+}
+
+// EncryptBooking encrypts the personal data of every passenger: names,
+// contact details and travel documents, each bound to the booking ID and
+// field it is stored in. The booking passed in is left unchanged.
+func (c *Cipher) EncryptBooking(booking domain.Booking) (domain.Booking, error) {
+	return c.mapBooking(booking, c.encryptPassenger)
+}
+
+func (c *Cipher) DecryptBooking(booking domain.Booking) (domain.Booking, error) {
+	return c.mapBooking(booking, c.decryptPassenger)
+}
+
+// BookingCurrent reports whether all of a booking's personal data is
+// encrypted under the current key.
+func (c *Cipher) BookingCurrent(booking domain.Booking) bool {
+	current := true
+	c.mapBooking(booking, func(passenger domain.Passenger, context string) (domain.Passenger, error) {
+		current = current && c.Current(passenger.Name) && c.Current(passenger.Email) && c.Current(passenger.Phone)
+		if document := passenger.Document; document != nil {
+			current = current && c.Current(document.Number) && *document == domain.TravelDocument{Number: document.Number}
+		}
+		return passenger, nil
+	})
+	return current
+}
+
+func (c *Cipher) mapBooking(booking domain.Booking, fn func(domain.Passenger, string) (domain.Passenger, error)) (domain.Booking, error) {
+	passengers := make([]domain.Passenger, len(booking.Passengers))
+	for i, passenger := range booking.Passengers {
+		mapped, err := fn(passenger, fmt.Sprintf("%s/passengers/%d", booking.ID, i))
+		if err != nil {
+			return domain.Booking{}, fmt.Errorf("booking %s passenger %d: %w", booking.ID, i+1, err)
+		}
+		passengers[i] = mapped
+	}
+	tickets := make([]domain.Ticket, len(booking.Tickets))
+	for i, ticket := range booking.Tickets {
+		mapped, err := fn(ticket.Passenger, fmt.Sprintf("%s/tickets/%d", booking.ID, i))
+		if err != nil {
+			return domain.Booking{}, fmt.Errorf("booking %s ticket %d: %w", booking.ID, i+1, err)
+		}
+		ticket.Passenger = mapped
+		tickets[i] = ticket
+	}
+	booking.Passengers = passengers
+	booking.Tickets = tickets
+	return booking, nil
+}
+
+// encryptPassenger encrypts a passenger's name and contact details, and
+// seals the whole travel document as one value kept in its Number, so
+// neither the document type, issuing country nor expiry date is stored in
+// the clear.
+func (c *Cipher) encryptPassenger(passenger domain.Passenger, context string) (domain.Passenger, error) {
+	if err := c.mapContact(&passenger, context, c.Encrypt); err != nil {
+		return domain.Passenger{}, err
+	}
+	if passenger.Document != nil {
+		data, err := json.Marshal(passenger.Document)
+		if err != nil {
+			return domain.Passenger{}, err
+		}
+		sealed, err := c.Encrypt(string(data), context+"/document")
+		if err != nil {
+			return domain.Passenger{}, err
+		}
+		passenger.Document = &domain.TravelDocument{Number: sealed}
+	}
+	return passenger, nil
+}
+
+// decryptPassenger reverses encryptPassenger. Documents whose Number is
+// not encrypted were written before encryption and are read as they are.
+func (c *Cipher) decryptPassenger(passenger domain.Passenger, context string) (domain.Passenger, error) {
+	if err := c.mapContact(&passenger, context, c.Decrypt); err != nil {
+		return domain.Passenger{}, err
+	}
+	if passenger.Document != nil && strings.HasPrefix(passenger.Document.Number, prefix) {
+		data, err := c.Decrypt(passenger.Document.Number, context+"/document")
+		if err != nil {
+			return domain.Passenger{}, err
+		}
+		var document domain.TravelDocument
+		if err := json.Unmarshal([]byte(data), &document); err != nil {
+			return domain.Passenger{}, EncryptionError{Message: "Encrypted travel document is malformed", Code: "INVALID_CIPHERTEXT"}
+		}
+		passenger.Document = &document
+	}
+	return passenger, nil
+}
+
+func (c *Cipher) mapContact(passenger *domain.Passenger, context string, fn func(string, string) (string, error)) error {
+	var err error
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"name", &passenger.Name},
+		{"email", &passenger.Email},
+		{"phone", &passenger.Phone},
+	}
+	for _, field := range fields {
+		if *field.value, err = fn(*field.value, context+"/"+field.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dataKey returns the data key for new values, generating and wrapping a
+// fresh one after a rotation or once the current one is used up.
+func (c *Cipher) dataKey() (*dataKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keyID := c.provider.CurrentKeyID()
+	if c.current == nil || c.current.keyID != keyID || c.current.uses >= dataKeyUses {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate data key: %w", err)
+		}
+		wrapped, err := c.provider.WrapKey(keyID, key)
+		if err != nil {
+			return nil, err
+		}
+		c.current = &dataKey{keyID: keyID, key: key, wrapped: wrapped}
+		c.unwrapped[keyID+"."+encode(wrapped)] = key
+	}
+	c.current.uses++
+	return c.current, nil
+}
+
+func (c *Cipher) unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	cacheKey := keyID + "." + encode(wrapped)
+
+	c.mu.Lock()
+	key, cached := c.unwrapped[cacheKey]
+	c.mu.Unlock()
+	if cached {
+		return key, nil
+	}
+
+	key, err := c.provider.UnwrapKey(keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.unwrapped[cacheKey] = key
+	c.mu.Unlock()
+	return key, nil
+}
+
+func parse(value string) (keyID string, wrapped, sealed []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ".")
+	if len(parts) != 3 {
+		return "", nil, nil, EncryptionError{Message: "Encrypted value is malformed", Code: "INVALID_CIPHERTEXT"}
+	}
+	decoded := make([][]byte, len(parts))
+	for i, part := range parts {
+		if decoded[i], err = base64.RawURLEncoding.DecodeString(part); err != nil {
+			return "", nil, nil, EncryptionError{Message: "Encrypted value is malformed", Code: "INVALID_CIPHERTEXT"}
+		}
+	}
+	return string(decoded[0]), decoded[1], decoded[2], nil
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,369 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"ticketing-app/pkg/audit"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/reservation"
+	"ticketing-app/pkg/store"
+	"ticketing-app/pkg/testdata"
+	"time"
+)
+
+func localKeys(t *testing.T, ids ...string) *LocalKeys {
+	keys := NewLocalKeys()
+	for i, id := range ids {
+		if err := keys.Add(id, bytes.Repeat([]byte{byte(i + 1)}, 32)); err != nil {
+			t.Fatalf("Failed to add key %s: %v", id, err)
+		}
+	}
+	return keys
+}
+
+// fakeKMS "encrypts" by sealing with a key derived from the key ID and
+// counts calls, standing in for a remote service.
+type fakeKMS struct {
+	encrypts, decrypts int
+}
+
+func (f *fakeKMS) key(keyID string) []byte {
+	return bytes.Repeat([]byte{byte(len(keyID))}, 32)
+}
+
+func (f *fakeKMS) Encrypt(keyID string, plaintext []byte) ([]byte, error) {
+	f.encrypts++
+	return seal(f.key(keyID), plaintext, nil)
+}
+
+func (f *fakeKMS) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	f.decrypts++
+	return open(f.key(keyID), ciphertext, nil)
+}
+
+func passenger() domain.Passenger {
+	return domain.Passenger{
+		Name:  "John Doe",
+		Email: "john@example.com",
+		Phone: "+33 6 12 34 56 78",
+		Document: &domain.TravelDocument{
+			Type:           "passport",
+			Number:         "19FR12345",
+			IssuingCountry: "FR",
+			Expires:        time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestCipher_RoundTrip(t *testing.T) {
+	c := NewCipher(localKeys(t, "k1"))
+
+	encrypted, err := c.Encrypt("John Doe", "B0001/passengers/0/name")
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if !strings.HasPrefix(encrypted, prefix) || strings.Contains(encrypted, "John") {
+		t.Errorf("Expected an encrypted value, got %q", encrypted)
+	}
+	decrypted, err := c.Decrypt(encrypted, "B0001/passengers/0/name")
+	if err != nil || decrypted != "John Doe" {
+		t.Errorf("Expected John Doe, got %q (%v)", decrypted, err)
+	}
+
+	if empty, _ := c.Encrypt("", "B0001/passengers/0/phone"); empty != "" {
+		t.Errorf("Expected empty values to stay empty, got %q", empty)
+	}
+	if plain, _ := c.Decrypt("Jane Doe", "B0001/passengers/0/name"); plain != "Jane Doe" {
+		t.Errorf("Expected plaintext written before encryption to pass through, got %q", plain)
+	}
+}
+
+func TestCipher_Errors(t *testing.T) {
+	encrypted, _ := NewCipher(localKeys(t, "k1")).Encrypt("John Doe", "B0001/passengers/0/name")
+	keyID, wrapped, sealed, _ := parse(encrypted)
+	sealed[len(sealed)-1] ^= 1
+	tampered := prefix + encode([]byte(keyID)) + "." + encode(wrapped) + "." + encode(sealed)
+
+	tests := []struct {
+		name    string
+		keys    *LocalKeys
+		value   string
+		context string
+		code    string
+	}{
+		{"unknown key", localKeys(t, "k2"), encrypted, "B0001/passengers/0/name", "KEY_NOT_FOUND"},
+		{"wrong key material", func() *LocalKeys {
+			keys := NewLocalKeys()
+			keys.Add("k1", bytes.Repeat([]byte{9}, 32))
+			return keys
+		}(), encrypted, "B0001/passengers/0/name", "DECRYPTION_FAILED"},
+		{"tampered ciphertext", localKeys(t, "k1"), tampered, "B0001/passengers/0/name", "DECRYPTION_FAILED"},
+		{"copied to another booking", localKeys(t, "k1"), encrypted, "B0002/passengers/0/name", "DECRYPTION_FAILED"},
+		{"copied to another field", localKeys(t, "k1"), encrypted, "B0001/passengers/0/email", "DECRYPTION_FAILED"},
+		{"malformed", localKeys(t, "k1"), prefix + "abc", "B0001/passengers/0/name", "INVALID_CIPHERTEXT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCipher(tt.keys).Decrypt(tt.value, tt.context)
+			var encErr EncryptionError
+			if !errors.As(err, &encErr) || encErr.Code != tt.code {
+				t.Errorf("Expected %s, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestCipher_BookingLeavesOriginalUnchanged(t *testing.T) {
+	c := NewCipher(localKeys(t, "k1"))
+	booking := domain.Booking{
+		ID:         "B0001",
+		Passengers: []domain.Passenger{passenger()},
+		Tickets:    []domain.Ticket{{Passenger: passenger()}},
+	}
+
+	encrypted, err := c.EncryptBooking(booking)
+	if err != nil {
+		t.Fatalf("Failed to encrypt booking: %v", err)
+	}
+	if booking.Passengers[0].Name != "John Doe" || booking.Tickets[0].Passenger.Document.Number != "19FR12345" {
+		t.Error("Expected the original booking to be left unchanged")
+	}
+
+	p := encrypted.Tickets[0].Passenger
+	for _, value := range []string{p.Name, p.Email, p.Phone, p.Document.Number} {
+		if !strings.HasPrefix(value, prefix) {
+			t.Errorf("Expected %q to be encrypted", value)
+		}
+	}
+	if *p.Document != (domain.TravelDocument{Number: p.Document.Number}) {
+		t.Errorf("Expected the whole travel document to be encrypted, got %+v", p.Document)
+	}
+
+	decrypted, err := c.DecryptBooking(encrypted)
+	if err != nil {
+		t.Fatalf("Failed to decrypt booking: %v", err)
+	}
+	if decrypted.ContentChecksum() != booking.ContentChecksum() {
+		t.Errorf("Expected decryption to restore the booking, got %+v", decrypted.Passengers[0])
+	}
+
+	// Ciphertext moved into another booking no longer decrypts
+	encrypted.ID = "B0002"
+	var encErr EncryptionError
+	if _, err := c.DecryptBooking(encrypted); !errors.As(err, &encErr) || encErr.Code != "DECRYPTION_FAILED" {
+		t.Errorf("Expected DECRYPTION_FAILED for another booking's ciphertext, got %v", err)
+	}
+}
+
+func TestKMS_WrapsOneDataKeyPerCipher(t *testing.T) {
+	client := &fakeKMS{}
+	kms := NewKMS(client, "arn:aws:kms:eu-west-1:111122223333:key/pii")
+
+	c := NewCipher(kms)
+	var values []string
+	for i := 0; i < 10; i++ {
+		value, err := c.Encrypt("John Doe", "B0001/passengers/0/name")
+		if err != nil {
+			t.Fatalf("Failed to encrypt: %v", err)
+		}
+		values = append(values, value)
+	}
+	if client.encrypts != 1 {
+		t.Errorf("Expected one KMS call to wrap the data key, got %d", client.encrypts)
+	}
+
+	reader := NewCipher(kms)
+	for _, value := range values {
+		if plaintext, err := reader.Decrypt(value, "B0001/passengers/0/name"); err != nil || plaintext != "John Doe" {
+			t.Fatalf("Expected John Doe, got %q (%v)", plaintext, err)
+		}
+	}
+	if client.decrypts != 1 {
+		t.Errorf("Expected one KMS call to unwrap the data key, got %d", client.decrypts)
+	}
+}
+
+func TestStore_EncryptsAtRestAndRotates(t *testing.T) {
+	keys := localKeys(t, "k1", "k2")
+	inner := store.NewMemory()
+	encrypted := WrapStore(inner, NewCipher(keys))
+
+	rs, err := testdata.SetupTestDataWithStore(encrypted)
+	if err != nil {
+		t.Fatalf("Failed to set up system: %v", err)
+	}
+	_, err = rs.MakeReservation(domain.ReservationRequest{
+		ServiceID:    "5160",
+		Origin:       "Paris",
+		Destination:  "Amsterdam",
+		Passengers:   []domain.Passenger{passenger()},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A1"}},
+		Date:         time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Failed to book: %v", err)
+	}
+	inner.SaveBooking(domain.Booking{ID: "B0100", Passengers: []domain.Passenger{{Name: "Legacy Plaintext"}}})
+
+	raw, _, _ := inner.GetBooking("B0001")
+	if strings.Contains(raw.Passengers[0].Name, "John") || strings.Contains(raw.Tickets[0].Passenger.Email, "john") {
+		t.Errorf("Expected personal data to be encrypted at rest, got %+v", raw.Passengers[0])
+	}
+
+	reloaded, err := reservation.NewSystemWithStore(encrypted)
+	if err != nil {
+		t.Fatalf("Failed to reload system: %v", err)
+	}
+	agent := reloaded.QueryAs(audit.Caller{UserID: "a-test", Role: audit.Agent})
+	if p, found, _ := agent.GetPassengerOnSeat("5160", "A", "A1", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)); !found || p.Name != "John Doe" {
+		t.Errorf("Expected the reloaded system to see John Doe, got %+v", p)
+	}
+	if issues := reloaded.LoadIssues(); len(issues) != 0 {
+		t.Errorf("Expected checksums to hold over decrypted bookings, got %+v", issues)
+	}
+
+	keys.Rotate("k2")
+	rewritten, err := encrypted.Reencrypt()
+	if err != nil {
+		t.Fatalf("Failed to re-encrypt: %v", err)
+	}
+	if rewritten != 2 {
+		t.Errorf("Expected the k1 booking and the plaintext one to be rewritten, got %d", rewritten)
+	}
+	if again, _ := encrypted.Reencrypt(); again != 0 {
+		t.Errorf("Expected nothing left to re-encrypt, got %d", again)
+	}
+
+	// k1 can now be retired
+	onlyK2 := NewLocalKeys()
+	onlyK2.Add("k2", bytes.Repeat([]byte{2}, 32))
+	bookings, err := WrapStore(inner, NewCipher(onlyK2)).Bookings()
+	if err != nil {
+		t.Fatalf("Expected every booking to decrypt with k2 alone, got %v", err)
+	}
+	if bookings[1].Passengers[0].Name != "Legacy Plaintext" {
+		t.Errorf("Expected the legacy booking to decrypt, got %+v", bookings[1].Passengers[0])
+	}
+}
+
+func TestStore_SkipsBookingsItCannotDecrypt(t *testing.T) {
+	keys := localKeys(t, "k1", "k2")
+	inner := store.NewMemory()
+	rs, err := testdata.SetupTestDataWithStore(WrapStore(inner, NewCipher(keys)))
+	if err != nil {
+		t.Fatalf("Failed to set up system: %v", err)
+	}
+	book := func(seat string) {
+		_, err := rs.MakeReservation(domain.ReservationRequest{
+			ServiceID:    "5160",
+			Origin:       "Paris",
+			Destination:  "Amsterdam",
+			Passengers:   []domain.Passenger{passenger()},
+			SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: seat}},
+			Date:         time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("Failed to book %s: %v", seat, err)
+		}
+	}
+	book("A1")
+	keys.Rotate("k2")
+	book("A2")
+
+	// k1 removed before B0001 was re-encrypted
+	onlyK2 := NewLocalKeys()
+	onlyK2.Add("k2", bytes.Repeat([]byte{2}, 32))
+	encrypted := WrapStore(inner, NewCipher(onlyK2))
+
+	bookings, err := encrypted.Bookings()
+	var unreadable *store.UnreadableError
+	if !errors.As(err, &unreadable) || len(unreadable.Bookings) != 1 || unreadable.Bookings["B0001"] == nil {
+		t.Fatalf("Expected B0001 to be reported unreadable, got %v", err)
+	}
+	if len(bookings) != 1 || bookings[0].ID != "B0002" || bookings[0].Passengers[0].Name != "John Doe" {
+		t.Errorf("Expected B0002 to still decrypt, got %+v", bookings)
+	}
+
+	reloaded, err := reservation.NewSystemWithStore(encrypted)
+	if err != nil {
+		t.Fatalf("Expected the system to load around the unreadable booking, got %v", err)
+	}
+	if issues := reloaded.LoadIssues(); len(issues) != 1 || issues[0].BookingID != "B0001" || issues[0].Code != reservation.UnreadableInStore {
+		t.Errorf("Expected B0001 as an unreadable load issue, got %+v", issues)
+	}
+	if _, found, _ := reloaded.GetBooking("B0002"); !found {
+		t.Error("Expected B0002 to be loaded")
+	}
+	report, err := reloaded.Reconcile()
+	if err != nil {
+		t.Fatalf("Failed to reconcile: %v", err)
+	}
+	if report.Checked != 2 || len(report.Issues) != 1 || report.Issues[0].Code != reservation.UnreadableInStore {
+		t.Errorf("Expected only B0001 to be reported, got %+v", report)
+	}
+
+	rewritten, err := encrypted.Reencrypt()
+	if !errors.As(err, &unreadable) || rewritten != 0 {
+		t.Errorf("Expected B0001 to be skipped and reported, got %d rewritten and %v", rewritten, err)
+	}
+}
+
+func TestStore_EncryptsDistributionBookings(t *testing.T) {
+	keys := localKeys(t, "k1", "k2")
+	inner := store.NewMemory()
+	encrypted := WrapStore(inner, NewCipher(keys))
+
+	record := []byte(`{"passengers":[{"id":"p1","name":"John Doe"}]}`)
+	if err := encrypted.SaveDistributionBooking("BO000001", record); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	raw, _ := inner.DistributionBookings()
+	if bytes.Contains(raw["BO000001"], []byte("John")) {
+		t.Errorf("Expected the record to be encrypted at rest, got %s", raw["BO000001"])
+	}
+	records, err := encrypted.DistributionBookings()
+	if err != nil || !bytes.Equal(records["BO000001"], record) {
+		t.Errorf("Expected the record back, got %s (err %v)", records["BO000001"], err)
+	}
+
+	// A record moved to another booking does not decrypt
+	inner.SaveDistributionBooking("BO000002", raw["BO000001"])
+	records, err = encrypted.DistributionBookings()
+	var unreadable *store.UnreadableError
+	if !errors.As(err, &unreadable) || unreadable.Bookings["BO000002"] == nil || records["BO000001"] == nil {
+		t.Errorf("Expected only BO000002 to be unreadable, got %v", err)
+	}
+
+	keys.Rotate("k2")
+	inner.SaveDistributionBooking("BO000002", record)
+	rewritten, err := encrypted.Reencrypt()
+	if err != nil || rewritten != 2 {
+		t.Errorf("Expected both records to be rewritten, got %d (err %v)", rewritten, err)
+	}
+}
+
+func TestLoadLocalKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	k1 := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	k2 := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+	os.WriteFile(path, []byte(`{"current": "k2", "keys": {"k1": "`+k1+`", "k2": "`+k2+`"}}`), 0600)
+
+	keys, err := LoadLocalKeys(path)
+	if err != nil {
+		t.Fatalf("Failed to load keys: %v", err)
+	}
+	if keys.CurrentKeyID() != "k2" {
+		t.Errorf("Expected k2 to be current, got %s", keys.CurrentKeyID())
+	}
+
+	os.WriteFile(path, []byte(`{"current": "k3", "keys": {"k1": "`+k1+`"}}`), 0600)
+	if _, err := LoadLocalKeys(path); err == nil {
+		t.Error("Expected an unknown current key to be rejected")
+	}
+}
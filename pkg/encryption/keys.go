@@ -0,0 +1,243 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+type EncryptionError struct {
+	Message string
+	Code    string
+}
+
+func (e EncryptionError) Error() string {
+	return e.Message
+}
+
+// KeyProvider holds the key-encryption keys. Field values are encrypted
+// with data keys, and only the data keys are wrapped by the provider, so
+// a remote KMS is called once per data key rather than once per field.
+// Keys are named by ID; after a rotation the provider must still unwrap
+// data keys wrapped under earlier keys.
+type KeyProvider interface {
+	CurrentKeyID() string
+	WrapKey(keyID string, dataKey []byte) ([]byte, error)
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// LocalKeys keeps AES-256 key-encryption keys in process, for development
+// and for deployments without a KMS.
+type LocalKeys struct {
+	mu      sync.RWMutex
+	current string
+	keys    map[string][]byte
+}
+
+func NewLocalKeys() *LocalKeys {
+	return &LocalKeys{keys: make(map[string][]byte)}
+}
+
+// Add registers a 32-byte key. The first key added becomes current.
+func (l *LocalKeys) Add(keyID string, key []byte) error {
+	if len(key) != 32 {
+		return EncryptionError{
+			Message: fmt.Sprintf("Key %s is %d bytes, expected 32", keyID, len(key)),
+			Code:    "INVALID_KEY",
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.keys[keyID] = key
+	if l.current == "" {
+		l.current = keyID
+	}
+	return nil
+}
+
+// Rotate makes a registered key current. Earlier keys stay available for
+// decryption until every value has been re-encrypted.
+func (l *LocalKeys) Rotate(keyID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, exists := l.keys[keyID]; !exists {
+		return keyNotFound(keyID)
+	}
+	l.current = keyID
+	return nil
+}
+
+func (l *LocalKeys) CurrentKeyID() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.current
+}
+
+func (l *LocalKeys) WrapKey(keyID string, dataKey []byte) ([]byte, error) {
+	key, err := l.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return seal(key, dataKey, nil)
+}
+
+func (l *LocalKeys) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	key, err := l.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return open(key, wrapped, nil)
+}
+
+func (l *LocalKeys) key(keyID string) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	key, exists := l.keys[keyID]
+	if !exists {
+		return nil, keyNotFound(keyID)
+	}
+	return key, nil
+}
+
+// LoadLocalKeys reads a key file of the form
+//
+//	{"current": "k2", "keys": {"k1": "<base64>", "k2": "<base64>"}}
+func LoadLocalKeys(path string) (*LocalKeys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	var file struct {
+		Current string
+		Keys    map[string]string
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse key file %s: %w", path, err)
+	}
+
+	keys := NewLocalKeys()
+	for id, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key %s: %w", id, err)
+		}
+		if err := keys.Add(id, key); err != nil {
+			return nil, err
+		}
+	}
+	if err := keys.Rotate(file.Current); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// KMSClient is the part of a key management service used here. Adapters
+// for a cloud provider's SDK implement it with the provider's encrypt and
+// decrypt calls.
+type KMSClient interface {
+	Encrypt(keyID string, plaintext []byte) ([]byte, error)
+	Decrypt(keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// KMS wraps data keys with a key that never leaves the key management
+// service. Rotation is done in the KMS; SetCurrent points new data keys
+// at the new key ID.
+type KMS struct {
+	client  KMSClient
+	mu      sync.RWMutex
+	current string
+}
+
+func NewKMS(client KMSClient, keyID string) *KMS {
+	return &KMS{client: client, current: keyID}
+}
+
+func (k *KMS) SetCurrent(keyID string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.current = keyID
+}
+
+func (k *KMS) CurrentKeyID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.current
+}
+
+func (k *KMS) WrapKey(keyID string, dataKey []byte) ([]byte, error) {
+	wrapped, err := k.client.Encrypt(keyID, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key with %s: %w", keyID, err)
+	}
+	return wrapped, nil
+}
+
+func (k *KMS) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	dataKey, err := k.client.Decrypt(keyID, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key with %s: %w", keyID, err)
+	}
+	return dataKey, nil
+}
+
+// seal encrypts with AES-GCM and prefixes the random nonce.
+func seal(key, plaintext, associated []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, associated), nil
+}
+
+func open(key, sealed, associated []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, decryptionFailed("ciphertext is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, associated)
+	if err != nil {
+		return nil, decryptionFailed(err.Error())
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, EncryptionError{Message: fmt.Sprintf("Invalid key: %v", err), Code: "INVALID_KEY"}
+	}
+	return cipher.NewGCM(block)
+}
+
+func keyNotFound(keyID string) EncryptionError {
+	return EncryptionError{
+		Message: fmt.Sprintf("Key %s not found", keyID),
+		Code:    "KEY_NOT_FOUND",
+	}
+}
+
+func decryptionFailed(reason string) EncryptionError {
+	return EncryptionError{
+		Message: fmt.Sprintf("Decryption failed: %s", reason),
+		Code:    "DECRYPTION_FAILED",
+	}
+}
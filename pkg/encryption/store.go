@@ -0,0 +1,154 @@
+package encryption
+
+import (
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/store"
+)
+
+// Store encrypts passenger personal data on its way into the wrapped
+// store and decrypts it on the way out, so the reservation system only
+// ever sees plaintext. It deliberately does not offer snapshots: back up
+// the wrapped store, so backups stay encrypted.
+type Store struct {
+	store.Store
+	cipher *Cipher
+}
+
+func WrapStore(st store.Store, cipher *Cipher) *Store {
+	return &Store{Store: st, cipher: cipher}
+}
+
+func (s *Store) SaveBooking(booking domain.Booking) error {
+	encrypted, err := s.cipher.EncryptBooking(booking)
+	if err != nil {
+		return err
+	}
+	return s.Store.SaveBooking(encrypted)
+}
+
+func (s *Store) GetBooking(bookingID string) (domain.Booking, bool, error) {
+	booking, found, err := s.Store.GetBooking(bookingID)
+	if err != nil || !found {
+		return booking, found, err
+	}
+	decrypted, err := s.cipher.DecryptBooking(booking)
+	if err != nil {
+		return domain.Booking{}, false, err
+	}
+	return decrypted, true, nil
+}
+
+// Bookings decrypts every stored booking. One that cannot be decrypted,
+// say because its key was removed, is left out rather than failing the
+// rest, and listed in a *store.UnreadableError returned with them.
+func (s *Store) Bookings() ([]domain.Booking, error) {
+	bookings, err := s.Store.Bookings()
+	if err != nil {
+		return nil, err
+	}
+	decrypted := make([]domain.Booking, 0, len(bookings))
+	unreadable := make(map[string]error)
+	for _, booking := range bookings {
+		plain, err := s.cipher.DecryptBooking(booking)
+		if err != nil {
+			unreadable[booking.ID] = err
+			continue
+		}
+		decrypted = append(decrypted, plain)
+	}
+	if len(unreadable) > 0 {
+		return decrypted, &store.UnreadableError{Bookings: unreadable}
+	}
+	return decrypted, nil
+}
+
+// SaveDistributionBooking encrypts a distribution API's booking record
+// whole, as it carries the passengers' names.
+func (s *Store) SaveDistributionBooking(bookingID string, record []byte) error {
+	encrypted, err := s.cipher.Encrypt(string(record), distributionContext(bookingID))
+	if err != nil {
+		return err
+	}
+	return s.Store.SaveDistributionBooking(bookingID, []byte(encrypted))
+}
+
+// DistributionBookings decrypts the records kept. Like Bookings, it leaves
+// out records it cannot decrypt and lists them in a *store.UnreadableError.
+func (s *Store) DistributionBookings() (map[string][]byte, error) {
+	records, err := s.Store.DistributionBookings()
+	if err != nil {
+		return nil, err
+	}
+	decrypted := make(map[string][]byte, len(records))
+	unreadable := make(map[string]error)
+	for id, record := range records {
+		plain, err := s.cipher.Decrypt(string(record), distributionContext(id))
+		if err != nil {
+			unreadable[id] = err
+			continue
+		}
+		decrypted[id] = []byte(plain)
+	}
+	if len(unreadable) > 0 {
+		return decrypted, &store.UnreadableError{Bookings: unreadable}
+	}
+	return decrypted, nil
+}
+
+func distributionContext(bookingID string) string {
+	return "distribution/" + bookingID
+}
+
+// Reencrypt rewrites every booking and distribution record whose personal
+// data is not encrypted under the current key: after a key rotation, and
+// for plaintext ones written before encryption was enabled. It reports
+// how many it rewrote. Those it cannot decrypt are skipped and listed in a
+// *store.UnreadableError; retired keys can be removed once it has run
+// without one.
+func (s *Store) Reencrypt() (int, error) {
+	bookings, err := s.Store.Bookings()
+	if err != nil {
+		return 0, err
+	}
+
+	rewritten := 0
+	unreadable := make(map[string]error)
+	for _, booking := range bookings {
+		if s.cipher.BookingCurrent(booking) {
+			continue
+		}
+		decrypted, err := s.cipher.DecryptBooking(booking)
+		if err != nil {
+			unreadable[booking.ID] = err
+			continue
+		}
+		if err := s.SaveBooking(decrypted); err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+
+	records, err := s.Store.DistributionBookings()
+	if err != nil {
+		return rewritten, err
+	}
+	for id, record := range records {
+		if s.cipher.Current(string(record)) {
+			continue
+		}
+		plain, err := s.cipher.Decrypt(string(record), distributionContext(id))
+		if err != nil {
+			unreadable[id] = err
+			continue
+		}
+		if err := s.SaveDistributionBooking(id, []byte(plain)); err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+
+	if len(unreadable) > 0 {
+		return rewritten, &store.UnreadableError{Bookings: unreadable}
+	}
+	return rewritten, nil
+}
@@ -0,0 +1,32 @@
+package events
+
+import "testing"
+
+func TestBus_PublishSubscribe(t *testing.T) {
+	bus := NewBus()
+	
+	var received []string
+	unsubscribe := bus.Subscribe(func(e Event) {
+		received = append(received, e.Type)
+	})
+	
+	bus.Publish(Event{Type: "first"})
+	unsubscribe()
+	bus.Publish(Event{Type: "second"})
+	
+	if len(received) != 1 || received[0] != "first" {
+		t.Errorf("Expected only the event published while subscribed, got %v", received)
+	}
+}
+
+func TestBus_PublishSetsTime(t *testing.T) {
+	bus := NewBus()
+	
+	var event Event
+	bus.Subscribe(func(e Event) { event = e })
+	bus.Publish(Event{Type: "test"})
+	
+	if event.Time.IsZero() {
+		t.Errorf("Expected publish to stamp the event time")
+	}
+}
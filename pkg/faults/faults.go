@@ -0,0 +1,102 @@
+//go:build !production
+
+package faults
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Injector decides, per injection point, whether a call fails or is slowed
+// down. Its random source is seeded, so a test run with the same seed and
+// the same sequence of calls sees the same faults every time.
+//
+// Fault injection only exists in non-production builds; building with the
+// production tag replaces it with a no-op.
+type Injector struct {
+	mu    sync.Mutex
+	rng   *rand.Rand
+	rules map[Point]*rule
+	sleep func(time.Duration)
+}
+
+type rule struct {
+	Rule
+	calls    int
+	injected int
+}
+
+func New(seed int64) *Injector {
+	return &Injector{
+		rng:   rand.New(rand.NewSource(seed)),
+		rules: make(map[Point]*rule),
+		sleep: time.Sleep,
+	}
+}
+
+// SetSleep replaces time.Sleep, so tests can observe latency spikes
+// without waiting for them.
+func (i *Injector) SetSleep(sleep func(time.Duration)) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.sleep = sleep
+}
+
+// Set installs the rule for a point, resetting its call counts.
+func (i *Injector) Set(point Point, r Rule) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rules[point] = &rule{Rule: r}
+}
+
+func (i *Injector) Clear(point Point) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.rules, point)
+}
+
+// Check is called at an injection point. It may sleep, and returns the
+// injected error or nil.
+func (i *Injector) Check(point Point) error {
+	i.mu.Lock()
+	r, exists := i.rules[point]
+	if !exists {
+		i.mu.Unlock()
+		return nil
+	}
+	r.calls++
+	var delay time.Duration
+	if r.Latency > 0 && i.rng.Float64() < r.LatencyRate {
+		delay = r.Latency
+	}
+	fail := r.calls > r.After &&
+		(r.Times == 0 || r.injected < r.Times) &&
+		i.rng.Float64() < r.ErrorRate
+	if fail {
+		r.injected++
+	}
+	sleep := i.sleep
+	i.mu.Unlock()
+
+	if delay > 0 {
+		sleep(delay)
+	}
+	if !fail {
+		return nil
+	}
+	if r.Err != nil {
+		return r.Err
+	}
+	return Error{Point: point}
+}
+
+// Injected reports how many faults have been injected at a point.
+func (i *Injector) Injected(point Point) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if r, exists := i.rules[point]; exists {
+		return r.injected
+	}
+	return 0
+}
@@ -0,0 +1,134 @@
+//go:build !production
+
+package faults
+
+import (
+	"errors"
+	"testing"
+	"ticketing-app/pkg/audit"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/store"
+	"ticketing-app/pkg/testdata"
+	"time"
+)
+
+func TestInjector_IsDeterministic(t *testing.T) {
+	run := func() []bool {
+		injector := New(42)
+		injector.Set(StoreWrite, Rule{ErrorRate: 0.5})
+		var outcomes []bool
+		for i := 0; i < 20; i++ {
+			outcomes = append(outcomes, injector.Check(StoreWrite) != nil)
+		}
+		return outcomes
+	}
+	
+	first, second := run(), run()
+	failures := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected the same faults for the same seed, call %d differs", i)
+		}
+		if first[i] {
+			failures++
+		}
+	}
+	if failures == 0 || failures == len(first) {
+		t.Errorf("Expected some but not all calls to fail at rate 0.5, got %d of %d", failures, len(first))
+	}
+}
+
+func TestInjector_AfterAndTimes(t *testing.T) {
+	injector := New(1)
+	diskFull := errors.New("disk full")
+	injector.Set(StoreWrite, Rule{ErrorRate: 1, Err: diskFull, After: 2, Times: 1})
+	
+	var results []error
+	for i := 0; i < 4; i++ {
+		results = append(results, injector.Check(StoreWrite))
+	}
+	if results[0] != nil || results[1] != nil || !errors.Is(results[2], diskFull) || results[3] != nil {
+		t.Errorf("Expected only the third call to fail, got %v", results)
+	}
+	if injector.Injected(StoreWrite) != 1 {
+		t.Errorf("Expected 1 injected fault, got %d", injector.Injected(StoreWrite))
+	}
+	if err := injector.Check(StoreRead); err != nil {
+		t.Errorf("Expected points without a rule to pass, got %v", err)
+	}
+}
+
+func TestInjector_LatencySpikes(t *testing.T) {
+	injector := New(7)
+	var slept []time.Duration
+	injector.SetSleep(func(d time.Duration) { slept = append(slept, d) })
+	injector.Set(StoreRead, Rule{Latency: 2 * time.Second, LatencyRate: 1})
+	
+	if err := injector.Check(StoreRead); err != nil {
+		t.Errorf("Expected latency without an error, got %v", err)
+	}
+	if len(slept) != 1 || slept[0] != 2*time.Second {
+		t.Errorf("Expected a 2s spike, got %v", slept)
+	}
+}
+
+func TestStore_FailedWriteLeavesNoBooking(t *testing.T) {
+	injector := New(1)
+	rs, err := testdata.SetupTestDataWithStore(WrapStore(store.NewMemory(), injector))
+	if err != nil {
+		t.Fatalf("Failed to set up system: %v", err)
+	}
+	injector.Set(StoreWrite, Rule{ErrorRate: 1})
+	
+	_, err = rs.MakeReservation(domain.ReservationRequest{
+		ServiceID:    "5160",
+		Origin:       "Paris",
+		Destination:  "Amsterdam",
+		Passengers:   []domain.Passenger{{Name: "John Doe"}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A1"}},
+		Date:         time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	var fault Error
+	if !errors.As(err, &fault) || fault.Point != StoreWrite {
+		t.Fatalf("Expected an injected store write fault, got %v", err)
+	}
+	if bookings, _ := rs.QueryAs(audit.Caller{UserID: "a-test", Role: audit.Agent}).GetAllBookings(); len(bookings) != 0 {
+		t.Error("Expected no booking to be kept when the store write fails")
+	}
+	
+	injector.Clear(StoreWrite)
+	seats := rs.GetAvailableSeats("5160", "Paris", "Amsterdam", domain.FirstClass, time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	if len(seats) == 0 || seats[0].Number != "A1" {
+		t.Errorf("Expected A1 to still be available, got %v", seats)
+	}
+}
+
+func TestStore_ForwardsSnapshots(t *testing.T) {
+	injector := New(1)
+	wrapped := WrapStore(store.NewMemory(), injector)
+	if err := wrapped.SaveBooking(domain.Booking{ID: "B0001"}); err != nil {
+		t.Fatalf("Failed to save booking: %v", err)
+	}
+	
+	snapshotter, ok := wrapped.(store.Snapshotter)
+	if !ok {
+		t.Fatal("Expected a wrapped snapshotting store to still take snapshots")
+	}
+	if snapshot, err := snapshotter.Snapshot(); err != nil || len(snapshot.Bookings) != 1 {
+		t.Errorf("Expected a snapshot with 1 booking, got %+v: %v", snapshot, err)
+	}
+	
+	injector.Set(StoreRead, Rule{ErrorRate: 1})
+	if _, err := snapshotter.Snapshot(); err == nil {
+		t.Error("Expected snapshots to go through the injector")
+	}
+	
+	if _, ok := WrapStore(plainStore{store.NewMemory()}, injector).(store.Snapshotter); ok {
+		t.Error("Expected a store without snapshots to stay without them when wrapped")
+	}
+}
+
+// plainStore hides Memory's Snapshot method.
+type plainStore struct {
+	store.Store
+}
@@ -0,0 +1,37 @@
+package faults
+
+import (
+	"fmt"
+	"time"
+)
+
+// Point names a place in the code where a fault can be injected.
+type Point string
+
+const (
+	StoreRead   Point = "store.read"
+	StoreWrite  Point = "store.write"
+	StoreDelete Point = "store.delete"
+)
+
+// Rule configures faults at one point. ErrorRate and LatencyRate are
+// probabilities between 0 and 1. After skips the first calls and Times
+// caps the number of injected errors, which together make "fail only the
+// third call" expressible.
+type Rule struct {
+	ErrorRate   float64
+	Err         error // returned instead of the default Error
+	Latency     time.Duration
+	LatencyRate float64
+	After       int
+	Times       int
+}
+
+// Error is the default injected error.
+type Error struct {
+	Point Point
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("injected fault at %s", e.Point)
+}
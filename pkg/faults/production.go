@@ -0,0 +1,34 @@
+//go:build production
+
+package faults
+
+import (
+	"ticketing-app/pkg/store"
+	"time"
+)
+
+// Injector never injects anything in production builds. The API matches
+// the non-production one so wiring code compiles unchanged.
+type Injector struct{}
+
+func New(seed int64) *Injector {
+	return &Injector{}
+}
+
+func (i *Injector) SetSleep(sleep func(time.Duration)) {}
+
+func (i *Injector) Set(point Point, r Rule) {}
+
+func (i *Injector) Clear(point Point) {}
+
+func (i *Injector) Check(point Point) error {
+	return nil
+}
+
+func (i *Injector) Injected(point Point) int {
+	return 0
+}
+
+func WrapStore(st store.Store, injector *Injector) store.Store {
+	return st
+}
@@ -0,0 +1,195 @@
+//go:build !production
+
+package faults
+
+import (
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/store"
+)
+
+// Store wraps a store so its reads, writes and deletes pass through the
+// injector first. An injected error means the wrapped store is not called.
+type Store struct {
+	store.Store
+	injector *Injector
+}
+
+// WrapStore wraps a store; the wrapper takes snapshots, and restores them,
+// if the wrapped store does, so backups still read it in one consistent
+// view and restore it in one transaction. Restores count as writes.
+func WrapStore(st store.Store, injector *Injector) store.Store {
+	wrapped := &Store{Store: st, injector: injector}
+	snapshotter, ok := st.(store.Snapshotter)
+	if !ok {
+		return wrapped
+	}
+	snapshots := &snapshotStore{Store: wrapped, snapshotter: snapshotter}
+	if restorer, ok := st.(store.Restorer); ok {
+		return &restoreStore{snapshotStore: snapshots, restorer: restorer}
+	}
+	return snapshots
+}
+
+type snapshotStore struct {
+	*Store
+	snapshotter store.Snapshotter
+}
+
+func (s *snapshotStore) Snapshot() (store.Snapshot, error) {
+	if err := s.injector.Check(StoreRead); err != nil {
+		return store.Snapshot{}, err
+	}
+	return s.snapshotter.Snapshot()
+}
+
+type restoreStore struct {
+	*snapshotStore
+	restorer store.Restorer
+}
+
+func (s *restoreStore) Restore(snapshot store.Snapshot) error {
+	if err := s.injector.Check(StoreWrite); err != nil {
+		return err
+	}
+	return s.restorer.Restore(snapshot)
+}
+
+func (s *Store) SaveRoute(route domain.Route) error {
+	if err := s.injector.Check(StoreWrite); err != nil {
+		return err
+	}
+	return s.Store.SaveRoute(route)
+}
+
+func (s *Store) SaveStation(station domain.Station) error {
+	if err := s.injector.Check(StoreWrite); err != nil {
+		return err
+	}
+	return s.Store.SaveStation(station)
+}
+
+func (s *Store) SaveService(service domain.Service) error {
+	if err := s.injector.Check(StoreWrite); err != nil {
+		return err
+	}
+	return s.Store.SaveService(service)
+}
+
+func (s *Store) SaveCoupling(coupling domain.Coupling) error {
+	if err := s.injector.Check(StoreWrite); err != nil {
+		return err
+	}
+	return s.Store.SaveCoupling(coupling)
+}
+
+func (s *Store) SaveBooking(booking domain.Booking) error {
+	if err := s.injector.Check(StoreWrite); err != nil {
+		return err
+	}
+	return s.Store.SaveBooking(booking)
+}
+
+func (s *Store) DeleteRoute(routeID string) error {
+	if err := s.injector.Check(StoreDelete); err != nil {
+		return err
+	}
+	return s.Store.DeleteRoute(routeID)
+}
+
+func (s *Store) DeleteStation(name string) error {
+	if err := s.injector.Check(StoreDelete); err != nil {
+		return err
+	}
+	return s.Store.DeleteStation(name)
+}
+
+func (s *Store) DeleteService(serviceID string) error {
+	if err := s.injector.Check(StoreDelete); err != nil {
+		return err
+	}
+	return s.Store.DeleteService(serviceID)
+}
+
+func (s *Store) DeleteCoupling(key string) error {
+	if err := s.injector.Check(StoreDelete); err != nil {
+		return err
+	}
+	return s.Store.DeleteCoupling(key)
+}
+
+func (s *Store) DeleteBooking(bookingID string) error {
+	if err := s.injector.Check(StoreDelete); err != nil {
+		return err
+	}
+	return s.Store.DeleteBooking(bookingID)
+}
+
+func (s *Store) GetBooking(bookingID string) (domain.Booking, bool, error) {
+	if err := s.injector.Check(StoreRead); err != nil {
+		return domain.Booking{}, false, err
+	}
+	return s.Store.GetBooking(bookingID)
+}
+
+func (s *Store) Routes() ([]domain.Route, error) {
+	if err := s.injector.Check(StoreRead); err != nil {
+		return nil, err
+	}
+	return s.Store.Routes()
+}
+
+func (s *Store) Stations() ([]domain.Station, error) {
+	if err := s.injector.Check(StoreRead); err != nil {
+		return nil, err
+	}
+	return s.Store.Stations()
+}
+
+func (s *Store) Services() ([]domain.Service, error) {
+	if err := s.injector.Check(StoreRead); err != nil {
+		return nil, err
+	}
+	return s.Store.Services()
+}
+
+func (s *Store) Couplings() ([]domain.Coupling, error) {
+	if err := s.injector.Check(StoreRead); err != nil {
+		return nil, err
+	}
+	return s.Store.Couplings()
+}
+
+func (s *Store) Bookings() ([]domain.Booking, error) {
+	if err := s.injector.Check(StoreRead); err != nil {
+		return nil, err
+	}
+	return s.Store.Bookings()
+}
+
+func (s *Store) AppendAudit(entry []byte) error {
+	if err := s.injector.Check(StoreWrite); err != nil {
+		return err
+	}
+	return s.Store.AppendAudit(entry)
+}
+
+func (s *Store) SaveDistributionBooking(bookingID string, record []byte) error {
+	if err := s.injector.Check(StoreWrite); err != nil {
+		return err
+	}
+	return s.Store.SaveDistributionBooking(bookingID, record)
+}
+
+func (s *Store) DistributionBookings() (map[string][]byte, error) {
+	if err := s.injector.Check(StoreRead); err != nil {
+		return nil, err
+	}
+	return s.Store.DistributionBookings()
+}
+
+func (s *Store) Ping() error {
+	if err := s.injector.Check(StoreRead); err != nil {
+		return err
+	}
+	return s.Store.Ping()
+}
@@ -0,0 +1,175 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Catalog holds translated messages per locale. Lookups walk a fallback
+// chain from the most specific locale to the catalog's default locale.
+type Catalog struct {
+	mu            sync.RWMutex
+	defaultLocale string
+	messages      map[string]map[string]string
+}
+
+func NewCatalog(defaultLocale string) *Catalog {
+	return &Catalog{
+		defaultLocale: Normalize(defaultLocale),
+		messages:      make(map[string]map[string]string),
+	}
+}
+
+func (c *Catalog) DefaultLocale() string {
+	return c.defaultLocale
+}
+
+func (c *Catalog) Add(locale string, messages map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	locale = Normalize(locale)
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]string)
+	}
+	for key, message := range messages {
+		c.messages[locale][key] = message
+	}
+}
+
+// Lookup returns the message for key in the first locale of the fallback
+// chain that defines it.
+func (c *Catalog) Lookup(locale, key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, candidate := range Fallbacks(locale, c.defaultLocale) {
+		if message, exists := c.messages[candidate][key]; exists {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// Translate is Lookup that falls back to the given default text, so an
+// untranslated station name still prints as its canonical name.
+func (c *Catalog) Translate(locale, key, fallback string) string {
+	if message, found := c.Lookup(locale, key); found {
+		return message
+	}
+	return fallback
+}
+
+func (c *Catalog) Translator(locale string) Translator {
+	return Translator{catalog: c, locale: locale}
+}
+
+// Translator binds a catalog to one locale for use inside templates.
+type Translator struct {
+	catalog *Catalog
+	locale  string
+}
+
+func (t Translator) Locale() string {
+	return t.locale
+}
+
+func (t Translator) Text(key string, args ...interface{}) string {
+	message := t.catalog.Translate(t.locale, key, key)
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// named is implemented by stations and routes that carry their own display
+// names, keyed by locale.
+type named interface {
+	DisplayNames() map[string]string
+}
+
+// Station accepts a station value or a canonical name. A display name set on
+// the station itself wins over the catalog's "station." entries, which act as
+// the operator-wide default. It is the one place station names are
+// translated, so documents, APIs and displays agree.
+func (t Translator) Station(station interface{}) string {
+	return t.name("station.", station)
+}
+
+func (t Translator) Route(route interface{}) string {
+	return t.name("route.", route)
+}
+
+func (t Translator) name(prefix string, v interface{}) string {
+	if n, ok := v.(named); ok {
+		if name, found := ownName(n.DisplayNames(), t.locale); found {
+			return name
+		}
+	}
+	name := fmt.Sprint(v)
+	return t.catalog.Translate(t.locale, prefix+name, name)
+}
+
+// ownName looks a locale up in a value's own names, falling back from a
+// regional locale to its language (nl-BE to nl) but not to the catalog's
+// default locale, which the catalog itself covers.
+func ownName(names map[string]string, locale string) (string, bool) {
+	if len(names) == 0 {
+		return "", false
+	}
+	for _, candidate := range Fallbacks(locale, "") {
+		for key, name := range names {
+			if candidate != "" && Normalize(key) == candidate {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (t Translator) Class(zone interface{}) string {
+	name := fmt.Sprint(zone)
+	return t.catalog.Translate(t.locale, "class."+name, name)
+}
+
+func (t Translator) FareConditions(zone interface{}) string {
+	return t.catalog.Translate(t.locale, "conditions."+fmt.Sprint(zone), "")
+}
+
+// Placement describes how closely a party was seated, from the catalog's
+// "placement." entries.
+func (t Translator) Placement(strategy interface{}) string {
+	return t.catalog.Translate(t.locale, "placement."+fmt.Sprint(strategy), "")
+}
+
+func Normalize(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+}
+
+// Fallbacks returns the lookup order for a locale: the locale itself, its
+// base language, the default locale and its base language, then "" for
+// locale-independent entries. For example nl-BE with default en gives
+// nl-be, nl, en, "".
+func Fallbacks(locale, defaultLocale string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	add := func(l string) {
+		if !seen[l] {
+			seen[l] = true
+			chain = append(chain, l)
+		}
+	}
+
+	for _, l := range []string{Normalize(locale), Normalize(defaultLocale)} {
+		if l == "" {
+			continue
+		}
+		add(l)
+		if i := strings.Index(l, "-"); i > 0 {
+			add(l[:i])
+		}
+	}
+	add("")
+	return chain
+}
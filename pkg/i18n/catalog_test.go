@@ -0,0 +1,138 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFallbacks(t *testing.T) {
+	tests := []struct {
+		locale        string
+		defaultLocale string
+		expected      []string
+	}{
+		{"nl-BE", "en", []string{"nl-be", "nl", "en", ""}},
+		{"nl_BE", "en-GB", []string{"nl-be", "nl", "en-gb", "en", ""}},
+		{"en", "en", []string{"en", ""}},
+		{"", "en", []string{"en", ""}},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			result := Fallbacks(tt.locale, tt.defaultLocale)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestCatalog_Translate(t *testing.T) {
+	catalog := NewCatalog("en")
+	catalog.Add("en", map[string]string{"greeting": "Hello", "farewell": "Goodbye"})
+	catalog.Add("nl", map[string]string{"greeting": "Hallo"})
+	catalog.Add("nl-BE", map[string]string{"greeting": "Dag"})
+	
+	tests := []struct {
+		locale   string
+		key      string
+		expected string
+	}{
+		{"nl-BE", "greeting", "Dag"},
+		{"nl-NL", "greeting", "Hallo"},
+		{"nl-BE", "farewell", "Goodbye"},
+		{"fr", "greeting", "Hello"},
+		{"fr", "unknown", "fallback"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.locale+"_"+tt.key, func(t *testing.T) {
+			result := catalog.Translate(tt.locale, tt.key, "fallback")
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestTranslator(t *testing.T) {
+	translator := DefaultCatalog().Translator("fr")
+	
+	if result := translator.Station("London"); result != "Londres" {
+		t.Errorf("Expected Londres, got %s", result)
+	}
+	if result := translator.Station("Calais"); result != "Calais" {
+		t.Errorf("Expected untranslated station to keep its name, got %s", result)
+	}
+	if result := translator.Class("first-class"); result != "Première classe" {
+		t.Errorf("Expected Première classe, got %s", result)
+	}
+	if result := translator.Text("label.booking_confirmed", "B0001"); result != "Votre réservation B0001 est confirmée." {
+		t.Errorf("Unexpected confirmation text %q", result)
+	}
+}
+
+type namedStation struct {
+	name  string
+	names map[string]string
+}
+
+func (s namedStation) DisplayNames() map[string]string {
+	return s.names
+}
+
+func (s namedStation) String() string {
+	return s.name
+}
+
+func TestTranslator_PrefersOwnNames(t *testing.T) {
+	translator := DefaultCatalog().Translator("fr")
+	
+	if result := translator.Station(namedStation{"London", map[string]string{"fr": "Londres Saint-Pancras"}}); result != "Londres Saint-Pancras" {
+		t.Errorf("Expected the station's own name, got %s", result)
+	}
+	if result := translator.Station(namedStation{"London", nil}); result != "Londres" {
+		t.Errorf("Expected catalog fallback, got %s", result)
+	}
+}
+
+func TestTranslator_OwnNamesFollowLocaleFallbacks(t *testing.T) {
+	station := namedStation{"Paris", map[string]string{"fr": "Paris Nord", "nl": "Parijs-Noord", "de-CH": "Paris Nord (CH)"}}
+	
+	tests := []struct {
+		locale   string
+		expected string
+	}{
+		{"nl", "Parijs-Noord"},
+		{"nl-BE", "Parijs-Noord"},
+		{"fr_FR", "Paris Nord"},
+		{"de-CH", "Paris Nord (CH)"},
+		{"de", "Paris"},
+		{"", "Paris"},
+	}
+	
+	for _, test := range tests {
+		if result := DefaultCatalog().Translator(Normalize(test.locale)).Station(station); result != test.expected {
+			t.Errorf("Station in %q = %q, expected %q", test.locale, result, test.expected)
+		}
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"nl-BE", "nl-be"},
+		{"en;q=0.5, fr-FR, de;q=0.8", "fr-fr"},
+		{"*", ""},
+		{"fr;q=0, nl;q=0.3", "nl"},
+	}
+	
+	for _, test := range tests {
+		if result := FromAcceptLanguage(test.header); result != test.expected {
+			t.Errorf("FromAcceptLanguage(%q) = %q, expected %q", test.header, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package i18n
+
+// DefaultCatalog returns a catalog with the built-in document labels,
+// class names, fare conditions, party placements and station names.
+func DefaultCatalog() *Catalog {
+	c := NewCatalog("en")
+
+	c.Add("en", map[string]string{
+		"label.booking":                "Booking",
+		"label.booking_confirmed":      "Your booking %s is confirmed.",
+		"label.passenger":              "Passenger",
+		"label.service":                "Service",
+		"label.from":                   "From",
+		"label.to":                     "To",
+		"label.carriage":               "Carriage",
+		"label.seat":                   "Seat",
+		"label.class":                  "Class",
+		"label.departure":              "Departure",
+		"label.ticket":                 "Ticket",
+		"label.manifest":               "Passenger manifest",
+		"label.passengers":             "Passengers",
+		"label.contact":                "Questions? Contact %s.",
+		"label.price":                  "Price",
+		"label.total":                  "Total",
+		"class.first-class":            "First class",
+		"class.second-class":           "Second class",
+		"conditions.first-class":       "Exchangeable free of charge until departure. Refundable with a fee.",
+		"conditions.second-class":      "Exchangeable with a fee until departure. Non-refundable.",
+		"placement.together":           "Your party is seated together.",
+		"placement.pairs":              "Your party is seated in pairs in one carriage.",
+		"placement.same-bay":           "Your party is seated in the same bay.",
+		"placement.same-carriage":      "Your party is seated in the same carriage.",
+		"placement.adjacent-carriages": "Your party is seated in neighbouring carriages.",
+		"placement.apart":              "Your party could not be seated together.",
+	})
+
+	c.Add("fr", map[string]string{
+		"label.booking":                "Réservation",
+		"label.booking_confirmed":      "Votre réservation %s est confirmée.",
+		"label.passenger":              "Passager",
+		"label.service":                "Train",
+		"label.from":                   "De",
+		"label.to":                     "À",
+		"label.carriage":               "Voiture",
+		"label.seat":                   "Place",
+		"label.class":                  "Classe",
+		"label.departure":              "Départ",
+		"label.ticket":                 "Billet",
+		"label.manifest":               "Liste des passagers",
+		"label.passengers":             "Passagers",
+		"label.contact":                "Des questions ? Contactez %s.",
+		"label.price":                  "Prix",
+		"label.total":                  "Total",
+		"class.first-class":            "Première classe",
+		"class.second-class":           "Seconde classe",
+		"conditions.first-class":       "Échangeable gratuitement jusqu'au départ. Remboursable avec frais.",
+		"conditions.second-class":      "Échangeable avec frais jusqu'au départ. Non remboursable.",
+		"placement.together":           "Votre groupe est placé ensemble.",
+		"placement.pairs":              "Votre groupe est placé par deux dans la même voiture.",
+		"placement.same-bay":           "Votre groupe est placé dans le même carré.",
+		"placement.same-carriage":      "Votre groupe est placé dans la même voiture.",
+		"placement.adjacent-carriages": "Votre groupe est placé dans des voitures voisines.",
+		"placement.apart":              "Votre groupe n'a pas pu être placé ensemble.",
+		"station.London":               "Londres",
+		"station.Antwerp":              "Anvers",
+		"station.Dover":                "Douvres",
+		"station.Hannover":             "Hanovre",
+	})
+
+	c.Add("nl", map[string]string{
+		"label.booking":                "Boeking",
+		"label.booking_confirmed":      "Uw boeking %s is bevestigd.",
+		"label.passenger":              "Reiziger",
+		"label.service":                "Trein",
+		"label.from":                   "Van",
+		"label.to":                     "Naar",
+		"label.carriage":               "Rijtuig",
+		"label.seat":                   "Plaats",
+		"label.class":                  "Klasse",
+		"label.departure":              "Vertrek",
+		"label.ticket":                 "Vervoerbewijs",
+		"label.manifest":               "Reizigerslijst",
+		"label.passengers":             "Reizigers",
+		"label.contact":                "Vragen? Neem contact op met %s.",
+		"label.price":                  "Prijs",
+		"label.total":                  "Totaal",
+		"class.first-class":            "Eerste klas",
+		"class.second-class":           "Tweede klas",
+		"conditions.first-class":       "Kosteloos om te ruilen tot vertrek. Terugbetaalbaar tegen een vergoeding.",
+		"conditions.second-class":      "Om te ruilen tegen een vergoeding tot vertrek. Niet terugbetaalbaar.",
+		"placement.together":           "Uw groep zit bij elkaar.",
+		"placement.pairs":              "Uw groep zit per twee in hetzelfde rijtuig.",
+		"placement.same-bay":           "Uw groep zit in hetzelfde compartiment.",
+		"placement.same-carriage":      "Uw groep zit in hetzelfde rijtuig.",
+		"placement.adjacent-carriages": "Uw groep zit in naast elkaar gelegen rijtuigen.",
+		"placement.apart":              "Uw groep kon niet bij elkaar worden geplaatst.",
+		"station.Paris":                "Parijs",
+		"station.London":               "Londen",
+		"station.Antwerp":              "Antwerpen",
+		"station.Berlin":               "Berlijn",
+	})
+
+	c.Add("de", map[string]string{
+		"label.booking":                "Buchung",
+		"label.booking_confirmed":      "Ihre Buchung %s ist bestätigt.",
+		"label.passenger":              "Fahrgast",
+		"label.service":                "Zug",
+		"label.from":                   "Von",
+		"label.to":                     "Nach",
+		"label.carriage":               "Wagen",
+		"label.seat":                   "Platz",
+		"label.class":                  "Klasse",
+		"label.departure":              "Abfahrt",
+		"label.ticket":                 "Fahrkarte",
+		"label.manifest":               "Fahrgastliste",
+		"label.passengers":             "Fahrgäste",
+		"label.contact":                "Fragen? Kontaktieren Sie %s.",
+		"label.price":                  "Preis",
+		"label.total":                  "Gesamt",
+		"class.first-class":            "Erste Klasse",
+		"class.second-class":           "Zweite Klasse",
+		"conditions.first-class":       "Bis zur Abfahrt kostenlos umtauschbar. Gegen Gebühr erstattungsfähig.",
+		"conditions.second-class":      "Bis zur Abfahrt gegen Gebühr umtauschbar. Nicht erstattungsfähig.",
+		"placement.together":           "Ihre Gruppe sitzt zusammen.",
+		"placement.pairs":              "Ihre Gruppe sitzt paarweise im selben Wagen.",
+		"placement.same-bay":           "Ihre Gruppe sitzt im selben Abteil.",
+		"placement.same-carriage":      "Ihre Gruppe sitzt im selben Wagen.",
+		"placement.adjacent-carriages": "Ihre Gruppe sitzt in benachbarten Wagen.",
+		"placement.apart":              "Ihre Gruppe konnte nicht zusammen platziert werden.",
+		"station.Antwerp":              "Antwerpen",
+		"station.Hannover":             "Hannover",
+	})
+
+	return c
+}
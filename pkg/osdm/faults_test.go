@@ -0,0 +1,139 @@
+//go:build !production
+
+package osdm
+
+import (
+	"net/http"
+	"testing"
+	"ticketing-app/pkg/audit"
+	"ticketing-app/pkg/faults"
+	"ticketing-app/pkg/store"
+	"ticketing-app/pkg/testdata"
+)
+
+var agent = audit.Caller{UserID: "a-test", Role: audit.Agent}
+
+func TestServer_BookingRollsBackWhenAStoreWriteFails(t *testing.T) {
+	injector := faults.New(1)
+	system, err := testdata.SetupTestDataWithStore(faults.WrapStore(store.NewMemory(), injector))
+	if err != nil {
+		t.Fatalf("Failed to set up system: %v", err)
+	}
+	server := newServer(t, system)
+	passengers := []Passenger{{ID: "p1", Name: "John Doe"}}
+	
+	var offers OfferCollectionResponse
+	do(t, server, http.MethodPost, "/offers", OfferSearchRequest{
+		ServiceID:   "5160",
+		Origin:      "Paris",
+		Destination: "Amsterdam",
+		Date:        "2021-04-01",
+		Passengers:  passengers,
+	}, &offers)
+	if len(offers.Offers) != 2 {
+		t.Fatalf("Expected a first and a second-class offer, got %+v", offers.Offers)
+	}
+	
+	// The first reservation is written, the second fails
+	injector.Set(faults.StoreWrite, faults.Rule{ErrorRate: 1, After: 1, Times: 1})
+	
+	var problems ProblemResponse
+	status := do(t, server, http.MethodPost, "/bookings", BookingRequest{
+		Offers:     []OfferReference{{OfferID: offers.Offers[0].OfferID}, {OfferID: offers.Offers[1].OfferID}},
+		Passengers: passengers,
+	}, &problems)
+	if status != http.StatusInternalServerError {
+		t.Errorf("Expected 500 for a failed store write, got %d: %+v", status, problems)
+	}
+	if bookings, err := system.QueryAs(agent).GetAllBookings(); err != nil || len(bookings) != 0 {
+		t.Errorf("Expected the first reservation to be cancelled, got %+v (err %v)", bookings, err)
+	}
+}
+
+func TestServer_BookingUnavailableWhileStoreIsDown(t *testing.T) {
+	injector := faults.New(1)
+	system, err := testdata.SetupTestDataWithStore(faults.WrapStore(store.NewMemory(), injector))
+	if err != nil {
+		t.Fatalf("Failed to set up system: %v", err)
+	}
+	server := newServer(t, system)
+	passengers := []Passenger{{ID: "p1", Name: "John Doe"}}
+
+	var offers OfferCollectionResponse
+	do(t, server, http.MethodPost, "/offers", OfferSearchRequest{
+		ServiceID:   "5160",
+		Origin:      "Paris",
+		Destination: "Amsterdam",
+		Date:        "2021-04-01",
+		Passengers:  passengers,
+	}, &offers)
+
+	injector.Set(faults.StoreRead, faults.Rule{ErrorRate: 1})
+	injector.Set(faults.StoreWrite, faults.Rule{ErrorRate: 1})
+
+	var problems ProblemResponse
+	status := do(t, server, http.MethodPost, "/bookings", BookingRequest{
+		Offers:     []OfferReference{{OfferID: offers.Offers[0].OfferID}},
+		Passengers: passengers,
+	}, &problems)
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while the store is down, got %d: %+v", status, problems)
+	}
+	if len(problems.Problems) != 1 || problems.Problems[0].Code != "SERVICE_DEGRADED" {
+		t.Errorf("Expected a SERVICE_DEGRADED problem, got %+v", problems.Problems)
+	}
+}
+
+func TestServer_RefundResumesAfterAFailedCancellation(t *testing.T) {
+	injector := faults.New(1)
+	system, err := testdata.SetupTestDataWithStore(faults.WrapStore(store.NewMemory(), injector))
+	if err != nil {
+		t.Fatalf("Failed to set up system: %v", err)
+	}
+	server := newServer(t, system)
+	passengers := []Passenger{{ID: "p1", Name: "John Doe"}}
+
+	var offers OfferCollectionResponse
+	do(t, server, http.MethodPost, "/offers", OfferSearchRequest{
+		ServiceID:   "5160",
+		Origin:      "Paris",
+		Destination: "Amsterdam",
+		Date:        "2021-04-01",
+		Passengers:  passengers,
+	}, &offers)
+	var created BookingResponse
+	do(t, server, http.MethodPost, "/bookings", BookingRequest{
+		Offers:     []OfferReference{{OfferID: offers.Offers[0].OfferID}, {OfferID: offers.Offers[1].OfferID}},
+		Passengers: passengers,
+	}, &created)
+
+	var refund RefundOfferResponse
+	path := "/bookings/" + created.Booking.ID + "/refund-offers"
+	do(t, server, http.MethodPost, path, nil, &refund)
+	if refund.RefundOffer.RefundableAmount != created.Booking.Price {
+		t.Errorf("Expected the whole booking to be refundable, got %+v of %+v", refund.RefundOffer.RefundableAmount, created.Booking.Price)
+	}
+
+	// The first reservation is cancelled, the second fails
+	injector.Set(faults.StoreDelete, faults.Rule{ErrorRate: 1, After: 1, Times: 1})
+	confirm := RefundOfferPatchRequest{Status: StatusConfirmed}
+	if status := do(t, server, http.MethodPatch, path+"/"+refund.RefundOffer.ID, confirm, nil); status != http.StatusInternalServerError {
+		t.Errorf("Expected 500 for a failed cancellation, got %d", status)
+	}
+	var fetched BookingResponse
+	do(t, server, http.MethodGet, "/bookings/"+created.Booking.ID, nil, &fetched)
+	if fetched.Booking.Status != StatusPartiallyRefunded {
+		t.Errorf("Expected PARTIALLY_REFUNDED booking, got %s", fetched.Booking.Status)
+	}
+
+	if status := do(t, server, http.MethodPatch, path+"/"+refund.RefundOffer.ID, confirm, nil); status != http.StatusOK {
+		t.Errorf("Expected retrying the refund to succeed, got %d", status)
+	}
+	do(t, server, http.MethodGet, "/bookings/"+created.Booking.ID, nil, &fetched)
+	if fetched.Booking.Status != StatusRefunded {
+		t.Errorf("Expected REFUNDED booking, got %s", fetched.Booking.Status)
+	}
+	if bookings, err := system.QueryAs(agent).GetAllBookings(); err != nil || len(bookings) != 0 {
+		t.Errorf("Expected every reservation to be cancelled, got %+v (err %v)", bookings, err)
+	}
+}
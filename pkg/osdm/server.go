@@ -0,0 +1,622 @@
+package osdm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/i18n"
+	"ticketing-app/pkg/reservation"
+	"ticketing-app/pkg/store"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+type offerRecord struct {
+	offer   Offer
+	date    time.Time
+	zone    domain.ComfortZone
+	quoteID string // locked for as long as the offer is valid
+}
+
+// leg is one reservation in the System behind an OSDM booking.
+type leg struct {
+	ReservationID string       `json:"reservationId"`
+	Price         domain.Money `json:"price"`
+}
+
+// bookingRecord is an OSDM booking and the reservations behind it. Its mu
+// serialises the changes to one booking, which call the System, so that
+// they need not hold the server's lock.
+type bookingRecord struct {
+	mu      sync.Mutex
+	booking Booking
+	legs    []leg // not yet refunded
+}
+
+// storedBooking is a booking as saved through the System.
+type storedBooking struct {
+	Booking Booking `json:"booking"`
+	Legs    []leg   `json:"legs"`
+}
+
+// Server exposes the reservation System through an OSDM-style API so
+// third-party retailers can search offers, book them and refund them.
+// Offers resolve to seat allocation by comfort zone; retailers do not pick
+// individual seats. Each offer is backed by a quote locked in the System,
+// so booking it gets the price and seats offered. Offers and refund offers
+// are dropped once they expire, whenever a request comes in.
+//
+// Bookings are saved through the System's store whenever they change, so
+// their reservations can still be found and refunded after a restart.
+// Offers and refund offers live only as long as the server.
+type Server struct {
+	// mu guards the maps and the ID counter. It is never held across calls
+	// to the System.
+	mu           sync.Mutex
+	system       *reservation.System
+	logger       *slog.Logger
+	catalog      *i18n.Catalog
+	now          func() time.Time
+	offerTTL     time.Duration
+	offers       map[string]offerRecord
+	bookings     map[string]*bookingRecord
+	refundOffers map[string]RefundOffer
+	nextID       int
+}
+
+// NewServer loads the bookings saved through the system's store. Bookings
+// the store cannot read are logged and left out; their reservations stay
+// booked in the System for an agent to deal with.
+func NewServer(system *reservation.System) (*Server, error) {
+	s := &Server{
+		system:       system,
+		logger:       system.Logger(),
+		catalog:      i18n.DefaultCatalog(),
+		now:          time.Now,
+		offerTTL:     15 * time.Minute,
+		offers:       make(map[string]offerRecord),
+		bookings:     make(map[string]*bookingRecord),
+		refundOffers: make(map[string]RefundOffer),
+		nextID:       1,
+	}
+
+	records, err := system.DistributionBookings()
+	var unreadable *store.UnreadableError
+	if errors.As(err, &unreadable) {
+		s.logger.Error("osdm: bookings could not be read and were left out", "error", err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load bookings: %w", err)
+	}
+	for id, data := range records {
+		var stored storedBooking
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return nil, fmt.Errorf("failed to decode booking %s: %w", id, err)
+		}
+		s.bookings[id] = &bookingRecord{booking: stored.Booking, legs: stored.Legs}
+		var n int
+		if _, err := fmt.Sscanf(id, "BO%d", &n); err == nil && n >= s.nextID {
+			s.nextID = n + 1
+		}
+	}
+	return s, nil
+}
+
+func (s *Server) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// SetLogger sets where the server reports reservations it could not roll
+// back. It defaults to the system's logger, which masks passenger data.
+func (s *Server) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// SetCatalog sets the catalog station names in offers are translated with,
+// the same one the operator's documents use.
+func (s *Server) SetCatalog(catalog *i18n.Catalog) {
+	s.catalog = catalog
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	s.purgeExpired()
+
+	switch {
+	case len(parts) == 1 && parts[0] == "offers" && r.Method == http.MethodPost:
+		s.searchOffers(w, r)
+	case len(parts) == 1 && parts[0] == "bookings" && r.Method == http.MethodPost:
+		s.createBooking(w, r)
+	case len(parts) == 2 && parts[0] == "bookings" && r.Method == http.MethodGet:
+		s.getBooking(w, parts[1])
+	case len(parts) == 3 && parts[0] == "bookings" && parts[2] == "refund-offers" && r.Method == http.MethodPost:
+		s.createRefundOffer(w, parts[1])
+	case len(parts) == 4 && parts[0] == "bookings" && parts[2] == "refund-offers" && r.Method == http.MethodPatch:
+		s.confirmRefundOffer(w, r, parts[1], parts[3])
+	default:
+		writeProblems(w, http.StatusNotFound, Problem{
+			Code:  "NOT_FOUND",
+			Title: fmt.Sprintf("No resource for %s %s", r.Method, r.URL.Path),
+		})
+	}
+}
+
+func (s *Server) searchOffers(w http.ResponseWriter, r *http.Request) {
+	var req OfferSearchRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	date, err := time.Parse(dateLayout, req.Date)
+	if err != nil {
+		writeProblems(w, http.StatusBadRequest, Problem{
+			Code:   "INVALID_DATE",
+			Title:  "Travel date must be formatted as YYYY-MM-DD",
+			Detail: req.Date,
+		})
+		return
+	}
+
+	locale := i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+	service, _ := s.system.GetService(req.ServiceID)
+
+	var offers []Offer
+	for _, zone := range []domain.ComfortZone{domain.FirstClass, domain.SecondClass} {
+		seats := s.system.GetAvailableSeats(req.ServiceID, req.Origin, req.Destination, zone, date)
+		if len(seats) < len(req.Passengers) || len(seats) == 0 {
+			continue
+		}
+
+		quoteReq := domain.ReservationRequest{
+			ServiceID:   req.ServiceID,
+			Origin:      req.Origin,
+			Destination: req.Destination,
+			Date:        date,
+		}
+		for _, passenger := range req.Passengers {
+			quoteReq.Passengers = append(quoteReq.Passengers, domain.Passenger{Name: passenger.Name})
+			quoteReq.SeatRequests = append(quoteReq.SeatRequests, domain.SeatRequest{ComfortZone: zone})
+		}
+		quote, err := s.system.Quote(quoteReq)
+		if err != nil || !quote.Available {
+			continue
+		}
+		if _, err := s.system.LockQuote(quote.ID, s.offerTTL); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		offer := Offer{
+			ServiceID:       req.ServiceID,
+			Origin:          req.Origin,
+			Destination:     req.Destination,
+			OriginName:      s.stationName(service, req.Origin, locale),
+			DestinationName: s.stationName(service, req.Destination, locale),
+			Date:            req.Date,
+			ServiceClass:    string(zone),
+			AvailableSeats:  len(seats),
+			Price:           toPrice(quote.Price.Total),
+			ValidUntil:      s.now().Add(s.offerTTL),
+		}
+		s.mu.Lock()
+		offer.OfferID = s.newID("OF")
+		s.offers[offer.OfferID] = offerRecord{offer: offer, date: date, zone: zone, quoteID: quote.ID}
+		s.mu.Unlock()
+		offers = append(offers, offer)
+	}
+
+	writeJSON(w, http.StatusOK, OfferCollectionResponse{Offers: offers})
+}
+
+// createBooking books every referenced offer. Offers may be on different
+// services, so each becomes its own reservation; if a later one fails the
+// earlier ones are cancelled so the retailer never holds a partial booking.
+// Every offer and passenger reference is checked before anything is
+// booked. An offer that was booked is used up, as its locked quote is,
+// even if the booking then fails.
+func (s *Server) createBooking(w http.ResponseWriter, r *http.Request) {
+	var req BookingRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if len(req.Offers) == 0 {
+		writeProblems(w, http.StatusBadRequest, Problem{
+			Code:  "NO_OFFERS",
+			Title: "A booking needs at least one offer",
+		})
+		return
+	}
+
+	s.mu.Lock()
+	offers := make([]offerRecord, len(req.Offers))
+	for i, ref := range req.Offers {
+		offer, exists := s.offers[ref.OfferID]
+		if !exists || s.now().After(offer.offer.ValidUntil) {
+			s.mu.Unlock()
+			writeProblems(w, http.StatusNotFound, Problem{
+				Code:   "OFFER_NOT_FOUND",
+				Title:  "Offer does not exist or has expired",
+				Detail: ref.OfferID,
+			})
+			return
+		}
+		offers[i] = offer
+	}
+	bookingID := s.newID("BO")
+	s.mu.Unlock()
+
+	passengers := make(map[string]Passenger)
+	for _, passenger := range req.Passengers {
+		passengers[passenger.ID] = passenger
+	}
+
+	requests := make([]domain.ReservationRequest, len(req.Offers))
+	passengerIDs := make([][]string, len(req.Offers))
+	for i, ref := range req.Offers {
+		passengerIDs[i] = ref.PassengerIDs
+		if len(passengerIDs[i]) == 0 {
+			for _, passenger := range req.Passengers {
+				passengerIDs[i] = append(passengerIDs[i], passenger.ID)
+			}
+		}
+
+		offer := offers[i]
+		requests[i] = domain.ReservationRequest{
+			ServiceID:   offer.offer.ServiceID,
+			Origin:      offer.offer.Origin,
+			Destination: offer.offer.Destination,
+			Date:        offer.date,
+			QuoteID:     offer.quoteID,
+		}
+		for _, id := range passengerIDs[i] {
+			passenger, known := passengers[id]
+			if !known {
+				writeProblems(w, http.StatusBadRequest, Problem{
+					Code:   "UNKNOWN_PASSENGER",
+					Title:  "Offer references an unknown passenger",
+					Detail: id,
+				})
+				return
+			}
+			requests[i].Passengers = append(requests[i].Passengers, domain.Passenger{Name: passenger.Name})
+			requests[i].SeatRequests = append(requests[i].SeatRequests, domain.SeatRequest{ComfortZone: offer.zone})
+		}
+	}
+
+	record := &bookingRecord{booking: Booking{
+		ID:         bookingID,
+		Status:     StatusConfirmed,
+		CreatedOn:  s.now(),
+		Passengers: req.Passengers,
+	}}
+
+	var used []string
+	defer s.dropOffers(&used)
+
+	for i, ref := range req.Offers {
+		booking, err := s.system.MakeReservation(requests[i])
+		if err != nil {
+			s.rollback(record)
+			writeError(w, err)
+			return
+		}
+		used = append(used, ref.OfferID)
+		record.legs = append(record.legs, leg{ReservationID: booking.ID, Price: booking.Price.Total})
+
+		booked := BookedOffer{OfferID: ref.OfferID, Price: toPrice(booking.Price.Total)}
+		for j, ticket := range booking.Tickets {
+			booked.Reservations = append(booked.Reservations, Reservation{
+				PassengerID: passengerIDs[i][j],
+				Coach:       ticket.Seat.CarriageID,
+				Place:       ticket.Seat.Number,
+			})
+		}
+		booked.Placement = toPlacement(booking.Placement, passengerIDs[i])
+		record.booking.BookedOffers = append(record.booking.BookedOffers, booked)
+	}
+	record.booking.Price = toPrice(total(record.legs))
+
+	if err := s.save(record); err != nil {
+		s.rollback(record)
+		writeError(w, err)
+		return
+	}
+	s.mu.Lock()
+	s.bookings[record.booking.ID] = record
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, BookingResponse{Booking: record.booking})
+}
+
+// dropOffers removes offers whose quotes a booking has used. It takes a
+// pointer so it can be deferred before the offers are known.
+func (s *Server) dropOffers(offerIDs *[]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range *offerIDs {
+		delete(s.offers, id)
+	}
+}
+
+// save writes a booking through the System. Callers hold record.mu, or
+// have not yet made the booking visible to other requests.
+func (s *Server) save(record *bookingRecord) error {
+	data, err := json.Marshal(storedBooking{Booking: record.booking, Legs: record.legs})
+	if err != nil {
+		return err
+	}
+	return s.system.SaveDistributionBooking(record.booking.ID, data)
+}
+
+// rollback cancels the reservations of a booking that failed. One that
+// cannot be cancelled stays booked in the System without an OSDM booking
+// referring to it, so it is logged for an agent to cancel by hand.
+func (s *Server) rollback(record *bookingRecord) {
+	for _, leg := range record.legs {
+		if _, err := s.system.CancelBooking(leg.ReservationID); err != nil {
+			s.logger.Error("osdm: failed to roll back reservation of a failed booking",
+				"booking", record.booking.ID, "reservation", leg.ReservationID, "error", err)
+		}
+	}
+}
+
+// purgeExpired drops offers and refund offers past their validity.
+func (s *Server) purgeExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, offer := range s.offers {
+		if now.After(offer.offer.ValidUntil) {
+			delete(s.offers, id)
+		}
+	}
+	for id, refundOffer := range s.refundOffers {
+		if now.After(refundOffer.ValidUntil) {
+			delete(s.refundOffers, id)
+		}
+	}
+}
+
+func (s *Server) getBooking(w http.ResponseWriter, bookingID string) {
+	record, exists := s.booking(bookingID)
+	if !exists {
+		writeBookingNotFound(w, bookingID)
+		return
+	}
+	record.mu.Lock()
+	booking := record.booking
+	record.mu.Unlock()
+	writeJSON(w, http.StatusOK, BookingResponse{Booking: booking})
+}
+
+func (s *Server) createRefundOffer(w http.ResponseWriter, bookingID string) {
+	record, exists := s.booking(bookingID)
+	if !exists {
+		writeBookingNotFound(w, bookingID)
+		return
+	}
+	record.mu.Lock()
+	status, refundable := record.booking.Status, total(record.legs)
+	record.mu.Unlock()
+	if status == StatusRefunded {
+		writeProblems(w, http.StatusConflict, Problem{
+			Code:   "ALREADY_REFUNDED",
+			Title:  "Booking has already been refunded",
+			Detail: bookingID,
+		})
+		return
+	}
+
+	s.mu.Lock()
+	refundOffer := RefundOffer{
+		ID:               s.newID("RO"),
+		BookingID:        bookingID,
+		Status:           StatusProposed,
+		RefundableAmount: toPrice(refundable),
+		ValidUntil:       s.now().Add(s.offerTTL),
+	}
+	s.refundOffers[refundOffer.ID] = refundOffer
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, RefundOfferResponse{RefundOffer: refundOffer})
+}
+
+func (s *Server) confirmRefundOffer(w http.ResponseWriter, r *http.Request, bookingID, refundOfferID string) {
+	var req RefundOfferPatchRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	refundOffer, exists := s.refundOffers[refundOfferID]
+	record := s.bookings[bookingID]
+	s.mu.Unlock()
+	if !exists || refundOffer.BookingID != bookingID || s.now().After(refundOffer.ValidUntil) {
+		writeProblems(w, http.StatusNotFound, Problem{
+			Code:   "REFUND_OFFER_NOT_FOUND",
+			Title:  "Refund offer does not exist or has expired",
+			Detail: refundOfferID,
+		})
+		return
+	}
+	if req.Status != StatusConfirmed {
+		writeProblems(w, http.StatusBadRequest, Problem{
+			Code:   "INVALID_STATUS",
+			Title:  "Refund offers can only be confirmed",
+			Detail: req.Status,
+		})
+		return
+	}
+
+	// Each reservation is cancelled on its own. If one fails, those already
+	// cancelled stay cancelled and the booking is partially refunded; the
+	// same refund offer can be confirmed again to cancel the rest.
+	// Reservations already cancelled in the System are skipped, so a
+	// booking saved before its last cancellations can be refunded again.
+	record.mu.Lock()
+	defer record.mu.Unlock()
+	for len(record.legs) > 0 {
+		_, err := s.system.CancelBooking(record.legs[0].ReservationID)
+		var reservationErr reservation.ReservationError
+		if err != nil && !(errors.As(err, &reservationErr) && reservationErr.Code == "BOOKING_NOT_FOUND") {
+			if len(record.legs) < len(record.booking.BookedOffers) {
+				record.booking.Status = StatusPartiallyRefunded
+				if saveErr := s.save(record); saveErr != nil {
+					s.logger.Error("osdm: failed to save partially refunded booking",
+						"booking", record.booking.ID, "error", saveErr)
+				}
+			}
+			writeError(w, err)
+			return
+		}
+		record.legs = record.legs[1:]
+	}
+	record.booking.Status = StatusRefunded
+	if err := s.save(record); err != nil {
+		writeError(w, err)
+		return
+	}
+	refundOffer.Status = StatusConfirmed
+	s.mu.Lock()
+	delete(s.refundOffers, refundOfferID)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, RefundOfferResponse{RefundOffer: refundOffer})
+}
+
+func (s *Server) booking(bookingID string) (*bookingRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.bookings[bookingID]
+	return record, exists
+}
+
+// newID is called with s.mu held.
+func (s *Server) newID(prefix string) string {
+	id := fmt.Sprintf("%s%06d", prefix, s.nextID)
+	s.nextID++
+	return id
+}
+
+func total(legs []leg) domain.Money {
+	var sum domain.Money
+	for _, leg := range legs {
+		sum.Currency = leg.Price.Currency
+		sum.Amount += leg.Price.Amount
+	}
+	return sum
+}
+
+func toPrice(money domain.Money) Price {
+	return Price{Amount: money.Amount, Currency: money.Currency, Scale: domain.MinorUnits(money.Currency)}
+}
+
+// toPlacement refers to passengers by the IDs the retailer gave them, in
+// the order they were booked.
+func toPlacement(placement *domain.Placement, passengerIDs []string) *Placement {
+	if placement == nil {
+		return nil
+	}
+	result := &Placement{Strategy: string(placement.Strategy)}
+	for _, cluster := range placement.Clusters {
+		group := PlacementGroup{Coach: cluster.CarriageID, Places: cluster.Seats}
+		for _, passenger := range cluster.Passengers {
+			group.PassengerIDs = append(group.PassengerIDs, passengerIDs[passenger])
+		}
+		result.Groups = append(result.Groups, group)
+	}
+	return result
+}
+
+func (s *Server) stationName(service domain.Service, name, locale string) string {
+	if station, found := service.GetStation(name); found {
+		return s.catalog.Translator(locale).Station(station)
+	}
+	return s.catalog.Translator(locale).Station(name)
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeProblems(w, http.StatusBadRequest, Problem{
+			Code:   "INVALID_REQUEST_BODY",
+			Title:  "Request body is not valid JSON",
+			Detail: err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+func writeBookingNotFound(w http.ResponseWriter, bookingID string) {
+	writeProblems(w, http.StatusNotFound, Problem{
+		Code:   "BOOKING_NOT_FOUND",
+		Title:  "Booking not found",
+		Detail: bookingID,
+	})
+}
+
+// writeError maps reservation errors onto problems, keeping every
+// validation error so retailers can fix a request in one round trip.
+func writeError(w http.ResponseWriter, err error) {
+	var reservationErrs []reservation.ReservationError
+	var validationErrs reservation.ValidationErrors
+	var reservationErr reservation.ReservationError
+	switch {
+	case errors.As(err, &validationErrs):
+		reservationErrs = validationErrs
+	case errors.As(err, &reservationErr):
+		reservationErrs = []reservation.ReservationError{reservationErr}
+	default:
+		writeProblems(w, http.StatusInternalServerError, Problem{
+			Code:  "INTERNAL_ERROR",
+			Title: err.Error(),
+		})
+		return
+	}
+
+	status := http.StatusBadRequest
+	problems := make([]Problem, len(reservationErrs))
+	for i, e := range reservationErrs {
+		problemStatus := statusForCode(e.Code)
+		if problemStatus > status {
+			status = problemStatus
+		}
+		problems[i] = Problem{Code: e.Code, Title: e.Message, Status: problemStatus, Detail: e.Field}
+	}
+	writeJSON(w, status, ProblemResponse{Problems: problems})
+}
+
+func statusForCode(code string) int {
+	switch code {
+	case "SERVICE_NOT_FOUND", "BOOKING_NOT_FOUND", "QUOTE_NOT_FOUND", "QUOTE_EXPIRED":
+		return http.StatusNotFound
+	case "SEAT_ALREADY_BOOKED", "NO_SEAT_AVAILABLE", "QUOTE_SEATS_TAKEN":
+		return http.StatusConflict
+	case "SERVICE_DEGRADED":
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func writeProblems(w http.ResponseWriter, status int, problems ...Problem) {
+	for i := range problems {
+		problems[i].Status = status
+	}
+	writeJSON(w, status, ProblemResponse{Problems: problems})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
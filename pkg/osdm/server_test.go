@@ -0,0 +1,392 @@
+package osdm
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/pricing"
+	"ticketing-app/pkg/reservation"
+	"ticketing-app/pkg/store"
+	"ticketing-app/pkg/testdata"
+	"time"
+)
+
+func newServer(t *testing.T, system *reservation.System) *Server {
+	server, err := NewServer(system)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	return server
+}
+
+func do(t *testing.T, server http.Handler, method, path string, body interface{}, out interface{}) int {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("Failed to encode request: %v", err)
+		}
+	}
+	
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(method, path, &buf))
+	
+	if out != nil {
+		if err := json.NewDecoder(rec.Body).Decode(out); err != nil {
+			t.Fatalf("Failed to decode %s %s response: %v", method, path, err)
+		}
+	}
+	return rec.Code
+}
+
+func searchFirstClass(t *testing.T, server *Server, passengers []Passenger) Offer {
+	var offers OfferCollectionResponse
+	status := do(t, server, http.MethodPost, "/offers", OfferSearchRequest{
+		ServiceID:   "5160",
+		Origin:      "Paris",
+		Destination: "Amsterdam",
+		Date:        "2021-04-01",
+		Passengers:  passengers,
+	}, &offers)
+	if status != http.StatusOK {
+		t.Fatalf("Expected 200 from offer search, got %d", status)
+	}
+	
+	for _, offer := range offers.Offers {
+		if offer.ServiceClass == "first-class" {
+			return offer
+		}
+	}
+	t.Fatalf("Expected a first-class offer, got %+v", offers.Offers)
+	return Offer{}
+}
+
+func TestServer_OfferBookRefund(t *testing.T) {
+	server := newServer(t, testdata.SetupTestData())
+	passengers := []Passenger{{ID: "p1", Name: "John Doe"}, {ID: "p2", Name: "Jane Smith"}}
+	
+	offer := searchFirstClass(t, server, passengers)
+	if offer.AvailableSeats != 22 {
+		t.Errorf("Expected 22 first-class seats, got %d", offer.AvailableSeats)
+	}
+	if offer.Price.Amount <= 0 || offer.Price.Currency != "EUR" || offer.Price.Scale != 2 {
+		t.Errorf("Expected a price in euro cents, got %+v", offer.Price)
+	}
+	
+	var created BookingResponse
+	status := do(t, server, http.MethodPost, "/bookings", BookingRequest{
+		Offers:     []OfferReference{{OfferID: offer.OfferID}},
+		Passengers: passengers,
+	}, &created)
+	if status != http.StatusCreated {
+		t.Fatalf("Expected 201 from booking, got %d", status)
+	}
+	if created.Booking.Status != StatusConfirmed {
+		t.Errorf("Expected CONFIRMED booking, got %s", created.Booking.Status)
+	}
+	reservations := created.Booking.BookedOffers[0].Reservations
+	if len(reservations) != 2 || reservations[0].PassengerID != "p1" || reservations[0].Coach != "A" {
+		t.Errorf("Unexpected reservations %+v", reservations)
+	}
+	// The sample carriages have no layout, so no two seats are next to each other
+	placement := created.Booking.BookedOffers[0].Placement
+	if placement == nil || placement.Strategy != "same-carriage" || len(placement.Groups) != 2 ||
+		placement.Groups[1].Coach != "A" || placement.Groups[1].PassengerIDs[0] != "p2" ||
+		placement.Groups[1].Places[0] != reservations[1].Place {
+		t.Errorf("Expected each passenger in their own group in coach A, got %+v", placement)
+	}
+	
+	offer = searchFirstClass(t, server, passengers)
+	if offer.AvailableSeats != 20 {
+		t.Errorf("Expected 20 first-class seats after booking, got %d", offer.AvailableSeats)
+	}
+	
+	var fetched BookingResponse
+	if status := do(t, server, http.MethodGet, "/bookings/"+created.Booking.ID, nil, &fetched); status != http.StatusOK {
+		t.Fatalf("Expected 200 fetching booking, got %d", status)
+	}
+	
+	var refund RefundOfferResponse
+	path := "/bookings/" + created.Booking.ID + "/refund-offers"
+	if status := do(t, server, http.MethodPost, path, nil, &refund); status != http.StatusCreated {
+		t.Fatalf("Expected 201 from refund offer, got %d", status)
+	}
+	if created.Booking.Price != offer.Price || refund.RefundOffer.RefundableAmount != created.Booking.Price {
+		t.Errorf("Expected offer, booking and refund of %+v, got booking %+v and refund %+v",
+			offer.Price, created.Booking.Price, refund.RefundOffer.RefundableAmount)
+	}
+	if status := do(t, server, http.MethodPatch, path+"/"+refund.RefundOffer.ID, RefundOfferPatchRequest{Status: StatusConfirmed}, &refund); status != http.StatusOK {
+		t.Fatalf("Expected 200 confirming refund, got %d", status)
+	}
+	
+	do(t, server, http.MethodGet, "/bookings/"+created.Booking.ID, nil, &fetched)
+	if fetched.Booking.Status != StatusRefunded {
+		t.Errorf("Expected REFUNDED booking, got %s", fetched.Booking.Status)
+	}
+	
+	offer = searchFirstClass(t, server, passengers)
+	if offer.AvailableSeats != 22 {
+		t.Errorf("Expected refunded seats to be released, got %d available", offer.AvailableSeats)
+	}
+}
+
+func TestServer_ExpiredOffer(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := newServer(t, testdata.SetupTestData())
+	server.SetClock(func() time.Time { return now })
+	passengers := []Passenger{{ID: "p1", Name: "John Doe"}}
+	
+	offer := searchFirstClass(t, server, passengers)
+	now = now.Add(time.Hour)
+	
+	var problems ProblemResponse
+	status := do(t, server, http.MethodPost, "/bookings", BookingRequest{
+		Offers:     []OfferReference{{OfferID: offer.OfferID}},
+		Passengers: passengers,
+	}, &problems)
+	if status != http.StatusNotFound || problems.Problems[0].Code != "OFFER_NOT_FOUND" {
+		t.Errorf("Expected OFFER_NOT_FOUND, got %d %+v", status, problems)
+	}
+}
+
+func TestServer_PurgesExpiredOffers(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := newServer(t, testdata.SetupTestData())
+	server.SetClock(func() time.Time { return now })
+	passengers := []Passenger{{ID: "p1", Name: "John Doe"}}
+	
+	offer := searchFirstClass(t, server, passengers)
+	var created BookingResponse
+	do(t, server, http.MethodPost, "/bookings", BookingRequest{
+		Offers:     []OfferReference{{OfferID: offer.OfferID}},
+		Passengers: passengers,
+	}, &created)
+	do(t, server, http.MethodPost, "/bookings/"+created.Booking.ID+"/refund-offers", nil, nil)
+	searchFirstClass(t, server, passengers)
+	
+	now = now.Add(time.Hour)
+	do(t, server, http.MethodGet, "/bookings/"+created.Booking.ID, nil, nil)
+	if len(server.offers) != 0 || len(server.refundOffers) != 0 {
+		t.Errorf("Expected expired offers to be dropped, %d offers and %d refund offers left",
+			len(server.offers), len(server.refundOffers))
+	}
+}
+
+func TestServer_UnknownPassengerReference(t *testing.T) {
+	server := newServer(t, testdata.SetupTestData())
+	passengers := []Passenger{{ID: "p1", Name: "John Doe"}}
+	offer := searchFirstClass(t, server, passengers)
+	
+	var problems ProblemResponse
+	status := do(t, server, http.MethodPost, "/bookings", BookingRequest{
+		Offers:     []OfferReference{{OfferID: offer.OfferID, PassengerIDs: []string{"p9"}}},
+		Passengers: passengers,
+	}, &problems)
+	if status != http.StatusBadRequest || problems.Problems[0].Code != "UNKNOWN_PASSENGER" {
+		t.Errorf("Expected UNKNOWN_PASSENGER, got %d %+v", status, problems)
+	}
+}
+
+func TestServer_Problems(t *testing.T) {
+	server := newServer(t, testdata.SetupTestData())
+	
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    interface{}
+		status  int
+		errCode string
+	}{
+		{"Unknown route", http.MethodGet, "/trips", nil, http.StatusNotFound, "NOT_FOUND"},
+		{"Bad date", http.MethodPost, "/offers", OfferSearchRequest{Date: "01/04/2021"}, http.StatusBadRequest, "INVALID_DATE"},
+		{"Unknown booking", http.MethodGet, "/bookings/BO999999", nil, http.StatusNotFound, "BOOKING_NOT_FOUND"},
+		{"No offers", http.MethodPost, "/bookings", BookingRequest{}, http.StatusBadRequest, "NO_OFFERS"},
+		{"Unknown offer", http.MethodPost, "/bookings", BookingRequest{Offers: []OfferReference{{OfferID: "OF999999"}}}, http.StatusNotFound, "OFFER_NOT_FOUND"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var problems ProblemResponse
+			status := do(t, server, tt.method, tt.path, tt.body, &problems)
+			if status != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, status)
+			}
+			if tt.errCode != "" && (len(problems.Problems) == 0 || problems.Problems[0].Code != tt.errCode) {
+				t.Errorf("Expected %s, got %+v", tt.errCode, problems)
+			}
+		})
+	}
+}
+
+func TestServer_OfferStationNamesFollowAcceptLanguage(t *testing.T) {
+	server := newServer(t, testdata.SetupTestData())
+	
+	body, _ := json.Marshal(OfferSearchRequest{
+		ServiceID:   "5160",
+		Origin:      "Paris",
+		Destination: "Amsterdam",
+		Date:        "2021-04-01",
+		Passengers:  []Passenger{{ID: "p1"}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/offers", bytes.NewReader(body))
+	req.Header.Set("Accept-Language", "nl-BE,nl;q=0.9,en;q=0.5")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	
+	var offers OfferCollectionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&offers); err != nil || len(offers.Offers) == 0 {
+		t.Fatalf("Expected offers, got status %d: %v", rec.Code, err)
+	}
+	offer := offers.Offers[0]
+	if offer.Origin != "Paris" || offer.OriginName != "Parijs-Noord" {
+		t.Errorf("Expected origin Paris named Parijs-Noord, got %s named %s", offer.Origin, offer.OriginName)
+	}
+	if offer.DestinationName != "Amsterdam" {
+		t.Errorf("Expected untranslated destination to keep its name, got %s", offer.DestinationName)
+	}
+}
+
+func TestServer_OfferStationNamesFallBackToTheCatalog(t *testing.T) {
+	server := newServer(t, testdata.SetupTestData())
+	
+	body, _ := json.Marshal(OfferSearchRequest{
+		ServiceID:   "5160",
+		Origin:      "Antwerp",
+		Destination: "Amsterdam",
+		Date:        "2021-04-01",
+		Passengers:  []Passenger{{ID: "p1"}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/offers", bytes.NewReader(body))
+	req.Header.Set("Accept-Language", "fr")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	
+	var offers OfferCollectionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&offers); err != nil || len(offers.Offers) == 0 {
+		t.Fatalf("Expected offers, got status %d: %v", rec.Code, err)
+	}
+	if name := offers.Offers[0].OriginName; name != "Anvers" {
+		t.Errorf("Expected Antwerp named Anvers as on documents, got %s", name)
+	}
+}
+
+func TestServer_BookingsSurviveARestart(t *testing.T) {
+	st := store.NewMemory()
+	system, err := testdata.SetupTestDataWithStore(st)
+	if err != nil {
+		t.Fatalf("Failed to set up system: %v", err)
+	}
+	server := newServer(t, system)
+	passengers := []Passenger{{ID: "p1", Name: "John Doe"}}
+	
+	offer := searchFirstClass(t, server, passengers)
+	var created BookingResponse
+	if status := do(t, server, http.MethodPost, "/bookings", BookingRequest{
+		Offers:     []OfferReference{{OfferID: offer.OfferID}},
+		Passengers: passengers,
+	}, &created); status != http.StatusCreated {
+		t.Fatalf("Expected 201 from booking, got %d", status)
+	}
+	
+	reloaded, err := reservation.NewSystemWithStore(st)
+	if err != nil {
+		t.Fatalf("Failed to reload system: %v", err)
+	}
+	restarted := newServer(t, reloaded)
+	
+	var fetched BookingResponse
+	if status := do(t, restarted, http.MethodGet, "/bookings/"+created.Booking.ID, nil, &fetched); status != http.StatusOK {
+		t.Fatalf("Expected the booking to be found after a restart, got %d", status)
+	}
+	if fetched.Booking.Passengers[0].Name != "John Doe" || fetched.Booking.BookedOffers[0].Reservations[0] != created.Booking.BookedOffers[0].Reservations[0] {
+		t.Errorf("Expected the booking as made, got %+v", fetched.Booking)
+	}
+	
+	var refund RefundOfferResponse
+	path := "/bookings/" + created.Booking.ID + "/refund-offers"
+	if status := do(t, restarted, http.MethodPost, path, nil, &refund); status != http.StatusCreated || refund.RefundOffer.RefundableAmount != created.Booking.Price {
+		t.Fatalf("Expected a refund offer of %+v, got %d %+v", created.Booking.Price, status, refund.RefundOffer)
+	}
+	if status := do(t, restarted, http.MethodPatch, path+"/"+refund.RefundOffer.ID, RefundOfferPatchRequest{Status: StatusConfirmed}, nil); status != http.StatusOK {
+		t.Fatalf("Expected 200 confirming refund, got %d", status)
+	}
+	if offer := searchFirstClass(t, restarted, passengers); offer.AvailableSeats != 22 {
+		t.Errorf("Expected the reservation behind the booking to be cancelled, got %d seats available", offer.AvailableSeats)
+	}
+	
+	again := newServer(t, reloaded)
+	do(t, again, http.MethodGet, "/bookings/"+created.Booking.ID, nil, &fetched)
+	if fetched.Booking.Status != StatusRefunded {
+		t.Errorf("Expected the refund to be saved, got %s", fetched.Booking.Status)
+	}
+	offer = searchFirstClass(t, again, passengers)
+	do(t, again, http.MethodPost, "/bookings", BookingRequest{
+		Offers:     []OfferReference{{OfferID: offer.OfferID}},
+		Passengers: passengers,
+	}, &fetched)
+	if fetched.Booking.ID == created.Booking.ID {
+		t.Errorf("Expected a new booking ID after a restart, got %s again", fetched.Booking.ID)
+	}
+}
+
+func TestServer_OfferKeepsItsPriceAfterATariffChange(t *testing.T) {
+	system := testdata.SetupTestData()
+	server := newServer(t, system)
+	passengers := []Passenger{{ID: "p1", Name: "John Doe"}}
+	
+	offer := searchFirstClass(t, server, passengers)
+	tariff := pricing.DefaultTariff()
+	tariff.PerKm = map[domain.ComfortZone]int64{domain.FirstClass: 100, domain.SecondClass: 50}
+	system.SetTariff(tariff)
+	if repriced := searchFirstClass(t, server, passengers); repriced.Price == offer.Price {
+		t.Fatalf("Expected the new tariff to change the price, got %+v", repriced.Price)
+	}
+	
+	var created BookingResponse
+	if status := do(t, server, http.MethodPost, "/bookings", BookingRequest{
+		Offers:     []OfferReference{{OfferID: offer.OfferID}},
+		Passengers: passengers,
+	}, &created); status != http.StatusCreated {
+		t.Fatalf("Expected 201 from booking, got %d", status)
+	}
+	if created.Booking.Price != offer.Price {
+		t.Errorf("Expected the offered price %+v, got %+v", offer.Price, created.Booking.Price)
+	}
+}
+
+func TestServer_OfferIsBookedOnce(t *testing.T) {
+	server := newServer(t, testdata.SetupTestData())
+	passengers := []Passenger{{ID: "p1", Name: "John Doe"}}
+	offer := searchFirstClass(t, server, passengers)
+	
+	statuses := make([]int, 2)
+	var wg sync.WaitGroup
+	for i := range statuses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			statuses[i] = do(t, server, http.MethodPost, "/bookings", BookingRequest{
+				Offers:     []OfferReference{{OfferID: offer.OfferID}},
+				Passengers: passengers,
+			}, nil)
+		}(i)
+	}
+	wg.Wait()
+	
+	created, notFound := 0, 0
+	for _, status := range statuses {
+		switch status {
+		case http.StatusCreated:
+			created++
+		case http.StatusNotFound:
+			notFound++
+		}
+	}
+	if created != 1 || notFound != 1 {
+		t.Errorf("Expected one booking and one offer not found, got %v", statuses)
+	}
+}
@@ -0,0 +1,135 @@
+package osdm
+
+import "time"
+
+// Request and response bodies follow the OSDM online specification's
+// naming, trimmed to the parts the reservation system can fulfil.
+
+// Price is an amount in minor units; Scale is the number of decimals, so
+// 12345 at scale 2 is 123.45.
+type Price struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Scale    int    `json:"scale"`
+}
+
+type Passenger struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type OfferSearchRequest struct {
+	ServiceID   string      `json:"serviceId"`
+	Origin      string      `json:"origin"`
+	Destination string      `json:"destination"`
+	Date        string      `json:"date"`
+	Passengers  []Passenger `json:"passengers"`
+}
+
+type Offer struct {
+	OfferID         string    `json:"offerId"`
+	ServiceID       string    `json:"serviceId"`
+	Origin          string    `json:"origin"`
+	Destination     string    `json:"destination"`
+	OriginName      string    `json:"originName"` // in the Accept-Language of the search
+	DestinationName string    `json:"destinationName"`
+	Date            string    `json:"date"`
+	ServiceClass    string    `json:"serviceClass"`
+	AvailableSeats  int       `json:"availablePlaces"`
+	Price           Price     `json:"price"` // for all passengers of the search
+	ValidUntil      time.Time `json:"validUntil"`
+}
+
+type OfferCollectionResponse struct {
+	Offers []Offer `json:"offers"`
+}
+
+type BookingRequest struct {
+	Offers     []OfferReference `json:"offers"`
+	Passengers []Passenger      `json:"passengers"`
+}
+
+type OfferReference struct {
+	OfferID      string   `json:"offerId"`
+	PassengerIDs []string `json:"passengerRefs"`
+}
+
+type Reservation struct {
+	PassengerID string `json:"passengerRef"`
+	Coach       string `json:"coachNumber"`
+	Place       string `json:"placeNumber"`
+}
+
+type BookedOffer struct {
+	OfferID      string        `json:"offerId"`
+	Price        Price         `json:"price"`
+	Reservations []Reservation `json:"reservations"`
+	Placement    *Placement    `json:"placement,omitempty"` // unset for a single passenger
+}
+
+// Placement tells the retailer how closely the passengers of an offer sit:
+// Strategy is one of together, pairs, same-bay, same-carriage,
+// adjacent-carriages or apart, and each group is a run of places next to
+// each other.
+type Placement struct {
+	Strategy string           `json:"strategy"`
+	Groups   []PlacementGroup `json:"groups"`
+}
+
+type PlacementGroup struct {
+	Coach        string   `json:"coachNumber"`
+	Places       []string `json:"placeNumbers"`
+	PassengerIDs []string `json:"passengerRefs"`
+}
+
+type Booking struct {
+	ID           string        `json:"id"`
+	Status       string        `json:"status"`
+	CreatedOn    time.Time     `json:"createdOn"`
+	Passengers   []Passenger   `json:"passengers"`
+	BookedOffers []BookedOffer `json:"bookedOffers"`
+	Price        Price         `json:"price"`
+}
+
+type BookingResponse struct {
+	Booking Booking `json:"booking"`
+}
+
+type RefundOffer struct {
+	ID               string    `json:"id"`
+	BookingID        string    `json:"bookingId"`
+	Status           string    `json:"status"`
+	RefundableAmount Price     `json:"refundableAmount"`
+	ValidUntil       time.Time `json:"validUntil"`
+}
+
+type RefundOfferResponse struct {
+	RefundOffer RefundOffer `json:"refundOffer"`
+}
+
+type RefundOfferPatchRequest struct {
+	Status string `json:"status"`
+}
+
+// Problem is the RFC 7807 error body OSDM uses.
+type Problem struct {
+	Code   string `json:"code"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type ProblemResponse struct {
+	Problems []Problem `json:"problems"`
+}
+
+const (
+	StatusProposed  = "PROPOSED"
+	StatusConfirmed = "CONFIRMED"
+	StatusFulfilled = "FULFILLED"
+	StatusRefunded  = "REFUNDED"
+	// StatusPartiallyRefunded is a booking some of whose reservations were
+	// cancelled before a refund failed; confirming the refund offer again
+	// cancels the rest.
+	StatusPartiallyRefunded = "PARTIALLY_REFUNDED"
+)
@@ -0,0 +1,105 @@
+package pricing
+
+import (
+	"fmt"
+	"ticketing-app/pkg/domain"
+)
+
+// Tariff is a distance-based fare table. Amounts are in the minor unit of
+// Currency; rates in basis points (1/100 of a percent) so that a 12.5%
+// discount is 1250.
+type Tariff struct {
+	Currency           string
+	PerKm              map[domain.ComfortZone]int64
+	MinimumFare        int64
+	SeatReservationFee int64 // per passenger
+	BookingFee         int64 // per booking
+	TaxRate            int64 // basis points, charged on fares and fees after discounts
+	Discounts          map[domain.PassengerCategory]int64
+}
+
+func DefaultTariff() Tariff {
+	return Tariff{
+		Currency: "EUR",
+		PerKm: map[domain.ComfortZone]int64{
+			domain.FirstClass:  25,
+			domain.SecondClass: 15,
+		},
+		MinimumFare:        500,
+		SeatReservationFee: 300,
+		BookingFee:         150,
+		TaxRate:            900,
+		Discounts: map[domain.PassengerCategory]int64{
+			domain.Child:  5000,
+			domain.Youth:  2500,
+			domain.Senior: 3000,
+		},
+	}
+}
+
+// Leg is what one passenger travels: how far, in which class and, once a
+// seat is allocated, in which carriage.
+type Leg struct {
+	Passenger   domain.Passenger
+	ComfortZone domain.ComfortZone
+	Distance    int
+	Carriage    domain.Carriage
+}
+
+// Price breaks the price of a journey down per passenger. Tax is computed
+// per passenger so that each ticket's share adds up to what it shows.
+func (t Tariff) Price(legs []Leg) domain.PriceBreakdown {
+	breakdown := domain.PriceBreakdown{Total: domain.Money{Currency: t.Currency}}
+	add := func(passenger int, component domain.PriceComponent, description string, amount int64) {
+		if amount == 0 {
+			return
+		}
+		breakdown.Lines = append(breakdown.Lines, domain.PriceLine{
+			Component:   component,
+			Description: description,
+			Passenger:   passenger,
+			Amount:      domain.Money{Amount: amount, Currency: t.Currency},
+		})
+		breakdown.Total.Amount += amount
+	}
+
+	for i, leg := range legs {
+		fare := t.PerKm[leg.ComfortZone] * int64(leg.Distance)
+		if fare < t.MinimumFare {
+			fare = t.MinimumFare
+		}
+		adjustment := percentOf(fare, leg.Carriage.PriceAdjustment)
+		if leg.Carriage.PriceAdjustment < 0 {
+			// Round a carriage discount the way passenger discounts are
+			adjustment = -percentOf(fare, -leg.Carriage.PriceAdjustment)
+		}
+		discount := percentOf(fare+adjustment, t.Discounts[leg.Passenger.Category])
+
+		add(i, domain.FareComponent, fmt.Sprintf("%s fare, %d km", leg.ComfortZone, leg.Distance), fare)
+		add(i, domain.CarriageComponent, carriageNote(leg.Carriage), adjustment)
+		add(i, domain.DiscountComponent, fmt.Sprintf("%s discount", leg.Passenger.Category), -discount)
+		add(i, domain.FeeComponent, "Seat reservation", t.SeatReservationFee)
+		add(i, domain.TaxComponent, "VAT", percentOf(fare+adjustment-discount+t.SeatReservationFee, t.TaxRate))
+	}
+
+	if len(legs) > 0 {
+		add(-1, domain.FeeComponent, "Booking fee", t.BookingFee)
+		add(-1, domain.TaxComponent, "VAT", percentOf(t.BookingFee, t.TaxRate))
+	}
+	return breakdown
+}
+
+func carriageNote(carriage domain.Carriage) string {
+	switch {
+	case carriage.PriceNote != "":
+		return carriage.PriceNote
+	case carriage.PriceAdjustment < 0:
+		return fmt.Sprintf("Carriage %s discount", carriage.ID)
+	}
+	return fmt.Sprintf("Carriage %s premium", carriage.ID)
+}
+
+// percentOf applies a basis point rate, rounding half up.
+func percentOf(amount, basisPoints int64) int64 {
+	return (amount*basisPoints + 5000) / 10000
+}
@@ -0,0 +1,53 @@
+package pricing
+
+import (
+	"testing"
+	"ticketing-app/pkg/domain"
+)
+
+func TestTariff_Price(t *testing.T) {
+	tariff := DefaultTariff()
+	
+	tests := []struct {
+		name     string
+		legs     []Leg
+		expected int64
+	}{
+		{"No passengers", nil, 0},
+		{"Adult first class", []Leg{{ComfortZone: domain.FirstClass, Distance: 520}}, 13000 + 300 + 1197 + 150 + 14},
+		{"Child second class", []Leg{{Passenger: domain.Passenger{Category: domain.Child}, ComfortZone: domain.SecondClass, Distance: 520}}, 7800 - 3900 + 300 + 378 + 150 + 14},
+		{"Minimum fare", []Leg{{ComfortZone: domain.SecondClass, Distance: 10}}, 500 + 300 + 72 + 150 + 14},
+		{"Carriage premium", []Leg{{ComfortZone: domain.SecondClass, Distance: 520, Carriage: domain.Carriage{ID: "P", PriceAdjustment: 2000}}}, 7800 + 1560 + 300 + 869 + 150 + 14},
+		{"Child in discounted carriage", []Leg{{Passenger: domain.Passenger{Category: domain.Child}, ComfortZone: domain.SecondClass, Distance: 520, Carriage: domain.Carriage{ID: "Q", PriceAdjustment: -1000}}}, 7800 - 780 - 3510 + 300 + 343 + 150 + 14},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := tariff.Price(tt.legs)
+			if breakdown.Total.Amount != tt.expected || breakdown.Total.Currency != "EUR" {
+				t.Errorf("Expected EUR %d, got %s: %+v", tt.expected, breakdown.Total, breakdown.Lines)
+			}
+		})
+	}
+}
+
+func TestTariff_LinesAddUp(t *testing.T) {
+	breakdown := DefaultTariff().Price([]Leg{
+		{ComfortZone: domain.FirstClass, Distance: 450},
+		{Passenger: domain.Passenger{Category: domain.Senior}, ComfortZone: domain.FirstClass, Distance: 450},
+	})
+	
+	components := breakdown.Sum(domain.FareComponent).Amount + breakdown.Sum(domain.FeeComponent).Amount +
+		breakdown.Sum(domain.DiscountComponent).Amount + breakdown.Sum(domain.TaxComponent).Amount
+	if components != breakdown.Total.Amount {
+		t.Errorf("Expected components to add up to %d, got %d", breakdown.Total.Amount, components)
+	}
+	
+	perPassenger := breakdown.PassengerTotal(0).Amount + breakdown.PassengerTotal(1).Amount + breakdown.PassengerTotal(-1).Amount
+	if perPassenger != breakdown.Total.Amount {
+		t.Errorf("Expected passenger shares to add up to %d, got %d", breakdown.Total.Amount, perPassenger)
+	}
+	if discount := breakdown.Sum(domain.DiscountComponent).Amount; discount != -3375 {
+		t.Errorf("Expected a 30%% senior discount of -3375, got %d", discount)
+	}
+}
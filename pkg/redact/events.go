@@ -0,0 +1,18 @@
+package redact
+
+import "ticketing-app/pkg/events"
+
+// Event masks passenger data in an event's payload, for events leaving
+// the system as webhooks or messages.
+func (p Policy) Event(event events.Event) events.Event {
+	event.Data = p.Value(event.Data)
+	return event
+}
+
+// Subscribe registers a handler that only ever sees masked events, for
+// subscribers that forward events outside the system.
+func Subscribe(bus *events.Bus, policy Policy, handler func(events.Event)) func() {
+	return bus.Subscribe(func(event events.Event) {
+		handler(policy.Event(event))
+	})
+}
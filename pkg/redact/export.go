@@ -0,0 +1,56 @@
+package redact
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"ticketing-app/pkg/domain"
+)
+
+var exportHeader = []string{
+	"booking_id", "service_id", "date", "origin", "destination", "carriage", "seat",
+	"comfort_zone", "category", "passenger", "email", "phone", "document",
+	"price_minor_units", "currency",
+}
+
+// WriteBookingsCSV exports one row per ticket for analytics, with
+// passenger data masked according to the policy.
+func WriteBookingsCSV(w io.Writer, bookings []domain.Booking, policy Policy) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(exportHeader); err != nil {
+		return err
+	}
+
+	for _, booking := range bookings {
+		for _, ticket := range booking.Tickets {
+			passenger := policy.Passenger(ticket.Passenger)
+			document := ""
+			if passenger.Document != nil {
+				document = passenger.Document.Number
+			}
+			err := out.Write([]string{
+				booking.ID,
+				ticket.Service.ID,
+				ticket.Service.DateTime.Format("2006-01-02"),
+				ticket.Origin.Name,
+				ticket.Destination.Name,
+				ticket.Seat.CarriageID,
+				ticket.Seat.Number,
+				string(ticket.Seat.ComfortZone),
+				string(passenger.Category),
+				passenger.Name,
+				passenger.Email,
+				passenger.Phone,
+				document,
+				strconv.FormatInt(ticket.Price.Amount, 10),
+				ticket.Price.Currency,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
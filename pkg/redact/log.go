@@ -0,0 +1,58 @@
+package redact
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Handler masks passenger data in every attribute before passing records
+// on, so a logger built on it cannot leak a name by logging a booking.
+type Handler struct {
+	inner  slog.Handler
+	policy Policy
+}
+
+func NewHandler(inner slog.Handler, policy Policy) *Handler {
+	return &Handler{inner: inner, policy: policy}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	masked := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		masked.AddAttrs(h.attr(attr))
+		return true
+	})
+	return h.inner.Handle(ctx, masked)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	masked := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		masked[i] = h.attr(attr)
+	}
+	return &Handler{inner: h.inner.WithAttrs(masked), policy: h.policy}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{inner: h.inner.WithGroup(name), policy: h.policy}
+}
+
+func (h *Handler) attr(attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	switch attr.Value.Kind() {
+	case slog.KindAny:
+		attr.Value = slog.AnyValue(h.policy.Value(attr.Value.Any()))
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		masked := make([]slog.Attr, len(group))
+		for i, member := range group {
+			masked[i] = h.attr(member)
+		}
+		attr.Value = slog.GroupValue(masked...)
+	}
+	return attr
+}
@@ -0,0 +1,228 @@
+package redact
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"ticketing-app/pkg/domain"
+	"unicode/utf8"
+)
+
+// Field is a piece of passenger personal data that is masked unless a
+// policy reveals it.
+type Field string
+
+const (
+	Name     Field = "name"
+	Email    Field = "email"
+	Phone    Field = "phone"
+	Document Field = "document"
+)
+
+// Policy decides which fields are shown in full. The zero value masks
+// everything; consumers that need a field, such as a conductor's manifest
+// needing names, opt in with Reveal.
+type Policy struct {
+	reveal map[Field]bool
+}
+
+func Reveal(fields ...Field) Policy {
+	reveal := make(map[Field]bool, len(fields))
+	for _, field := range fields {
+		reveal[field] = true
+	}
+	return Policy{reveal: reveal}
+}
+
+func (p Policy) Reveals(field Field) bool {
+	return p.reveal[field]
+}
+
+// Passenger returns a copy of the passenger with unrevealed fields masked.
+func (p Policy) Passenger(passenger domain.Passenger) domain.Passenger {
+	if !p.reveal[Name] {
+		passenger.Name = MaskName(passenger.Name)
+	}
+	if !p.reveal[Email] {
+		passenger.Email = MaskEmail(passenger.Email)
+	}
+	if !p.reveal[Phone] {
+		passenger.Phone = MaskPhone(passenger.Phone)
+	}
+	if passenger.Document != nil && !p.reveal[Document] {
+		document := *passenger.Document
+		document.Number = MaskDocument(document.Number)
+		passenger.Document = &document
+	}
+	return passenger
+}
+
+// Value returns a copy of v with every domain.Passenger inside it masked,
+// however deeply nested: in bookings, tickets, manifests, event payloads.
+// Parts of v that cannot hold a passenger are shared, not copied.
+func (p Policy) Value(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return p.walk(reflect.ValueOf(v)).Interface()
+}
+
+func (p Policy) walk(v reflect.Value) reflect.Value {
+	if !mayHoldPassenger(v.Type()) {
+		return v
+	}
+	if v.Type() == passengerType {
+		return reflect.ValueOf(p.Passenger(v.Interface().(domain.Passenger)))
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(p.walk(v.Elem()))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(p.walk(v.Elem()))
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if out.Field(i).CanSet() {
+				out.Field(i).Set(p.walk(v.Field(i)))
+			}
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(p.walk(v.Index(i)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(p.walk(v.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), p.walk(iter.Value()))
+		}
+		return out
+	}
+	return v
+}
+
+var (
+	passengerType = reflect.TypeOf(domain.Passenger{})
+
+	holdsMu sync.Mutex
+	holds   = make(map[reflect.Type]bool)
+)
+
+// mayHoldPassenger reports whether a value of type t can contain a
+// passenger. Interfaces can hold anything, so they always may.
+func mayHoldPassenger(t reflect.Type) bool {
+	holdsMu.Lock()
+	defer holdsMu.Unlock()
+
+	result, known := holds[t]
+	if !known {
+		result = typeHolds(t, make(map[reflect.Type]bool))
+		holds[t] = result
+	}
+	return result
+}
+
+// typeHolds leaves caching to mayHoldPassenger: results found part way
+// through a recursive type are incomplete.
+func typeHolds(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if result, known := holds[t]; known {
+		return result
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+
+	result := false
+	switch t.Kind() {
+	case reflect.Interface:
+		result = true
+	case reflect.Struct:
+		if t == passengerType {
+			result = true
+			break
+		}
+		for i := 0; i < t.NumField() && !result; i++ {
+			result = t.Field(i).IsExported() && typeHolds(t.Field(i).Type, visiting)
+		}
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		result = typeHolds(t.Elem(), visiting)
+	}
+	return result
+}
+
+// MaskName keeps the initial of each word: "John Doe" becomes "J*** D***".
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		r, _ := utf8.DecodeRuneInString(word)
+		words[i] = string(r) + "***"
+	}
+	return strings.Join(words, " ")
+}
+
+// MaskEmail keeps the first character and the domain.
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return mask(email, 0)
+	}
+	r, _ := utf8.DecodeRuneInString(email)
+	return string(r) + "***" + email[at:]
+}
+
+// MaskPhone keeps the last two digits.
+func MaskPhone(phone string) string {
+	var digits []rune
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, r)
+		}
+	}
+	return mask(string(digits), 2)
+}
+
+// MaskDocument keeps the last three characters of a document number.
+func MaskDocument(number string) string {
+	return mask(number, 3)
+}
+
+// mask replaces all but the last keep characters, and everything when the
+// value is too short for the kept part to be anonymous.
+func mask(value string, keep int) string {
+	if value == "" {
+		return ""
+	}
+	runes := []rune(value)
+	if len(runes) <= 2*keep {
+		return "***"
+	}
+	return "***" + string(runes[len(runes)-keep:])
+}
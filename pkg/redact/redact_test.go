@@ -0,0 +1,171 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/csv"
+	"log/slog"
+	"strings"
+	"testing"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"time"
+)
+
+func passenger() domain.Passenger {
+	return domain.Passenger{
+		Name:     "John Doe",
+		Email:    "john.doe@example.com",
+		Phone:    "+33 6 12 34 56 78",
+		Document: &domain.TravelDocument{Type: "passport", Number: "19FR12345", IssuingCountry: "FR"},
+		Category: domain.Child,
+	}
+}
+
+func booking() domain.Booking {
+	return domain.Booking{
+		ID:         "B0001",
+		Passengers: []domain.Passenger{passenger()},
+		Tickets: []domain.Ticket{{
+			Passenger:   passenger(),
+			Service:     domain.Service{ID: "5160", DateTime: time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC)},
+			Origin:      domain.NewStation("Paris"),
+			Destination: domain.NewStation("Amsterdam"),
+			Seat:        domain.Seat{Number: "A1", CarriageID: "A", ComfortZone: domain.FirstClass},
+			Price:       domain.Money{Amount: 7412, Currency: "EUR"},
+		}},
+	}
+}
+
+func TestMasks(t *testing.T) {
+	tests := []struct {
+		name     string
+		mask     func(string) string
+		value    string
+		expected string
+	}{
+		{"name", MaskName, "John Doe", "J*** D***"},
+		{"accented name", MaskName, "Émile  Zola", "É*** Z***"},
+		{"email", MaskEmail, "john.doe@example.com", "j***@example.com"},
+		{"email without domain", MaskEmail, "john.doe", "***"},
+		{"phone", MaskPhone, "+33 6 12 34 56 78", "***78"},
+		{"short phone", MaskPhone, "112", "***"},
+		{"document", MaskDocument, "19FR12345", "***345"},
+		{"empty", MaskName, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if masked := tt.mask(tt.value); masked != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, masked)
+			}
+		})
+	}
+}
+
+func TestPolicy_Passenger(t *testing.T) {
+	original := passenger()
+
+	masked := Policy{}.Passenger(original)
+	if masked.Name != "J*** D***" || masked.Email != "j***@example.com" || masked.Phone != "***78" || masked.Document.Number != "***345" {
+		t.Errorf("Expected every field masked by default, got %+v %+v", masked, masked.Document)
+	}
+	if original.Document.Number != "19FR12345" {
+		t.Error("Expected the original passenger to be left unchanged")
+	}
+
+	revealed := Reveal(Name).Passenger(original)
+	if revealed.Name != "John Doe" || revealed.Email != "j***@example.com" {
+		t.Errorf("Expected only the name revealed, got %+v", revealed)
+	}
+}
+
+func TestPolicy_ValueMasksNestedPassengers(t *testing.T) {
+	manifest := domain.Manifest{
+		Service: domain.Service{ID: "5160"},
+		Entries: []domain.ManifestEntry{{BookingID: "B0001", Passenger: passenger()}},
+	}
+
+	masked := Policy{}.Value(manifest).(domain.Manifest)
+	if masked.Entries[0].Passenger.Name != "J*** D***" {
+		t.Errorf("Expected the manifest entry's passenger masked, got %q", masked.Entries[0].Passenger.Name)
+	}
+	if manifest.Entries[0].Passenger.Name != "John Doe" {
+		t.Error("Expected the original manifest to be left unchanged")
+	}
+
+	b := booking()
+	maskedBooking := Policy{}.Value(&b).(*domain.Booking)
+	if maskedBooking.Tickets[0].Passenger.Name != "J*** D***" || maskedBooking.Tickets[0].Seat.Number != "A1" {
+		t.Errorf("Expected the ticket's passenger masked and the rest kept, got %+v", maskedBooking.Tickets[0])
+	}
+
+	if v := (Policy{}).Value("John Doe"); v != "John Doe" {
+		t.Errorf("Expected values that are not passengers to pass through, got %v", v)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	bus := events.NewBus()
+	var received events.Event
+	Subscribe(bus, Policy{}, func(e events.Event) { received = e })
+
+	var internal events.Event
+	bus.Subscribe(func(e events.Event) { internal = e })
+
+	bus.Publish(events.Event{Type: "booking.created", Data: booking()})
+
+	if received.Data.(domain.Booking).Passengers[0].Name != "J*** D***" {
+		t.Errorf("Expected the forwarded event masked, got %+v", received.Data)
+	}
+	if internal.Data.(domain.Booking).Passengers[0].Name != "John Doe" {
+		t.Error("Expected other subscribers to see the event unmasked")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewJSONHandler(&buf, nil), Policy{}))
+
+	logger.With("booking", booking()).Info("booked", slog.Group("lead", "passenger", passenger()))
+
+	out := buf.String()
+	for _, leaked := range []string{"John", "Doe", "john.doe", "12345", "56 78"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("Expected %q to be masked in %s", leaked, out)
+		}
+	}
+	if !strings.Contains(out, "J*** D***") || !strings.Contains(out, "B0001") {
+		t.Errorf("Expected masked names and other fields in %s", out)
+	}
+}
+
+func TestWriteBookingsCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		expected []string
+	}{
+		{"masked by default", Policy{}, []string{"J*** D***", "j***@example.com", "***78", "***345"}},
+		{"opted in", Reveal(Name, Email), []string{"John Doe", "john.doe@example.com", "***78", "***345"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteBookingsCSV(&buf, []domain.Booking{booking()}, tt.policy); err != nil {
+				t.Fatalf("Failed to export: %v", err)
+			}
+			rows, err := csv.NewReader(&buf).ReadAll()
+			if err != nil || len(rows) != 2 {
+				t.Fatalf("Expected a header and one row, got %v (%v)", rows, err)
+			}
+			row := rows[1]
+			if row[0] != "B0001" || row[2] != "2021-04-01" || row[8] != "child" || row[13] != "7412" {
+				t.Errorf("Unexpected row %v", row)
+			}
+			if got := row[9:13]; strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Expected passenger fields %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
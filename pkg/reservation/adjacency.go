@@ -0,0 +1,407 @@
+package reservation
+
+import (
+	"ticketing-app/pkg/domain"
+)
+
+var validPreferences = map[domain.SeatPreference]bool{
+	"":                  true,
+	domain.PreferWindow: true,
+	domain.PreferAisle:  true,
+	domain.PreferTable:  true,
+}
+
+// adjacencyWeight ranks how well two seats keep a party together: sitting
+// side by side or facing each other beats talking across a table, which
+// beats talking across the aisle.
+var adjacencyWeight = map[domain.AdjacencyKind]int{
+	domain.SideBySide:  3,
+	domain.FacingSeats: 3,
+	domain.AcrossTable: 2,
+	domain.AcrossAisle: 1,
+}
+
+// seatGroup is a set of connected seats, all in one carriage.
+type seatGroup struct {
+	carriage  domain.Carriage
+	seats     []domain.Seat
+	closeness int // sum of adjacencyWeight over every pair in the group
+}
+
+// partyCandidate is one way of seating a whole party, as one or more
+// groups.
+type partyCandidate []seatGroup
+
+func (c partyCandidate) closeness() int {
+	total := 0
+	for _, group := range c {
+		total += group.closeness
+	}
+	return total
+}
+
+// allocateGroup seats a party's open seat requests as close together as
+// the free seats allow, and returns the seat for each request index along
+// with the step that seated them. taken holds the seats booked or already
+// resolved for the request. It tries, in order:
+//
+//  1. the whole party in one connected group of seats (PlacedTogether)
+//  2. everyone next to at least one other member, in one carriage
+//     (PlacedInPairs)
+//  3. everyone in the same bay (PlacedInBay)
+//  4. everyone in the same carriage (PlacedInCarriage)
+//  5. the fewest adjacent carriages (PlacedInAdjacentCarriages)
+//  6. any carriages running the journey (PlacedApart)
+//
+// Among the candidates of a step it takes the one split into the fewest
+// groups, then the closest, then the one meeting the most preferences,
+// then the earliest in the train.
+//
+// It returns nothing, leaving the requests to be allocated one by one,
+// when there is no party, when the requests ask for different carriages or
+// comfort zones, or when there are not enough free seats.
+func (rs *System) allocateGroup(service domain.Service, req domain.ReservationRequest, taken map[string]bool) (map[int]domain.Seat, domain.PlacementStrategy) {
+	var open []int
+	for i, seatReq := range req.SeatRequests {
+		if seatReq.SeatNumber == "" {
+			open = append(open, i)
+		}
+	}
+	if len(open) < 2 {
+		return nil, ""
+	}
+	first := req.SeatRequests[open[0]]
+	for _, i := range open[1:] {
+		seatReq := req.SeatRequests[i]
+		if seatReq.CarriageID != first.CarriageID || seatReq.ComfortZone != first.ComfortZone {
+			return nil, ""
+		}
+	}
+	size := len(open)
+
+	carriages := rs.carriagesFor(service, req, first)
+	graphs := rs.seatGraphs[service.ID]
+	free := make(map[string][]domain.Seat, len(carriages))
+	for _, carriage := range carriages {
+		free[carriage.ID] = rs.freeSeats(carriage, first, taken)
+	}
+	choose := func(candidates []partyCandidate, accept func(partyCandidate) bool) map[int]domain.Seat {
+		var best map[int]domain.Seat
+		var bestCandidate partyCandidate
+		bestMatched := -1
+		for _, candidate := range candidates {
+			if !accept(candidate) {
+				continue
+			}
+			assigned, matched := assignPreferences(candidate, req.SeatRequests, open)
+			if best == nil || len(candidate) < len(bestCandidate) ||
+				(len(candidate) == len(bestCandidate) && candidate.closeness() > bestCandidate.closeness()) ||
+				(len(candidate) == len(bestCandidate) && candidate.closeness() == bestCandidate.closeness() && matched > bestMatched) {
+				best, bestCandidate, bestMatched = assigned, candidate, matched
+			}
+		}
+		return best
+	}
+	anyCandidate := func(partyCandidate) bool { return true }
+
+	var inCarriage []partyCandidate
+	for _, carriage := range carriages {
+		if len(free[carriage.ID]) >= size {
+			inCarriage = append(inCarriage, packGroups(carriage, graphs[carriage.ID], free[carriage.ID], size))
+		}
+	}
+	if best := choose(inCarriage, func(c partyCandidate) bool { return len(c) == 1 }); best != nil {
+		return best, domain.PlacedTogether
+	}
+	if best := choose(inCarriage, func(c partyCandidate) bool {
+		for _, group := range c {
+			if len(group.seats) < 2 {
+				return false
+			}
+		}
+		return true
+	}); best != nil {
+		return best, domain.PlacedInPairs
+	}
+
+	var inBay []partyCandidate
+	for _, carriage := range carriages {
+		for _, seats := range seatsByBay(free[carriage.ID]) {
+			if len(seats) >= size {
+				inBay = append(inBay, packGroups(carriage, graphs[carriage.ID], seats, size))
+			}
+		}
+	}
+	if best := choose(inBay, anyCandidate); best != nil {
+		return best, domain.PlacedInBay
+	}
+	if best := choose(inCarriage, anyCandidate); best != nil {
+		return best, domain.PlacedInCarriage
+	}
+
+	// Carriages are adjacent when they follow each other in the train and
+	// all run the journey
+	for length := 2; length <= len(service.Carriages); length++ {
+		var spread []partyCandidate
+		for start := 0; start+length <= len(service.Carriages); start++ {
+			if candidate, filled := fillCarriages(service.Carriages[start:start+length], free, graphs, size); filled {
+				spread = append(spread, candidate)
+			}
+		}
+		if best := choose(spread, anyCandidate); best != nil {
+			return best, domain.PlacedInAdjacentCarriages
+		}
+	}
+
+	if candidate, filled := fillCarriages(carriages, free, graphs, size); filled {
+		return choose([]partyCandidate{candidate}, anyCandidate), domain.PlacedApart
+	}
+	return nil, ""
+}
+
+// fillCarriages seats size passengers across the given carriages in train
+// order, filling each before moving to the next. It fails if a carriage
+// does not run the journey or the carriages lack free seats.
+func fillCarriages(carriages []domain.Carriage, free map[string][]domain.Seat, graphs map[string]domain.SeatGraph, size int) (partyCandidate, bool) {
+	var candidate partyCandidate
+	for _, carriage := range carriages {
+		seats, serves := free[carriage.ID]
+		if !serves {
+			return nil, false
+		}
+		if size == 0 || len(seats) == 0 {
+			continue
+		}
+		take := size
+		if len(seats) < take {
+			take = len(seats)
+		}
+		candidate = append(candidate, packGroups(carriage, graphs[carriage.ID], seats, take)...)
+		size -= take
+	}
+	return candidate, size == 0
+}
+
+// packGroups picks size of the free seats as a few large connected groups:
+// it grows the largest, closest group it can, then repeats with the seats
+// left until it has enough. free must hold at least size seats.
+func packGroups(carriage domain.Carriage, graph domain.SeatGraph, free []domain.Seat, size int) partyCandidate {
+	var groups partyCandidate
+	remaining := free
+	for size > 0 {
+		var best seatGroup
+		for _, start := range remaining {
+			group := growGroup(carriage, graph, remaining, start, size)
+			if len(group.seats) > len(best.seats) || (len(group.seats) == len(best.seats) && group.closeness > best.closeness) {
+				best = group
+			}
+		}
+		groups = append(groups, best)
+		size -= len(best.seats)
+
+		used := make(map[string]bool, len(best.seats))
+		for _, seat := range best.seats {
+			used[seat.Number] = true
+		}
+		var left []domain.Seat
+		for _, seat := range remaining {
+			if !used[seat.Number] {
+				left = append(left, seat)
+			}
+		}
+		remaining = left
+	}
+	return groups
+}
+
+// growGroup builds a group of up to size seats from start, each time adding
+// the free seat most closely connected to the seats already in the group.
+// Ties go to the seat earliest in the carriage. It stops early when no free
+// seat is next to the group.
+func growGroup(carriage domain.Carriage, graph domain.SeatGraph, free []domain.Seat, start domain.Seat, size int) seatGroup {
+	group := seatGroup{carriage: carriage, seats: []domain.Seat{start}}
+	in := map[string]bool{start.Number: true}
+	for len(group.seats) < size {
+		var next domain.Seat
+		bestGain := 0
+		for _, candidate := range free {
+			if in[candidate.Number] {
+				continue
+			}
+			gain := 0
+			for _, member := range group.seats {
+				if kind, adjacent := graph.Adjacent(member.Number, candidate.Number); adjacent {
+					gain += adjacencyWeight[kind]
+				}
+			}
+			if gain > bestGain {
+				next, bestGain = candidate, gain
+			}
+		}
+		if bestGain == 0 {
+			break
+		}
+		group.seats = append(group.seats, next)
+		group.closeness += bestGain
+		in[next.Number] = true
+	}
+	return group
+}
+
+// seatsByBay splits seats by bay, in order of each bay's first seat. Seats
+// outside any bay are left out.
+func seatsByBay(seats []domain.Seat) [][]domain.Seat {
+	var bays [][]domain.Seat
+	index := make(map[string]int)
+	for _, seat := range seats {
+		if seat.Bay == "" {
+			continue
+		}
+		i, seen := index[seat.Bay]
+		if !seen {
+			i = len(bays)
+			index[seat.Bay] = i
+			bays = append(bays, nil)
+		}
+		bays[i] = append(bays[i], seat)
+	}
+	return bays
+}
+
+// assignPreferences hands the candidate's seats to the open requests:
+// requests with a preference choose first, in request order, and the rest
+// take the remaining seats group by group, in carriage order within a
+// group, so passengers listed together sit together. It returns how many
+// preferences were met.
+func assignPreferences(candidate partyCandidate, seatRequests []domain.SeatRequest, open []int) (map[int]domain.Seat, int) {
+	assigned := make(map[int]domain.Seat, len(open))
+	used := make(map[string]bool, len(open))
+	key := func(seat domain.Seat) string { return seat.CarriageID + "/" + seat.Number }
+	matched := 0
+	for _, i := range open {
+		preference := seatRequests[i].Preference
+		if preference == "" {
+			continue
+		}
+	groups:
+		for _, group := range candidate {
+			for _, seat := range group.seats {
+				if !used[key(seat)] && group.carriage.Satisfies(seat, preference) {
+					assigned[i] = seat
+					used[key(seat)] = true
+					matched++
+					break groups
+				}
+			}
+		}
+	}
+
+	var remaining []domain.Seat
+	for _, group := range candidate {
+		members := make(map[string]bool, len(group.seats))
+		for _, seat := range group.seats {
+			members[seat.Number] = true
+		}
+		for _, seat := range group.carriage.Seats {
+			if members[seat.Number] && !used[key(seat)] {
+				remaining = append(remaining, seat)
+			}
+		}
+	}
+	for _, i := range open {
+		if _, done := assigned[i]; !done {
+			assigned[i] = remaining[0]
+			remaining = remaining[1:]
+		}
+	}
+	return assigned, matched
+}
+
+// describePlacement reports how the seats of a booking keep its passengers
+// together. seats are in passenger order; graphs are the service's seat
+// graphs by carriage; strategy is the step allocateGroup seated the party
+// with, or empty when passengers chose some of their seats, in which case it
+// is worked out from the seats. Bookings for a single passenger have no
+// placement.
+func describePlacement(service domain.Service, graphs map[string]domain.SeatGraph, seats []domain.Seat, strategy domain.PlacementStrategy) *domain.Placement {
+	if len(seats) < 2 {
+		return nil
+	}
+
+	placement := &domain.Placement{}
+	clustered := make([]bool, len(seats))
+	for i := range seats {
+		if clustered[i] {
+			continue
+		}
+		clustered[i] = true
+		cluster := domain.SeatCluster{CarriageID: seats[i].CarriageID}
+		queue := []int{i}
+		for len(queue) > 0 {
+			p := queue[0]
+			queue = queue[1:]
+			cluster.Seats = append(cluster.Seats, seats[p].Number)
+			cluster.Passengers = append(cluster.Passengers, p)
+			for q := range seats {
+				if clustered[q] || seats[q].CarriageID != seats[p].CarriageID {
+					continue
+				}
+				if _, adjacent := graphs[seats[p].CarriageID].Adjacent(seats[p].Number, seats[q].Number); adjacent {
+					clustered[q] = true
+					queue = append(queue, q)
+				}
+			}
+		}
+		placement.Clusters = append(placement.Clusters, cluster)
+	}
+	placement.Strategy = strategy
+	if strategy == "" {
+		placement.Strategy = placementStrategy(service, seats, placement.Clusters)
+	}
+	return placement
+}
+
+// placementStrategy names the closest step that would have seated a party
+// on seats its passengers chose themselves.
+func placementStrategy(service domain.Service, seats []domain.Seat, clusters []domain.SeatCluster) domain.PlacementStrategy {
+	if len(clusters) == 1 {
+		return domain.PlacedTogether
+	}
+
+	carriages := make(map[string]bool)
+	bays := make(map[string]bool)
+	paired := true
+	for _, seat := range seats {
+		carriages[seat.CarriageID] = true
+		bays[seat.Bay] = true
+	}
+	for _, cluster := range clusters {
+		if len(cluster.Seats) < 2 {
+			paired = false
+		}
+	}
+	if len(carriages) == 1 {
+		switch {
+		case paired:
+			return domain.PlacedInPairs
+		case len(bays) == 1 && !bays[""]:
+			return domain.PlacedInBay
+		}
+		return domain.PlacedInCarriage
+	}
+
+	// Adjacent when the carriages used follow each other in the train
+	first, last := -1, -1
+	for i, carriage := range service.Carriages {
+		if carriages[carriage.ID] {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if last-first+1 == len(carriages) {
+		return domain.PlacedInAdjacentCarriages
+	}
+	return domain.PlacedApart
+}
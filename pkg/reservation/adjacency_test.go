@@ -0,0 +1,205 @@
+package reservation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+// setupLayoutSystem runs 2+2 carriages, by default just C. In each, rows 1
+// and 2 face each other across tables and form one bay, rows 3 and 4 face
+// forward and form another. Seats are numbered row then column, so 12 and
+// 13 sit either side of the aisle.
+func setupLayoutSystem(carriageIDs ...string) *System {
+	if len(carriageIDs) == 0 {
+		carriageIDs = []string{"C"}
+	}
+	rs := NewSystem()
+	route := domain.NewRoute("R001", "Paris-Amsterdam",
+		[]domain.Station{domain.NewStation("Paris"), domain.NewStation("Amsterdam")},
+		[]int{0, 500})
+
+	var carriages []domain.Carriage
+	for _, id := range carriageIDs {
+		carriage := domain.Carriage{ID: id, Aisle: 2}
+		for row := 1; row <= 4; row++ {
+			for column := 1; column <= 4; column++ {
+				seat := domain.Seat{
+					Number:      fmt.Sprintf("%d%d", row, column),
+					ComfortZone: domain.SecondClass,
+					CarriageID:  id,
+					Row:         row,
+					Column:      column,
+					Facing:      domain.FacingForward,
+					Bay:         fmt.Sprint((row + 1) / 2),
+				}
+				if row == 1 {
+					seat.Facing = domain.FacingBackward
+				}
+				if row <= 2 {
+					seat.Table = fmt.Sprintf("T%d", (column+1)/2)
+				}
+				carriage.Seats = append(carriage.Seats, seat)
+			}
+		}
+		carriages = append(carriages, carriage)
+	}
+
+	rs.AddRoute(route)
+	rs.AddService(domain.NewService("5160", route,
+		time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC), carriages))
+	return rs
+}
+
+func layoutRequest(seatRequests ...domain.SeatRequest) domain.ReservationRequest {
+	req := domain.ReservationRequest{
+		ServiceID:    "5160",
+		Origin:       "Paris",
+		Destination:  "Amsterdam",
+		SeatRequests: seatRequests,
+		Date:         time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for i := range seatRequests {
+		req.Passengers = append(req.Passengers, domain.Passenger{Name: fmt.Sprintf("Passenger %d", i+1)})
+	}
+	return req
+}
+
+func TestSystem_MakeReservation_SeatsGroupsTogether(t *testing.T) {
+	open := domain.SeatRequest{ComfortZone: domain.SecondClass}
+	window := domain.SeatRequest{ComfortZone: domain.SecondClass, Preference: domain.PreferWindow}
+
+	tests := []struct {
+		name     string
+		booked   []string
+		requests []domain.SeatRequest
+		expected []string
+	}{
+		{"table bay for four", nil, []domain.SeatRequest{open, open, open, open}, []string{"11", "12", "21", "22"}},
+		// 11, 13 and 14 are the first free seats by number, but 13 is
+		// across the aisle from 11
+		{"facing seats over the aisle", []string{"12"}, []domain.SeatRequest{open, open, open}, []string{"11", "21", "22"}},
+		{"preference within the group", nil, []domain.SeatRequest{open, window}, []string{"12", "11"}},
+		{"single window seat", []string{"11"}, []domain.SeatRequest{window}, []string{"14"}},
+		{"single without preference", []string{"11"}, []domain.SeatRequest{open}, []string{"12"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := setupLayoutSystem()
+			for _, number := range tt.booked {
+				if _, err := rs.MakeReservation(layoutRequest(domain.SeatRequest{CarriageID: "C", SeatNumber: number})); err != nil {
+					t.Fatalf("Failed to book seat %s: %v", number, err)
+				}
+			}
+
+			booking, err := rs.MakeReservation(layoutRequest(tt.requests...))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var seats []string
+			for _, ticket := range booking.Tickets {
+				seats = append(seats, ticket.Seat.Number)
+			}
+			if fmt.Sprint(seats) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected seats %v, got %v", tt.expected, seats)
+			}
+		})
+	}
+}
+
+func TestSystem_MakeReservation_InvalidSeatPreference(t *testing.T) {
+	rs := setupLayoutSystem()
+
+	_, err := rs.MakeReservation(layoutRequest(domain.SeatRequest{Preference: "quiet"}))
+	var reservationErr ReservationError
+	if !errors.As(err, &reservationErr) || reservationErr.Code != "INVALID_SEAT_PREFERENCE" {
+		t.Errorf("Expected INVALID_SEAT_PREFERENCE, got %v", err)
+	}
+}
+
+func TestSystem_MakeReservation_SplitsGroupInFallbackOrder(t *testing.T) {
+	open := domain.SeatRequest{ComfortZone: domain.SecondClass}
+	party := []domain.SeatRequest{open, open, open, open}
+
+	tests := []struct {
+		name      string
+		carriages []string
+		free      map[string][]string // carriages listed keep only these seats free
+		strategy  domain.PlacementStrategy
+		seats     string
+		clusters  int
+	}{
+		{"together", []string{"C"}, nil, domain.PlacedTogether, "C/11 C/12 C/21 C/22", 1},
+		{"pairs", []string{"C"}, map[string][]string{"C": {"11", "12", "33", "34"}},
+			domain.PlacedInPairs, "C/11 C/12 C/33 C/34", 2},
+		{"same bay", []string{"C"}, map[string][]string{"C": {"11", "13", "14", "23", "41"}},
+			domain.PlacedInBay, "C/13 C/14 C/23 C/11", 2},
+		{"same carriage", []string{"C"}, map[string][]string{"C": {"11", "14", "31", "44"}},
+			domain.PlacedInCarriage, "C/11 C/14 C/31 C/44", 4},
+		{"adjacent carriages", []string{"C", "D", "E"}, map[string][]string{"C": {"11"}, "D": {"11", "12"}, "E": {"21", "22"}},
+			domain.PlacedInAdjacentCarriages, "D/11 D/12 E/21 E/22", 2},
+		// Reported as the step that seated them, walking through the full carriage
+		{"through a full carriage", []string{"C", "D", "E"}, map[string][]string{"C": {"11", "12"}, "D": {}, "E": {"21", "22"}},
+			domain.PlacedInAdjacentCarriages, "C/11 C/12 E/21 E/22", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := setupLayoutSystem(tt.carriages...)
+			service, _ := rs.GetService("5160")
+			for _, carriage := range service.Carriages {
+				free, listed := tt.free[carriage.ID]
+				if !listed {
+					continue
+				}
+				keep := strings.Join(free, " ") + " "
+				for _, seat := range carriage.Seats {
+					if strings.Contains(keep, seat.Number+" ") {
+						continue
+					}
+					if _, err := rs.MakeReservation(layoutRequest(domain.SeatRequest{CarriageID: carriage.ID, SeatNumber: seat.Number})); err != nil {
+						t.Fatalf("Failed to book seat %s/%s: %v", carriage.ID, seat.Number, err)
+					}
+				}
+			}
+
+			booking, err := rs.MakeReservation(layoutRequest(party...))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var seats []string
+			for _, ticket := range booking.Tickets {
+				seats = append(seats, ticket.Seat.CarriageID+"/"+ticket.Seat.Number)
+			}
+			if strings.Join(seats, " ") != tt.seats {
+				t.Errorf("Expected seats %s, got %v", tt.seats, seats)
+			}
+			if booking.Placement == nil || booking.Placement.Strategy != tt.strategy || len(booking.Placement.Clusters) != tt.clusters {
+				t.Fatalf("Expected %s placement in %d clusters, got %+v", tt.strategy, tt.clusters, booking.Placement)
+			}
+			placed := 0
+			for _, cluster := range booking.Placement.Clusters {
+				placed += len(cluster.Passengers)
+			}
+			if placed != len(party) {
+				t.Errorf("Expected every passenger in a cluster, got %+v", booking.Placement.Clusters)
+			}
+		})
+	}
+}
+
+func TestSystem_MakeReservation_SinglePassengerHasNoPlacement(t *testing.T) {
+	rs := setupLayoutSystem()
+
+	booking, err := rs.MakeReservation(layoutRequest(domain.SeatRequest{}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if booking.Placement != nil {
+		t.Errorf("Expected no placement, got %+v", booking.Placement)
+	}
+}
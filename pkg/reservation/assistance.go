@@ -0,0 +1,134 @@
+package reservation
+
+import (
+	"fmt"
+	"sort"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+func (rs *System) station(service domain.Service, name string) domain.Station {
+	if station, exists := rs.stations[name]; exists {
+		return station
+	}
+	station, _ := service.GetStation(name)
+	return station
+}
+
+// validateAssistance checks every passenger who needs assistance against
+// the boarding and alighting stations: the station must be staffed when
+// the train calls, step-free for wheelchair users, and the request must be
+// made at least the station's lead time in advance.
+func (rs *System) validateAssistance(req domain.ReservationRequest, service domain.Service) ValidationErrors {
+	var errs ValidationErrors
+
+	for i, passenger := range req.Passengers {
+		if passenger.Assistance == domain.NoAssistance {
+			continue
+		}
+		field := fmt.Sprintf("Passengers[%d].Assistance", i)
+
+		for _, stationName := range []string{req.Origin, req.Destination} {
+			station := rs.station(service, stationName)
+			at, _ := service.TimeAt(stationName)
+			local, err := station.LocalTime(at)
+			if err != nil {
+				errs = append(errs, ReservationError{
+					Message: fmt.Sprintf("Station %s cannot confirm its staffed hours: %v", stationName, err),
+					Code:    "STATION_NOT_STAFFED",
+					Field:   field,
+				})
+				continue
+			}
+
+			if !station.Accessibility.IsStaffedAt(local) {
+				errs = append(errs, ReservationError{
+					Message: fmt.Sprintf("Station %s is not staffed at %s to assist passenger %d", stationName, local.Format("15:04"), i+1),
+					Code:    "STATION_NOT_STAFFED",
+					Field:   field,
+				})
+				continue
+			}
+
+			if passenger.Assistance == domain.WheelchairAssistance && !station.Accessibility.StepFree {
+				errs = append(errs, ReservationError{
+					Message: fmt.Sprintf("Station %s has no step-free access for passenger %d", stationName, i+1),
+					Code:    "STATION_NOT_STEP_FREE",
+					Field:   field,
+				})
+			}
+
+			if rs.now().Add(station.Accessibility.AssistanceLeadTime).After(at) {
+				errs = append(errs, ReservationError{
+					Message: fmt.Sprintf("Assistance at %s must be booked at least %s in advance", stationName, station.Accessibility.AssistanceLeadTime),
+					Code:    "ASSISTANCE_LEAD_TIME",
+					Field:   field,
+				})
+			}
+		}
+	}
+
+	return errs
+}
+
+// assistanceTasks lists the boarding and alighting assistance staff at a
+// station must provide on a given day, in time order. The day is the
+// station's own, so a task just after local midnight is on the next day's
+// list even if it is still the previous day in UTC. Callers outside the
+// package go through Queries.GetAssistanceTasks.
+func (rs *System) assistanceTasks(stationName string, date time.Time) []domain.AssistanceTask {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	var tasks []domain.AssistanceTask
+
+	for _, booking := range rs.bookings {
+		for _, ticket := range booking.Tickets {
+			if ticket.Passenger.Assistance == domain.NoAssistance {
+				continue
+			}
+
+			var kind domain.AssistanceTaskKind
+			switch stationName {
+			case ticket.Origin.Name:
+				kind = domain.AssistBoarding
+			case ticket.Destination.Name:
+				kind = domain.AssistAlighting
+			default:
+				continue
+			}
+
+			at, found := ticket.Service.TimeAt(stationName)
+			if !found {
+				continue
+			}
+			local, err := rs.station(ticket.Service, stationName).LocalTime(at)
+			if err != nil {
+				local = at
+			}
+			if !rs.isSameDate(local, date) {
+				continue
+			}
+
+			tasks = append(tasks, domain.AssistanceTask{
+				Time:       at,
+				Kind:       kind,
+				Type:       ticket.Passenger.Assistance,
+				ServiceID:  ticket.Service.ID,
+				BookingID:  booking.ID,
+				Passenger:  ticket.Passenger,
+				CarriageID: ticket.Seat.CarriageID,
+				SeatNumber: ticket.Seat.Number,
+			})
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].Time.Equal(tasks[j].Time) {
+			return tasks[i].Time.Before(tasks[j].Time)
+		}
+		return tasks[i].BookingID < tasks[j].BookingID
+	})
+
+	return tasks
+}
@@ -0,0 +1,293 @@
+package reservation
+
+import (
+	"errors"
+	"testing"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+func setupAssistanceSystem() *System {
+	rs := NewSystem()
+	rs.SetClock(func() time.Time { return time.Date(2021, 3, 30, 12, 0, 0, 0, time.UTC) })
+	
+	paris := domain.Station{Name: "Paris", Accessibility: domain.Accessibility{
+		StepFree:           true,
+		StaffedFrom:        6 * time.Hour,
+		StaffedUntil:       23 * time.Hour,
+		AssistanceLeadTime: 24 * time.Hour,
+	}}
+	calais := domain.Station{Name: "Calais", Accessibility: domain.Accessibility{
+		StepFree:           false,
+		StaffedFrom:        7 * time.Hour,
+		StaffedUntil:       19 * time.Hour,
+		AssistanceLeadTime: 12 * time.Hour,
+	}}
+	amsterdam := domain.Station{Name: "Amsterdam", Accessibility: domain.Accessibility{
+		StepFree:           true,
+		StaffedFrom:        6 * time.Hour,
+		StaffedUntil:       22 * time.Hour,
+		AssistanceLeadTime: 12 * time.Hour,
+	}}
+	
+	route := domain.NewTimedRoute("R002", "Paris-Amsterdam",
+		[]domain.Station{paris, calais, amsterdam},
+		[]int{0, 300, 520},
+		[]time.Duration{0, 90 * time.Minute, 3*time.Hour + 20*time.Minute})
+	
+	carriages := []domain.Carriage{
+		{
+			ID: "A",
+			Seats: []domain.Seat{
+				{Number: "A1", ComfortZone: domain.FirstClass, CarriageID: "A"},
+				{Number: "A2", ComfortZone: domain.FirstClass, CarriageID: "A"},
+				{Number: "A3", ComfortZone: domain.FirstClass, CarriageID: "A"},
+			},
+		},
+	}
+	
+	rs.AddRoute(route)
+	rs.AddService(domain.NewService("5160", route,
+		time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC), carriages))
+	
+	return rs
+}
+
+func TestSystem_MakeReservation_Assistance(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		assistance  domain.AssistanceType
+		errCode     string
+	}{
+		{"Wheelchair to step-free staffed station", "Amsterdam", domain.WheelchairAssistance, ""},
+		{"Wheelchair to station without step-free access", "Calais", domain.WheelchairAssistance, "STATION_NOT_STEP_FREE"},
+		{"Visual assistance at station without step-free access", "Calais", domain.VisualAssistance, ""},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := setupAssistanceSystem()
+			_, err := rs.MakeReservation(domain.ReservationRequest{
+				ServiceID: "5160",
+				Origin:    "Paris",
+				Destination: tt.destination,
+				Passengers: []domain.Passenger{{Name: "Test Passenger", Assistance: tt.assistance}},
+				SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A1"}},
+				Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+			})
+			
+			if tt.errCode == "" {
+				if err != nil {
+					t.Errorf("Expected no error but got: %v", err)
+				}
+				return
+			}
+			var validationErrs ValidationErrors
+			if !errors.As(err, &validationErrs) || !validationErrs.HasCode(tt.errCode) {
+				t.Errorf("Expected error code %s, got %v", tt.errCode, err)
+			}
+		})
+	}
+}
+
+func TestSystem_MakeReservation_AssistanceStaffingAndLeadTime(t *testing.T) {
+	rs := setupAssistanceSystem()
+	rs.SetClock(func() time.Time { return time.Date(2021, 4, 1, 7, 0, 0, 0, time.UTC) })
+	rs.AddStation(domain.Station{Name: "Amsterdam", Accessibility: domain.Accessibility{
+		StepFree:     true,
+		StaffedFrom:  6 * time.Hour,
+		StaffedUntil: 10 * time.Hour,
+	}})
+	
+	// Arrives in Amsterdam at 11:20, after staffed hours, and only an hour
+	// ahead of the 08:00 departure from Paris.
+	_, err := rs.MakeReservation(domain.ReservationRequest{
+		ServiceID: "5160",
+		Origin:    "Paris",
+		Destination: "Amsterdam",
+		Passengers: []domain.Passenger{{Name: "Test Passenger", Assistance: domain.MobilityAssistance}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A1"}},
+		Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	if !validationErrs.HasCode("ASSISTANCE_LEAD_TIME") {
+		t.Errorf("Expected ASSISTANCE_LEAD_TIME, got %v", validationErrs)
+	}
+	if !validationErrs.HasCode("STATION_NOT_STAFFED") {
+		t.Errorf("Expected STATION_NOT_STAFFED, got %v", validationErrs)
+	}
+}
+
+func TestSystem_AddStationOverridesRouteMetadata(t *testing.T) {
+	rs := setupAssistanceSystem()
+	rs.AddStation(domain.Station{Name: "Calais", Accessibility: domain.Accessibility{
+		StepFree:     true,
+		StaffedFrom:  0,
+		StaffedUntil: 24 * time.Hour,
+	}})
+	
+	_, err := rs.MakeReservation(domain.ReservationRequest{
+		ServiceID: "5160",
+		Origin:    "Paris",
+		Destination: "Calais",
+		Passengers: []domain.Passenger{{Name: "Test Passenger", Assistance: domain.WheelchairAssistance}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A1"}},
+		Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Errorf("Expected registered station metadata to allow booking, got %v", err)
+	}
+}
+
+func TestSystem_AssistanceTasks(t *testing.T) {
+	rs := setupAssistanceSystem()
+	
+	requests := []struct {
+		seat        string
+		destination string
+		assistance  domain.AssistanceType
+	}{
+		{"A1", "Amsterdam", domain.WheelchairAssistance},
+		{"A2", "Calais", domain.VisualAssistance},
+		{"A3", "Amsterdam", domain.NoAssistance},
+	}
+	for _, r := range requests {
+		_, err := rs.MakeReservation(domain.ReservationRequest{
+			ServiceID: "5160",
+			Origin:    "Paris",
+			Destination: r.destination,
+			Passengers: []domain.Passenger{{Name: "Passenger " + r.seat, Assistance: r.assistance}},
+			SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: r.seat}},
+			Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test booking: %v", err)
+		}
+	}
+	
+	tasks := rs.assistanceTasks("Paris", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	if len(tasks) != 2 {
+		t.Fatalf("Expected 2 boarding tasks at Paris, got %d", len(tasks))
+	}
+	for _, task := range tasks {
+		if task.Kind != domain.AssistBoarding {
+			t.Errorf("Expected boarding task, got %s", task.Kind)
+		}
+	}
+	
+	tasks = rs.assistanceTasks("Calais", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	if len(tasks) != 1 {
+		t.Fatalf("Expected 1 task at Calais, got %d", len(tasks))
+	}
+	if tasks[0].Kind != domain.AssistAlighting || tasks[0].SeatNumber != "A2" {
+		t.Errorf("Unexpected task %+v", tasks[0])
+	}
+	expected := time.Date(2021, 4, 1, 9, 30, 0, 0, time.UTC)
+	if !tasks[0].Time.Equal(expected) {
+		t.Errorf("Expected task at %v, got %v", expected, tasks[0].Time)
+	}
+	
+	tasks = rs.assistanceTasks("Paris", time.Date(2021, 4, 2, 0, 0, 0, 0, time.UTC))
+	if len(tasks) != 0 {
+		t.Errorf("Expected no tasks on another day, got %d", len(tasks))
+	}
+}
+
+func TestSystem_AssistanceTasksFollowTheStationsDay(t *testing.T) {
+	rs := setupAssistanceSystem()
+	// The train reaches Amsterdam at 11:20 UTC on 1 April, 00:20 on 2 April
+	// in Auckland
+	rs.AddStation(domain.Station{Name: "Amsterdam", TimeZone: "Pacific/Auckland", Accessibility: domain.Accessibility{
+		StepFree:     true,
+		StaffedFrom:  0,
+		StaffedUntil: time.Hour,
+	}})
+	_, err := rs.MakeReservation(domain.ReservationRequest{
+		ServiceID: "5160",
+		Origin:    "Paris",
+		Destination: "Amsterdam",
+		Passengers: []domain.Passenger{{Name: "Test Passenger", Assistance: domain.WheelchairAssistance}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A1"}},
+		Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+	
+	if tasks := rs.assistanceTasks("Amsterdam", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)); len(tasks) != 0 {
+		t.Errorf("Expected no tasks on the UTC day, got %d", len(tasks))
+	}
+	if tasks := rs.assistanceTasks("Amsterdam", time.Date(2021, 4, 2, 0, 0, 0, 0, time.UTC)); len(tasks) != 1 {
+		t.Errorf("Expected the task on the station's day, got %d", len(tasks))
+	}
+}
+
+func TestSystem_MakeReservation_AssistanceAtPortionStation(t *testing.T) {
+	rs := setupPortionSystem()
+	rs.SetClock(func() time.Time { return time.Date(2021, 3, 30, 12, 0, 0, 0, time.UTC) })
+	rs.AddStation(domain.Station{Name: "Paris", Accessibility: domain.Accessibility{
+		StepFree:     true,
+		StaffedFrom:  6 * time.Hour,
+		StaffedUntil: 23 * time.Hour,
+	}})
+	// Cologne is only on the portion's route, so its metadata comes from there
+	rs.services["9400"].Portions[0].Route.Stops[2].Station.Accessibility = domain.Accessibility{
+		StepFree:     true,
+		StaffedFrom:  6 * time.Hour,
+		StaffedUntil: 23 * time.Hour,
+	}
+	
+	req := portionRequest("Cologne", domain.SeatRequest{CarriageID: "K", SeatNumber: "K1"})
+	req.Passengers[0].Assistance = domain.WheelchairAssistance
+	if _, err := rs.MakeReservation(req); err != nil {
+		t.Errorf("Expected no error but got: %v", err)
+	}
+}
+
+func TestSystem_MakeReservation_AssistanceStaffedHoursAreStationLocal(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeZone string
+		errCode  string
+	}{
+		// The train reaches Amsterdam at 11:20 UTC, 13:20 in Amsterdam
+		{"Staffed in station-local time", "Europe/Amsterdam", ""},
+		{"Without a time zone hours are UTC", "", "STATION_NOT_STAFFED"},
+		{"Unknown time zone", "Europe/Atlantis", "STATION_NOT_STAFFED"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := setupAssistanceSystem()
+			rs.AddStation(domain.Station{Name: "Amsterdam", TimeZone: tt.timeZone, Accessibility: domain.Accessibility{
+				StepFree:     true,
+				StaffedFrom:  12 * time.Hour,
+				StaffedUntil: 14 * time.Hour,
+			}})
+			_, err := rs.MakeReservation(domain.ReservationRequest{
+				ServiceID: "5160",
+				Origin:    "Paris",
+				Destination: "Amsterdam",
+				Passengers: []domain.Passenger{{Name: "Test Passenger", Assistance: domain.WheelchairAssistance}},
+				SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A1"}},
+				Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+			})
+			
+			if tt.errCode == "" {
+				if err != nil {
+					t.Errorf("Expected no error but got: %v", err)
+				}
+				return
+			}
+			var validationErrs ValidationErrors
+			if !errors.As(err, &validationErrs) || !validationErrs.HasCode(tt.errCode) {
+				t.Errorf("Expected error code %s, got %v", tt.errCode, err)
+			}
+		})
+	}
+}
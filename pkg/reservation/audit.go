@@ -0,0 +1,213 @@
+package reservation
+
+import (
+	"sync"
+	"ticketing-app/pkg/audit"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+// Queries runs lookups on behalf of a caller, records each one in the
+// system's audit log and returns only the fields the caller's role may
+// see. A lookup that cannot be recorded returns an error instead of its
+// result. It is the only way to read passenger data out of a System short
+// of fetching a booking by its ID.
+type Queries struct {
+	rs     *System
+	caller audit.Caller
+}
+
+func (rs *System) QueryAs(caller audit.Caller) *Queries {
+	return &Queries{rs: rs, caller: caller}
+}
+
+func (rs *System) AuditLog() *audit.Log {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	return rs.auditLog
+}
+
+// SetAuditLog replaces the audit log. The default one writes every entry
+// through to the system's store; a replacement keeps entries wherever its
+// own writer does.
+func (rs *System) SetAuditLog(log *audit.Log) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.auditLog = log
+}
+
+// newAuditLog returns an audit log that writes through to the system's
+// store.
+func newAuditLog(rs *System) *audit.Log {
+	log := audit.NewLog()
+	log.SetWriter(&auditWriter{rs: rs})
+	return log
+}
+
+// auditWriter appends audit entries to the system's store, so the trail is
+// as durable as the bookings it covers. While the system is degraded,
+// entries the store cannot take are held back and appended with the next
+// entry after it recovers, so conductors keep their manifests through an
+// outage. Outside degraded mode an entry that cannot be appended fails the
+// lookup.
+type auditWriter struct {
+	rs      *System
+	mu      sync.Mutex
+	backlog [][]byte
+}
+
+func (w *auditWriter) Write(entry []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.backlog = append(w.backlog, append([]byte(nil), entry...))
+	for len(w.backlog) > 0 {
+		if err := w.rs.store.AppendAudit(w.backlog[0]); err != nil {
+			if w.rs.Status().Degraded {
+				return len(entry), nil
+			}
+			w.backlog = w.backlog[:len(w.backlog)-1]
+			return 0, err
+		}
+		w.backlog = w.backlog[1:]
+	}
+	return len(entry), nil
+}
+
+func (q *Queries) GetManifest(serviceID string, date time.Time) (domain.Manifest, bool, error) {
+	filter, err := filterFor(q.caller.Role)
+	if err != nil {
+		return domain.Manifest{}, false, err
+	}
+	manifest, found := q.rs.departureManifest(serviceID, date)
+	if err := q.record("manifest", serviceID, date, "", len(manifest.Entries)); err != nil {
+		return domain.Manifest{}, false, err
+	}
+	manifest.Entries = filter.entries(manifest.Entries)
+	return manifest, found, nil
+}
+
+// GetCombinedManifest lists every passenger of the coupled services who is
+// on board somewhere along the shared section; see combinedManifest.
+func (q *Queries) GetCombinedManifest(serviceID string, date time.Time) (domain.CombinedManifest, bool, error) {
+	filter, err := filterFor(q.caller.Role)
+	if err != nil {
+		return domain.CombinedManifest{}, false, err
+	}
+	manifest, found := q.rs.combinedManifest(serviceID, date)
+	if err := q.record("combined-manifest", serviceID, date, "", len(manifest.Entries)); err != nil {
+		return domain.CombinedManifest{}, false, err
+	}
+	manifest.Entries = filter.entries(manifest.Entries)
+	return manifest, found, nil
+}
+
+func (q *Queries) GetPassengerOnSeat(serviceID, carriageID, seatNumber string, date time.Time) (*domain.Passenger, bool, error) {
+	filter, err := filterFor(q.caller.Role)
+	if err != nil {
+		return nil, false, err
+	}
+	passenger, found := q.rs.passengerOnSeat(serviceID, carriageID, seatNumber, date)
+	results := 0
+	if found {
+		results = 1
+	}
+	if err := q.record("seat", serviceID, date, carriageID+"/"+seatNumber, results); err != nil {
+		return nil, false, err
+	}
+	if found {
+		filtered := filter.passenger(*passenger)
+		passenger = &filtered
+	}
+	return passenger, found, nil
+}
+
+func (q *Queries) GetPassengersBoardingAt(serviceID, stationName string, date time.Time) ([]domain.Passenger, error) {
+	return q.passengers("boarding", serviceID, date, stationName, func() []domain.Passenger {
+		return q.rs.passengersBoardingAt(serviceID, stationName, date)
+	})
+}
+
+func (q *Queries) GetPassengersAlightingAt(serviceID, stationName string, date time.Time) ([]domain.Passenger, error) {
+	return q.passengers("alighting", serviceID, date, stationName, func() []domain.Passenger {
+		return q.rs.passengersAlightingAt(serviceID, stationName, date)
+	})
+}
+
+func (q *Queries) GetPassengersBetweenStations(serviceID, station1, station2 string, date time.Time) ([]domain.Passenger, error) {
+	return q.passengers("between", serviceID, date, station1+"-"+station2, func() []domain.Passenger {
+		return q.rs.passengersBetweenStations(serviceID, station1, station2, date)
+	})
+}
+
+// GetAllBookings returns every booking, with the passengers on it and on
+// its tickets filtered for the caller's role.
+func (q *Queries) GetAllBookings() ([]domain.Booking, error) {
+	filter, err := filterFor(q.caller.Role)
+	if err != nil {
+		return nil, err
+	}
+	bookings := q.rs.allBookings()
+	if err := q.record("bookings", "", time.Time{}, "", len(bookings)); err != nil {
+		return nil, err
+	}
+	for i := range bookings {
+		bookings[i] = filter.booking(bookings[i])
+	}
+	return bookings, nil
+}
+
+// GetAssistanceTasks lists the assistance staff at a station must give on
+// the station's day; see assistanceTasks. Each task is filtered as the
+// manifest entry for the same passenger and seat would be, so station staff
+// see the kind of assistance and the carriage to meet it at.
+func (q *Queries) GetAssistanceTasks(stationName string, date time.Time) ([]domain.AssistanceTask, error) {
+	filter, err := filterFor(q.caller.Role)
+	if err != nil {
+		return nil, err
+	}
+	tasks := q.rs.assistanceTasks(stationName, date)
+	if err := q.record("assistance", "", date, stationName, len(tasks)); err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		tasks[i] = filter.task(tasks[i])
+	}
+	return tasks, nil
+}
+
+func (q *Queries) passengers(query, serviceID string, date time.Time, detail string, lookup func() []domain.Passenger) ([]domain.Passenger, error) {
+	filter, err := filterFor(q.caller.Role)
+	if err != nil {
+		return nil, err
+	}
+	passengers := lookup()
+	if err := q.record(query, serviceID, date, detail, len(passengers)); err != nil {
+		return nil, err
+	}
+	return filter.passengers(passengers), nil
+}
+
+func (q *Queries) record(query, serviceID string, date time.Time, detail string, results int) error {
+	return q.rs.AuditLog().Record(audit.Entry{
+		Time:      q.rs.clock(),
+		UserID:    q.caller.UserID,
+		Role:      q.caller.Role,
+		Query:     query,
+		ServiceID: serviceID,
+		Date:      departureDate(date),
+		Detail:    detail,
+		Results:   results,
+	})
+}
+
+// departureDate formats the date of the departure a lookup was about; a
+// lookup across all departures has none.
+func departureDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
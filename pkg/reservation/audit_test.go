@@ -0,0 +1,112 @@
+package reservation
+
+import (
+	"errors"
+	"testing"
+	"ticketing-app/pkg/audit"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func setupAuditedSystem(t *testing.T) (*System, time.Time) {
+	rs := setupTestSystem()
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	_, err := rs.MakeReservation(domain.ReservationRequest{
+		ServiceID:    "5160",
+		Origin:       "Paris",
+		Destination:  "Amsterdam",
+		Passengers:   []domain.Passenger{{Name: "John Doe"}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A1"}},
+		Date:         date,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+	return rs, date
+}
+
+func TestQueries_RecordEveryLookup(t *testing.T) {
+	rs, date := setupAuditedSystem(t)
+	now := time.Date(2021, 4, 1, 7, 30, 0, 0, time.UTC)
+	rs.SetClock(func() time.Time { return now })
+
+	conductor := rs.QueryAs(audit.Caller{UserID: "c-042", Role: audit.Conductor})
+	agent := rs.QueryAs(audit.Caller{UserID: "a-007", Role: audit.Agent})
+
+	if manifest, found, err := conductor.GetManifest("5160", date); err != nil || !found || len(manifest.Entries) != 1 {
+		t.Errorf("Expected the manifest with 1 entry, got %d (found %v, err %v)", len(manifest.Entries), found, err)
+	}
+	if passengers, err := conductor.GetPassengersBoardingAt("5160", "Paris", date); err != nil || len(passengers) != 1 {
+		t.Errorf("Expected 1 passenger boarding, got %d (err %v)", len(passengers), err)
+	}
+	now = now.Add(time.Hour)
+	if passenger, found, err := agent.GetPassengerOnSeat("5160", "A", "A1", date); err != nil || !found || passenger.Name != "John Doe" {
+		t.Errorf("Expected John Doe on A1, got %v (found %v, err %v)", passenger, found, err)
+	}
+	agent.GetPassengersBetweenStations("5161", "Calais", "Amsterdam", date)
+
+	departure := rs.AuditLog().ByDeparture("5160", date)
+	if len(departure) != 3 {
+		t.Fatalf("Expected 3 lookups of 5160, got %+v", departure)
+	}
+	expected := []audit.Entry{
+		{UserID: "c-042", Role: audit.Conductor, Query: "manifest", Results: 1},
+		{UserID: "c-042", Role: audit.Conductor, Query: "boarding", Detail: "Paris", Results: 1},
+		{UserID: "a-007", Role: audit.Agent, Query: "seat", Detail: "A/A1", Results: 1},
+	}
+	for i, e := range expected {
+		got := departure[i]
+		if got.UserID != e.UserID || got.Role != e.Role || got.Query != e.Query || got.Detail != e.Detail || got.Results != e.Results {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, e, got)
+		}
+	}
+
+	byAgent := rs.AuditLog().ByUser("a-007", now.Add(-time.Minute), now.Add(time.Minute))
+	if len(byAgent) != 2 || byAgent[1].ServiceID != "5161" {
+		t.Errorf("Expected the agent's 2 lookups, got %+v", byAgent)
+	}
+	if early := rs.AuditLog().ByUser("a-007", now.Add(-2*time.Hour), now.Add(-time.Hour)); len(early) != 0 {
+		t.Errorf("Expected no agent lookups outside the window, got %+v", early)
+	}
+}
+
+func TestQueries_WithholdDataThatCannotBeAudited(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller audit.Caller
+		log    func() *audit.Log
+		code   string
+	}{
+		{"anonymous caller", audit.Caller{Role: audit.Conductor}, audit.NewLog, "UNKNOWN_CALLER"},
+		{"audit log down", audit.Caller{UserID: "c-042", Role: audit.Conductor}, func() *audit.Log {
+			log := audit.NewLog()
+			log.SetWriter(failingWriter{})
+			return log
+		}, "AUDIT_UNAVAILABLE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, date := setupAuditedSystem(t)
+			rs.SetAuditLog(tt.log())
+
+			passenger, found, err := rs.QueryAs(tt.caller).GetPassengerOnSeat("5160", "A", "A1", date)
+			var auditErr audit.AuditError
+			if !errors.As(err, &auditErr) || auditErr.Code != tt.code {
+				t.Errorf("Expected %s, got %v", tt.code, err)
+			}
+			if passenger != nil || found {
+				t.Errorf("Expected the passenger to be withheld, got %+v", passenger)
+			}
+			if entries := rs.AuditLog().ByDeparture("5160", date); len(entries) != 0 {
+				t.Errorf("Expected nothing recorded, got %+v", entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package reservation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+// AddCoupling registers services that run coupled over a shared section.
+// Every service must call at both ends of the section, and carriage IDs
+// must be unique across the coupled train so a seat on the shared section
+// is attributed to exactly one service. A service can be in only one
+// coupling, and a formation, if given, must list every carriage once.
+func (rs *System) AddCoupling(coupling domain.Coupling) error {
+	rs.lockForWrite()
+	defer rs.unlock()
+
+	if len(coupling.ServiceIDs) < 2 {
+		return ReservationError{
+			Message: "A coupling needs at least two services",
+			Code:    "INVALID_COUPLING",
+		}
+	}
+
+	listed := make(map[string]bool, len(coupling.ServiceIDs))
+	for _, serviceID := range coupling.ServiceIDs {
+		if listed[serviceID] {
+			return ReservationError{
+				Message: fmt.Sprintf("Service %s is listed twice", serviceID),
+				Code:    "INVALID_COUPLING",
+			}
+		}
+		listed[serviceID] = true
+	}
+
+	carriageOwner := make(map[string]string)
+	for _, serviceID := range coupling.ServiceIDs {
+		service, exists := rs.services[serviceID]
+		if !exists {
+			return ReservationError{
+				Message: fmt.Sprintf("Service %s not found", serviceID),
+				Code:    "SERVICE_NOT_FOUND",
+			}
+		}
+
+		if !service.IsValidOriginDestination(coupling.From, coupling.To) {
+			return ReservationError{
+				Message: fmt.Sprintf("Service %s does not run from %s to %s", serviceID, coupling.From, coupling.To),
+				Code:    "INVALID_COUPLING",
+			}
+		}
+
+		for _, carriage := range service.Carriages {
+			if owner, taken := carriageOwner[carriage.ID]; taken {
+				return ReservationError{
+					Message: fmt.Sprintf("Carriage %s is used by both service %s and service %s", carriage.ID, owner, serviceID),
+					Code:    "DUPLICATE_CARRIAGE",
+				}
+			}
+			carriageOwner[carriage.ID] = serviceID
+		}
+	}
+
+	if len(coupling.Formation) > 0 {
+		placed := make(map[string]bool, len(coupling.Formation))
+		for _, carriageID := range coupling.Formation {
+			if _, known := carriageOwner[carriageID]; !known || placed[carriageID] {
+				return ReservationError{
+					Message: fmt.Sprintf("Carriage %s is unknown or listed twice in the formation", carriageID),
+					Code:    "INVALID_COUPLING",
+				}
+			}
+			placed[carriageID] = true
+		}
+		if len(placed) != len(carriageOwner) {
+			return ReservationError{
+				Message: fmt.Sprintf("The formation lists %d of the coupled train's %d carriages", len(placed), len(carriageOwner)),
+				Code:    "INVALID_COUPLING",
+			}
+		}
+	}
+
+	for _, serviceID := range coupling.ServiceIDs {
+		if existing, coupled := rs.coupling(serviceID); coupled {
+			return ReservationError{
+				Message: fmt.Sprintf("Service %s is already coupled with %s", serviceID, strings.Join(existing.ServiceIDs, ", ")),
+				Code:    "ALREADY_COUPLED",
+			}
+		}
+	}
+
+	if err := rs.persist(func() error { return rs.store.SaveCoupling(coupling) }); err != nil {
+		return err
+	}
+	rs.couplings = append(rs.couplings, coupling)
+	return nil
+}
+
+func (rs *System) GetCoupling(serviceID string) (domain.Coupling, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	return rs.coupling(serviceID)
+}
+
+func (rs *System) coupling(serviceID string) (domain.Coupling, bool) {
+	for _, coupling := range rs.couplings {
+		for _, id := range coupling.ServiceIDs {
+			if id == serviceID {
+				return coupling, true
+			}
+		}
+	}
+	return domain.Coupling{}, false
+}
+
+// combinedManifest lists every passenger of the coupled services who is
+// on board somewhere along the shared section, as conductors there see one
+// train, in seat order from the front of the train. Each entry keeps the
+// service its seat was sold on.
+func (rs *System) combinedManifest(serviceID string, date time.Time) (domain.CombinedManifest, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	coupling, found := rs.coupling(serviceID)
+	if !found {
+		return domain.CombinedManifest{}, false
+	}
+
+	combined := domain.CombinedManifest{Coupling: coupling, Date: date}
+	for _, id := range coupling.ServiceIDs {
+		manifest, exists := rs.manifest(id, date)
+		if !exists {
+			continue
+		}
+		for _, entry := range manifest.Entries {
+			route := manifest.Service.RouteForCarriage(entry.Seat.CarriageID)
+			if overlapsSection(route, entry.Origin.Name, entry.Destination.Name, coupling.From, coupling.To) {
+				combined.Entries = append(combined.Entries, entry)
+			}
+		}
+	}
+
+	position := rs.trainPositions(coupling)
+	sort.SliceStable(combined.Entries, func(i, j int) bool {
+		a, b := combined.Entries[i].Seat, combined.Entries[j].Seat
+		return position[a.CarriageID+"/"+a.Number] < position[b.CarriageID+"/"+b.Number]
+	})
+
+	return combined, true
+}
+
+// trainPositions numbers the seats of a coupled train from the front,
+// carriage by carriage in formation order.
+func (rs *System) trainPositions(coupling domain.Coupling) map[string]int {
+	carriages := make(map[string]domain.Carriage)
+	var formation []string
+	for _, id := range coupling.ServiceIDs {
+		for _, carriage := range rs.services[id].Carriages {
+			carriages[carriage.ID] = carriage
+			formation = append(formation, carriage.ID)
+		}
+	}
+	if len(coupling.Formation) > 0 {
+		formation = coupling.Formation
+	}
+
+	position := make(map[string]int)
+	for _, carriageID := range formation {
+		for _, seat := range carriages[carriageID].Seats {
+			position[carriageID+"/"+seat.Number] = len(position)
+		}
+	}
+	return position
+}
+
+func overlapsSection(route domain.Route, origin, destination, from, to string) bool {
+	originIndex, foundOrigin := route.GetStopIndex(origin)
+	destIndex, foundDest := route.GetStopIndex(destination)
+	fromIndex, foundFrom := route.GetStopIndex(from)
+	toIndex, foundTo := route.GetStopIndex(to)
+	if !foundOrigin || !foundDest || !foundFrom || !foundTo {
+		return false
+	}
+	return originIndex < toIndex && destIndex > fromIndex
+}
@@ -0,0 +1,212 @@
+package reservation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+// setupCoupledSystem builds services 9401 to Amsterdam and 9402 to Cologne
+// running coupled from Paris to Brussels, in the given formation if any.
+func setupCoupledSystem(t *testing.T, formation ...string) *System {
+	rs := NewSystem()
+	
+	paris := domain.NewStation("Paris")
+	brussels := domain.NewStation("Brussels")
+	amsterdam := domain.NewStation("Amsterdam")
+	cologne := domain.NewStation("Cologne")
+	
+	amsterdamRoute := domain.NewRoute("R010", "Paris-Amsterdam",
+		[]domain.Station{paris, brussels, amsterdam}, []int{0, 310, 520})
+	cologneRoute := domain.NewRoute("R011", "Paris-Cologne",
+		[]domain.Station{paris, brussels, cologne}, []int{0, 310, 520})
+	
+	departure := time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC)
+	rs.AddService(domain.NewService("9401", amsterdamRoute, departure, []domain.Carriage{{
+		ID: "A",
+		Seats: []domain.Seat{
+			{Number: "A1", ComfortZone: domain.FirstClass, CarriageID: "A"},
+			{Number: "A2", ComfortZone: domain.FirstClass, CarriageID: "A"},
+		},
+	}}))
+	rs.AddService(domain.NewService("9402", cologneRoute, departure, []domain.Carriage{{
+		ID: "K",
+		Seats: []domain.Seat{
+			{Number: "K1", ComfortZone: domain.FirstClass, CarriageID: "K"},
+		},
+	}}))
+	
+	err := rs.AddCoupling(domain.Coupling{ServiceIDs: []string{"9401", "9402"}, From: "Paris", To: "Brussels", Formation: formation})
+	if err != nil {
+		t.Fatalf("Failed to couple services: %v", err)
+	}
+	
+	return rs
+}
+
+func TestSystem_AddCoupling_Validation(t *testing.T) {
+	rs := setupCoupledSystem(t)
+	
+	route := domain.NewRoute("R012", "Paris-Brussels",
+		[]domain.Station{domain.NewStation("Paris"), domain.NewStation("Brussels")}, []int{0, 310})
+	rs.AddService(domain.NewService("9403", route, time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC),
+		[]domain.Carriage{{ID: "A"}}))
+	
+	tests := []struct {
+		name     string
+		coupling domain.Coupling
+		errCode  string
+	}{
+		{"Single service", domain.Coupling{ServiceIDs: []string{"9401"}, From: "Paris", To: "Brussels"}, "INVALID_COUPLING"},
+		{"Unknown service", domain.Coupling{ServiceIDs: []string{"9401", "9999"}, From: "Paris", To: "Brussels"}, "SERVICE_NOT_FOUND"},
+		{"Section not on route", domain.Coupling{ServiceIDs: []string{"9401", "9402"}, From: "Brussels", To: "Amsterdam"}, "INVALID_COUPLING"},
+		{"Carriage ID reused", domain.Coupling{ServiceIDs: []string{"9401", "9403"}, From: "Paris", To: "Brussels"}, "DUPLICATE_CARRIAGE"},
+		{"Service listed twice", domain.Coupling{ServiceIDs: []string{"9403", "9403"}, From: "Paris", To: "Brussels"}, "INVALID_COUPLING"},
+		{"Service already coupled", domain.Coupling{ServiceIDs: []string{"9402", "9403"}, From: "Paris", To: "Brussels"}, "ALREADY_COUPLED"},
+		{"Formation missing a carriage", domain.Coupling{ServiceIDs: []string{"9402", "9403"}, From: "Paris", To: "Brussels", Formation: []string{"K"}}, "INVALID_COUPLING"},
+		{"Formation with unknown carriage", domain.Coupling{ServiceIDs: []string{"9402", "9403"}, From: "Paris", To: "Brussels", Formation: []string{"K", "A", "Z"}}, "INVALID_COUPLING"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rs.AddCoupling(tt.coupling)
+			reservationErr, ok := err.(ReservationError)
+			if !ok || reservationErr.Code != tt.errCode {
+				t.Errorf("Expected error code %s, got %v", tt.errCode, err)
+			}
+		})
+	}
+}
+
+func TestSystem_GetCombinedManifest(t *testing.T) {
+	rs := setupCoupledSystem(t)
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	
+	bookings := []struct {
+		serviceID   string
+		origin      string
+		destination string
+		seat        domain.SeatRequest
+	}{
+		{"9401", "Paris", "Amsterdam", domain.SeatRequest{CarriageID: "A", SeatNumber: "A1"}},
+		{"9401", "Brussels", "Amsterdam", domain.SeatRequest{CarriageID: "A", SeatNumber: "A2"}},
+		{"9402", "Paris", "Brussels", domain.SeatRequest{CarriageID: "K", SeatNumber: "K1"}},
+	}
+	for _, b := range bookings {
+		_, err := rs.MakeReservation(domain.ReservationRequest{
+			ServiceID: b.serviceID,
+			Origin:    b.origin,
+			Destination: b.destination,
+			Passengers: []domain.Passenger{{Name: "Passenger " + b.seat.SeatNumber}},
+			SeatRequests: []domain.SeatRequest{b.seat},
+			Date: date,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test booking: %v", err)
+		}
+	}
+	
+	manifest, found := rs.combinedManifest("9402", date)
+	if !found {
+		t.Fatalf("Expected combined manifest for coupled service 9402")
+	}
+	if len(manifest.Entries) != 2 {
+		t.Fatalf("Expected 2 passengers on the shared section, got %d", len(manifest.Entries))
+	}
+	
+	attribution := make(map[string]string)
+	for _, entry := range manifest.Entries {
+		attribution[entry.Seat.Number] = entry.ServiceID
+	}
+	if attribution["A1"] != "9401" || attribution["K1"] != "9402" {
+		t.Errorf("Expected seats attributed to their own services, got %v", attribution)
+	}
+	
+	if _, found := rs.combinedManifest("5160", date); found {
+		t.Errorf("Expected no combined manifest for an uncoupled service")
+	}
+}
+
+func TestSystem_CoupledServicesKeepSeparateInventory(t *testing.T) {
+	rs := setupCoupledSystem(t)
+	
+	_, err := rs.MakeReservation(domain.ReservationRequest{
+		ServiceID: "9401",
+		Origin:    "Paris",
+		Destination: "Brussels",
+		Passengers: []domain.Passenger{{Name: "Test Passenger"}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "K", SeatNumber: "K1"}},
+		Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) || !validationErrs.HasCode("SEAT_NOT_FOUND") {
+		t.Errorf("Expected SEAT_NOT_FOUND when booking another service's carriage, got %v", err)
+	}
+}
+
+func TestSystem_GetCombinedManifest_TrainOrder(t *testing.T) {
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		formation []string
+		want      []string
+	}{
+		{"Services in listed order", nil, []string{"A1", "A2", "K1"}},
+		{"Cologne portion at the front", []string{"K", "A"}, []string{"K1", "A1", "A2"}},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := setupCoupledSystem(t, tt.formation...)
+			for _, seat := range []domain.SeatRequest{
+				{CarriageID: "K", SeatNumber: "K1"},
+				{CarriageID: "A", SeatNumber: "A2"},
+				{CarriageID: "A", SeatNumber: "A1"},
+			} {
+				serviceID := "9401"
+				if seat.CarriageID == "K" {
+					serviceID = "9402"
+				}
+				_, err := rs.MakeReservation(domain.ReservationRequest{
+					ServiceID: serviceID,
+					Origin:    "Paris",
+					Destination: "Brussels",
+					Passengers: []domain.Passenger{{Name: "Passenger " + seat.SeatNumber}},
+					SeatRequests: []domain.SeatRequest{seat},
+					Date: date,
+				})
+				if err != nil {
+					t.Fatalf("Failed to create test booking: %v", err)
+				}
+			}
+			
+			manifest, _ := rs.combinedManifest("9401", date)
+			var seats []string
+			for _, entry := range manifest.Entries {
+				seats = append(seats, entry.Seat.Number)
+			}
+			if strings.Join(seats, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected seats %v, got %v", tt.want, seats)
+			}
+		})
+	}
+}
+
+func TestSystem_CouplingsArePersisted(t *testing.T) {
+	rs := setupCoupledSystem(t, "K", "A")
+	
+	reloaded, err := NewSystemWithStore(rs.store)
+	if err != nil {
+		t.Fatalf("Failed to reload system: %v", err)
+	}
+	coupling, found := reloaded.GetCoupling("9402")
+	if !found {
+		t.Fatalf("Expected coupling to survive a reload")
+	}
+	if coupling.From != "Paris" || coupling.To != "Brussels" || strings.Join(coupling.Formation, ",") != "K,A" {
+		t.Errorf("Expected reloaded coupling Paris-Brussels in formation K,A, got %+v", coupling)
+	}
+}
@@ -0,0 +1,122 @@
+package reservation
+
+import (
+	"fmt"
+	"ticketing-app/pkg/events"
+	"time"
+)
+
+const (
+	EventServiceDegraded  = "service.degraded"
+	EventServiceRecovered = "service.recovered"
+)
+
+// StoreHealthInterval is how often the scheduled health check probes an
+// unreachable store.
+const StoreHealthInterval = 10 * time.Second
+
+type StoreStatus struct {
+	Degraded bool
+	Since    time.Time
+	Reason   string
+}
+
+// Status reports whether the system is running read-only because its
+// store is unreachable.
+func (rs *System) Status() StoreStatus {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	return rs.status
+}
+
+// CheckStore probes the store and leaves or enters read-only mode
+// accordingly. It is scheduled by ScheduleJobs. The store is pinged before
+// taking the lock, so a store that hangs does not hold up reads.
+func (rs *System) CheckStore() StoreStatus {
+	err := rs.store.Ping()
+
+	rs.mu.Lock()
+	defer rs.unlock()
+
+	switch {
+	case err != nil && !rs.status.Degraded:
+		rs.degrade(err)
+	case err == nil && rs.status.Degraded:
+		rs.recover()
+	}
+	return rs.status
+}
+
+// lockForWrite takes the write lock for a call that writes to the store.
+// While degraded it first runs CheckStore, which pings the store without
+// the lock, so a system nobody schedules health checks for still recovers
+// on the next write and a hanging store never holds up reads. Otherwise it
+// queues for the lock only once, keeping writers in arrival order.
+func (rs *System) lockForWrite() {
+	if rs.degraded.Load() {
+		rs.CheckStore()
+	}
+	rs.mu.Lock()
+}
+
+// writable refuses writes while degraded, going by the last known status
+// rather than pinging the store under the lock.
+func (rs *System) writable() error {
+	if rs.status.Degraded {
+		return degradedError(rs.status)
+	}
+	return nil
+}
+
+// persist runs a store write unless degraded. A failed write only degrades
+// the system when the store no longer answers a ping; other failures are
+// returned to the caller as they are.
+func (rs *System) persist(write func() error) error {
+	if err := rs.writable(); err != nil {
+		return err
+	}
+	err := write()
+	if err == nil {
+		return nil
+	}
+	if pingErr := rs.store.Ping(); pingErr != nil {
+		rs.degrade(pingErr)
+		return degradedError(rs.status)
+	}
+	return err
+}
+
+func (rs *System) degrade(err error) {
+	rs.status = StoreStatus{Degraded: true, Since: rs.now(), Reason: err.Error()}
+	rs.degraded.Store(true)
+	rs.logger.Warn("reservation: store unavailable, refusing writes", "error", err)
+	rs.pending = append(rs.pending, events.Event{Type: EventServiceDegraded, Time: rs.status.Since, Data: rs.status})
+}
+
+func (rs *System) recover() {
+	outage := rs.status
+	rs.status = StoreStatus{}
+	rs.degraded.Store(false)
+	rs.logger.Info("reservation: store available again, accepting writes", "degradedSince", outage.Since)
+	rs.pending = append(rs.pending, events.Event{Type: EventServiceRecovered, Time: rs.now(), Data: outage})
+}
+
+// unlock releases the write lock and then publishes the events queued
+// while it was held.
+func (rs *System) unlock() {
+	pending := rs.pending
+	rs.pending = nil
+	rs.mu.Unlock()
+
+	for _, event := range pending {
+		rs.events.Publish(event)
+	}
+}
+
+func degradedError(status StoreStatus) ReservationError {
+	return ReservationError{
+		Message: fmt.Sprintf("Service is read-only since %s: %s", status.Since.Format(time.RFC3339), status.Reason),
+		Code:    "SERVICE_DEGRADED",
+	}
+}
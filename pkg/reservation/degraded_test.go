@@ -0,0 +1,246 @@
+//go:build !production
+
+package reservation
+
+import (
+	"errors"
+	"testing"
+	"ticketing-app/pkg/audit"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"ticketing-app/pkg/faults"
+	"ticketing-app/pkg/store"
+	"time"
+)
+
+func setupFaultySystem(t *testing.T) (*System, *faults.Injector) {
+	rs := setupTestSystem()
+	_, err := rs.MakeReservation(seatRequest("A1"))
+	if err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+
+	injector := faults.New(1)
+	rs.store = faults.WrapStore(rs.store, injector)
+	return rs, injector
+}
+
+func seatRequest(seat string) domain.ReservationRequest {
+	return domain.ReservationRequest{
+		ServiceID:    "5160",
+		Origin:       "Paris",
+		Destination:  "Amsterdam",
+		Passengers:   []domain.Passenger{{Name: "Passenger " + seat}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: seat}},
+		Date:         time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func isDegraded(err error) bool {
+	var reservationErr ReservationError
+	return errors.As(err, &reservationErr) && reservationErr.Code == "SERVICE_DEGRADED"
+}
+
+func TestSystem_DegradedModeServesReadsAndRejectsWrites(t *testing.T) {
+	rs, injector := setupFaultySystem(t)
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	var published []string
+	rs.Events().Subscribe(func(e events.Event) {
+		if e.Type == EventServiceDegraded || e.Type == EventServiceRecovered {
+			published = append(published, e.Type)
+		}
+	})
+
+	// The store goes away entirely
+	injector.Set(faults.StoreRead, faults.Rule{ErrorRate: 1})
+	injector.Set(faults.StoreWrite, faults.Rule{ErrorRate: 1})
+	injector.Set(faults.StoreDelete, faults.Rule{ErrorRate: 1})
+
+	_, err := rs.MakeReservation(seatRequest("A2"))
+	if !isDegraded(err) {
+		t.Fatalf("Expected SERVICE_DEGRADED for a write to an unreachable store, got %v", err)
+	}
+	if !rs.Status().Degraded {
+		t.Fatal("Expected the system to be degraded")
+	}
+
+	writes := []struct {
+		name  string
+		write func() error
+	}{
+		{"reservation", func() error { _, err := rs.MakeReservation(seatRequest("A3")); return err }},
+		{"cancellation", func() error { _, err := rs.CancelBooking("B0001"); return err }},
+		{"check-in", func() error { return rs.CheckIn("5160", "A", "A1", date) }},
+		{"service", func() error { return rs.AddService(domain.Service{ID: "9999"}) }},
+	}
+	for _, w := range writes {
+		if err := w.write(); !isDegraded(err) {
+			t.Errorf("Expected SERVICE_DEGRADED for %s, got %v", w.name, err)
+		}
+	}
+
+	conductor := rs.QueryAs(audit.Caller{UserID: "c-042", Role: audit.Conductor})
+	if passengers, err := conductor.GetPassengersBoardingAt("5160", "Paris", date); err != nil || len(passengers) != 1 {
+		t.Errorf("Expected conductor queries to be served while degraded, got %v", err)
+	}
+	if _, found, err := conductor.GetPassengerOnSeat("5160", "A", "A1", date); err != nil || !found {
+		t.Errorf("Expected seat lookups to be served while degraded, got %v", err)
+	}
+	if len(rs.GetAvailableSeats("5160", "Paris", "Amsterdam", "", date)) == 0 {
+		t.Error("Expected availability to be served while degraded")
+	}
+	if _, err := rs.Quote(seatRequest("A2")); err != nil {
+		t.Errorf("Expected quotes to be served while degraded, got %v", err)
+	}
+
+	if status := rs.CheckStore(); !status.Degraded {
+		t.Error("Expected the system to stay degraded while the store is unreachable")
+	}
+
+	injector.Clear(faults.StoreRead)
+	injector.Clear(faults.StoreWrite)
+	injector.Clear(faults.StoreDelete)
+	if status := rs.CheckStore(); status.Degraded {
+		t.Errorf("Expected the system to recover once the store is back, got %+v", status)
+	}
+	if _, err := rs.MakeReservation(seatRequest("A2")); err != nil {
+		t.Errorf("Expected writes to be accepted after recovery, got %v", err)
+	}
+
+	expected := []string{EventServiceDegraded, EventServiceRecovered}
+	if len(published) != len(expected) || published[0] != expected[0] || published[1] != expected[1] {
+		t.Errorf("Expected events %v, got %v", expected, published)
+	}
+}
+
+func TestSystem_FailedWriteToReachableStoreDoesNotDegrade(t *testing.T) {
+	rs, injector := setupFaultySystem(t)
+
+	injector.Set(faults.StoreWrite, faults.Rule{ErrorRate: 1, Times: 1})
+
+	_, err := rs.MakeReservation(seatRequest("A2"))
+	if err == nil || isDegraded(err) {
+		t.Fatalf("Expected the store error itself, got %v", err)
+	}
+	if rs.Status().Degraded {
+		t.Error("Expected a single failed write to leave the system writable")
+	}
+	if _, err := rs.MakeReservation(seatRequest("A2")); err != nil {
+		t.Errorf("Expected the retried reservation to succeed, got %v", err)
+	}
+}
+
+func TestSystem_DegradedSystemRecoversOnNextWrite(t *testing.T) {
+	rs, injector := setupFaultySystem(t)
+	
+	var published []string
+	rs.Events().Subscribe(func(e events.Event) {
+		if e.Type == EventServiceDegraded || e.Type == EventServiceRecovered {
+			published = append(published, e.Type)
+		}
+	})
+	
+	injector.Set(faults.StoreRead, faults.Rule{ErrorRate: 1})
+	injector.Set(faults.StoreWrite, faults.Rule{ErrorRate: 1})
+	if _, err := rs.MakeReservation(seatRequest("A2")); !isDegraded(err) {
+		t.Fatalf("Expected SERVICE_DEGRADED, got %v", err)
+	}
+	
+	// No health check runs; the next write finds the store back
+	injector.Clear(faults.StoreRead)
+	injector.Clear(faults.StoreWrite)
+	if _, err := rs.MakeReservation(seatRequest("A2")); err != nil {
+		t.Fatalf("Expected the write to recover the system, got %v", err)
+	}
+	if rs.Status().Degraded {
+		t.Error("Expected the system to be writable again")
+	}
+	if len(published) != 2 || published[1] != EventServiceRecovered {
+		t.Errorf("Expected degraded then recovered events, got %v", published)
+	}
+}
+
+func TestSystem_HangingStoreDoesNotHoldUpReadsWhileDegraded(t *testing.T) {
+	rs, injector := setupFaultySystem(t)
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	injector.Set(faults.StoreRead, faults.Rule{ErrorRate: 1})
+	injector.Set(faults.StoreWrite, faults.Rule{ErrorRate: 1})
+	if _, err := rs.MakeReservation(seatRequest("A2")); !isDegraded(err) {
+		t.Fatalf("Expected SERVICE_DEGRADED, got %v", err)
+	}
+	
+	// The next write's recovery ping hangs until released
+	pinging := make(chan struct{})
+	release := make(chan struct{})
+	injector.SetSleep(func(time.Duration) {
+		close(pinging)
+		<-release
+	})
+	injector.Set(faults.StoreRead, faults.Rule{ErrorRate: 1, Latency: time.Hour, LatencyRate: 1})
+	written := make(chan error)
+	go func() {
+		_, err := rs.MakeReservation(seatRequest("A2"))
+		written <- err
+	}()
+	<-pinging
+	
+	read := make(chan int)
+	go func() {
+		read <- len(rs.GetAvailableSeats("5160", "Paris", "Amsterdam", domain.FirstClass, date))
+	}()
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Error("Expected reads to be served while the store hangs")
+	}
+	close(release)
+	if err := <-written; !isDegraded(err) {
+		t.Errorf("Expected SERVICE_DEGRADED once the ping fails, got %v", err)
+	}
+}
+
+func TestQueries_AuditTrailIsKeptInTheStoreAndSurvivesDegradedMode(t *testing.T) {
+	rs := setupTestSystem()
+	inner := rs.store.(*store.Memory)
+	if _, err := rs.MakeReservation(seatRequest("A1")); err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+	injector := faults.New(1)
+	rs.store = faults.WrapStore(inner, injector)
+	conductor := rs.QueryAs(audit.Caller{UserID: "c-042", Role: audit.Conductor})
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	trail := func() int {
+		entries, _ := inner.AuditTrail()
+		return len(entries)
+	}
+	
+	if _, err := conductor.GetPassengersBoardingAt("5160", "Paris", date); err != nil || trail() != 1 {
+		t.Fatalf("Expected the lookup written to the store, got %d entries (err %v)", trail(), err)
+	}
+	
+	// A reachable store that fails the append withholds the lookup
+	injector.Set(faults.StoreWrite, faults.Rule{ErrorRate: 1, Times: 1})
+	var auditErr audit.AuditError
+	if _, err := conductor.GetPassengersBoardingAt("5160", "Paris", date); !errors.As(err, &auditErr) || auditErr.Code != "AUDIT_UNAVAILABLE" {
+		t.Errorf("Expected AUDIT_UNAVAILABLE, got %v", err)
+	}
+	
+	injector.Set(faults.StoreRead, faults.Rule{ErrorRate: 1})
+	injector.Set(faults.StoreWrite, faults.Rule{ErrorRate: 1})
+	rs.CheckStore()
+	if passengers, err := conductor.GetPassengersBoardingAt("5160", "Paris", date); err != nil || len(passengers) != 1 {
+		t.Errorf("Expected lookups to keep working while degraded, got %d (err %v)", len(passengers), err)
+	}
+	if trail() != 1 {
+		t.Errorf("Expected nothing appended while degraded, got %d entries", trail())
+	}
+	
+	injector.Clear(faults.StoreRead)
+	injector.Clear(faults.StoreWrite)
+	rs.CheckStore()
+	conductor.GetPassengerOnSeat("5160", "A", "A1", date)
+	if trail() != 3 {
+		t.Errorf("Expected the held entry appended after recovery, got %d entries", trail())
+	}
+}
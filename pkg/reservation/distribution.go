@@ -0,0 +1,21 @@
+package reservation
+
+// SaveDistributionBooking persists a distribution API's own record of a
+// booking, such as the OSDM server's link from its booking to the
+// reservations behind it, so the API can still find and refund them after
+// a restart. Like any write it is refused while degraded.
+func (rs *System) SaveDistributionBooking(bookingID string, record []byte) error {
+	rs.lockForWrite()
+	defer rs.unlock()
+
+	return rs.persist(func() error {
+		return rs.store.SaveDistributionBooking(bookingID, record)
+	})
+}
+
+// DistributionBookings reads back the records saved with
+// SaveDistributionBooking, by booking ID. Records the store could not read
+// are left out and listed in a *store.UnreadableError returned with them.
+func (rs *System) DistributionBookings() (map[string][]byte, error) {
+	return rs.store.DistributionBookings()
+}
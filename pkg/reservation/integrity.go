@@ -0,0 +1,212 @@
+package reservation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"ticketing-app/pkg/store"
+	"time"
+)
+
+const EventIntegrityViolated = "booking.integrity-violated"
+
+// IntegrityIssue codes.
+const (
+	ChecksumMismatch = "CHECKSUM_MISMATCH"
+	MissingFromStore = "MISSING_FROM_STORE"
+	UnknownInStore   = "UNKNOWN_IN_STORE"
+	DiffersFromStore = "DIFFERS_FROM_STORE"
+	// UnreadableInStore is a stored booking the store could not read back,
+	// as one an encrypting store has no key for.
+	UnreadableInStore = "UNREADABLE_IN_STORE"
+)
+
+type IntegrityIssue struct {
+	BookingID string
+	Code      string
+	Detail    string
+}
+
+type ReconciliationReport struct {
+	CheckedAt time.Time
+	Checked   int
+	// Unsealed counts bookings written before checksums existed; they are
+	// sealed the next time they change.
+	Unsealed int
+	Issues   []IntegrityIssue
+}
+
+// Reconcile reads every booking back from the store and checks it against
+// its own checksum and against the copy the system is working from. A
+// mismatch means the booking was corrupted in storage or edited directly
+// in the database.
+func (rs *System) Reconcile() (ReconciliationReport, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	stored, unreadable, err := storedBookings(rs.store)
+	if err != nil {
+		return ReconciliationReport{}, fmt.Errorf("failed to read bookings: %w", err)
+	}
+
+	report := ReconciliationReport{CheckedAt: rs.now(), Checked: len(stored) + len(unreadable), Issues: unreadable}
+	seen := make(map[string]bool, len(stored)+len(unreadable))
+	for _, issue := range unreadable {
+		seen[issue.BookingID] = true
+	}
+	for _, booking := range stored {
+		seen[booking.ID] = true
+		if booking.Checksum == "" {
+			report.Unsealed++
+		} else if !booking.ChecksumValid() {
+			report.Issues = append(report.Issues, IntegrityIssue{
+				BookingID: booking.ID,
+				Code:      ChecksumMismatch,
+				Detail:    fmt.Sprintf("stored checksum %s, content hashes to %s", booking.Checksum, booking.ContentChecksum()),
+			})
+			continue
+		}
+
+		working, exists := rs.bookings[booking.ID]
+		switch {
+		case !exists:
+			report.Issues = append(report.Issues, IntegrityIssue{
+				BookingID: booking.ID,
+				Code:      UnknownInStore,
+				Detail:    "booking is in the store but was never made through the system",
+			})
+		case working.ContentChecksum() != booking.ContentChecksum():
+			report.Issues = append(report.Issues, IntegrityIssue{
+				BookingID: booking.ID,
+				Code:      DiffersFromStore,
+				Detail:    "stored booking differs from the one in service",
+			})
+		}
+	}
+	for id := range rs.bookings {
+		if !seen[id] {
+			report.Issues = append(report.Issues, IntegrityIssue{
+				BookingID: id,
+				Code:      MissingFromStore,
+				Detail:    "booking is in service but not in the store",
+			})
+		}
+	}
+
+	sort.Slice(report.Issues, func(i, j int) bool {
+		if report.Issues[i].BookingID != report.Issues[j].BookingID {
+			return report.Issues[i].BookingID < report.Issues[j].BookingID
+		}
+		return report.Issues[i].Code < report.Issues[j].Code
+	})
+	return report, nil
+}
+
+// AlertIntegrity reconciles and publishes the report when it finds issues
+// that differ from the last alert, like AlertOversell.
+func (rs *System) AlertIntegrity() (ReconciliationReport, error) {
+	report, err := rs.Reconcile()
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+
+	fingerprint := fmt.Sprint(report.Issues)
+	rs.mu.Lock()
+	changed := rs.integrity != fingerprint
+	rs.integrity = fingerprint
+	rs.mu.Unlock()
+
+	if changed && len(report.Issues) > 0 {
+		rs.events.Publish(events.Event{Type: EventIntegrityViolated, Time: report.CheckedAt, Data: report})
+	}
+	return report, nil
+}
+
+// storedBookings reads every booking from the store. Bookings the store
+// reports it could not read are returned as issues, so one unreadable
+// booking does not stop the rest being checked or loaded.
+func storedBookings(st store.Store) ([]domain.Booking, []IntegrityIssue, error) {
+	bookings, err := st.Bookings()
+	var unreadable *store.UnreadableError
+	if !errors.As(err, &unreadable) {
+		return bookings, nil, err
+	}
+
+	issues := make([]IntegrityIssue, 0, len(unreadable.Bookings))
+	for id, err := range unreadable.Bookings {
+		issues = append(issues, IntegrityIssue{
+			BookingID: id,
+			Code:      UnreadableInStore,
+			Detail:    err.Error(),
+		})
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].BookingID < issues[j].BookingID })
+	return bookings, issues, nil
+}
+
+// verifyRead checks a booking about to be read against its checksum and
+// against the store's copy, which is read back and checked the same way,
+// so corruption shows when a booking is read rather than at the next
+// reconciliation. If the store cannot be read, as while degraded, the
+// working copy is checked alone.
+func (rs *System) verifyRead(working domain.Booking) []IntegrityIssue {
+	var issues []IntegrityIssue
+	if working.Checksum != "" && !working.ChecksumValid() {
+		issues = append(issues, IntegrityIssue{
+			BookingID: working.ID,
+			Code:      ChecksumMismatch,
+			Detail:    "booking in service does not match its checksum",
+		})
+	}
+
+	stored, found, err := rs.store.GetBooking(working.ID)
+	switch {
+	case err != nil:
+	case !found:
+		issues = append(issues, IntegrityIssue{
+			BookingID: working.ID,
+			Code:      MissingFromStore,
+			Detail:    "booking is in service but not in the store",
+		})
+	case stored.Checksum != "" && !stored.ChecksumValid():
+		issues = append(issues, IntegrityIssue{
+			BookingID: working.ID,
+			Code:      ChecksumMismatch,
+			Detail:    fmt.Sprintf("stored checksum %s, content hashes to %s", stored.Checksum, stored.ContentChecksum()),
+		})
+	case stored.ContentChecksum() != working.ContentChecksum():
+		issues = append(issues, IntegrityIssue{
+			BookingID: working.ID,
+			Code:      DiffersFromStore,
+			Detail:    "stored booking differs from the one in service",
+		})
+	}
+	return issues
+}
+
+// LoadIssues lists the bookings whose checksum failed when the system
+// loaded them from its store. They are still loaded, so their seats stay
+// held, and are left for an operator to repair. Bookings the store could
+// not read at all are listed too; those are not loaded.
+func (rs *System) LoadIssues() []IntegrityIssue {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	return rs.loadIssues
+}
+
+func verifyLoaded(bookings []domain.Booking) []IntegrityIssue {
+	var issues []IntegrityIssue
+	for _, booking := range bookings {
+		if booking.Checksum != "" && !booking.ChecksumValid() {
+			issues = append(issues, IntegrityIssue{
+				BookingID: booking.ID,
+				Code:      ChecksumMismatch,
+				Detail:    "checksum did not match when the booking was loaded",
+			})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,182 @@
+package reservation
+
+import (
+	"errors"
+	"testing"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"ticketing-app/pkg/store"
+	"time"
+)
+
+func setupSealedSystem(t *testing.T) (*System, store.Store) {
+	rs := setupTestSystem()
+	for _, seat := range []string{"A1", "A2", "A3"} {
+		_, err := rs.MakeReservation(domain.ReservationRequest{
+			ServiceID:    "5160",
+			Origin:       "Paris",
+			Destination:  "Amsterdam",
+			Passengers:   []domain.Passenger{{Name: "Passenger " + seat}},
+			SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: seat}},
+			Date:         time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test booking: %v", err)
+		}
+	}
+	return rs, rs.store
+}
+
+func TestSystem_BookingsAreSealed(t *testing.T) {
+	rs, _ := setupSealedSystem(t)
+
+	booking, _, _ := rs.GetBooking("B0001")
+	if !booking.ChecksumValid() {
+		t.Errorf("Expected a new booking to be sealed, got checksum %q", booking.Checksum)
+	}
+
+	if err := rs.CheckIn("5160", "A", "A1", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Failed to check in: %v", err)
+	}
+	checkedIn, _, _ := rs.GetBooking("B0001")
+	if !checkedIn.ChecksumValid() || checkedIn.Checksum == booking.Checksum {
+		t.Error("Expected the booking to be resealed after check-in")
+	}
+
+	report, err := rs.Reconcile()
+	if err != nil {
+		t.Fatalf("Failed to reconcile: %v", err)
+	}
+	if report.Checked != 3 || len(report.Issues) != 0 {
+		t.Errorf("Expected 3 clean bookings, got %+v", report)
+	}
+}
+
+func TestSystem_ReconcileFindsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(st store.Store, booking domain.Booking)
+		code   string
+	}{
+		{"edited without resealing", func(st store.Store, booking domain.Booking) {
+			booking.Passengers[0].Name = "Mallory"
+			st.SaveBooking(booking)
+		}, ChecksumMismatch},
+		{"edited and resealed", func(st store.Store, booking domain.Booking) {
+			booking.Passengers[0].Name = "Mallory"
+			st.SaveBooking(booking.Seal())
+		}, DiffersFromStore},
+		{"deleted", func(st store.Store, booking domain.Booking) {
+			st.DeleteBooking(booking.ID)
+		}, MissingFromStore},
+		{"inserted", func(st store.Store, booking domain.Booking) {
+			booking.ID = "B0999"
+			st.SaveBooking(booking.Seal())
+		}, UnknownInStore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, st := setupSealedSystem(t)
+			booking, _, _ := st.GetBooking("B0002")
+			booking.Passengers = append([]domain.Passenger(nil), booking.Passengers...)
+			tt.tamper(st, booking)
+
+			report, err := rs.Reconcile()
+			if err != nil {
+				t.Fatalf("Failed to reconcile: %v", err)
+			}
+			if len(report.Issues) != 1 || report.Issues[0].Code != tt.code {
+				t.Errorf("Expected a single %s issue, got %+v", tt.code, report.Issues)
+			}
+		})
+	}
+}
+
+func TestSystem_CorruptBookingsAreReportedOnLoad(t *testing.T) {
+	_, st := setupSealedSystem(t)
+	booking, _, _ := st.GetBooking("B0003")
+	booking.Passengers = []domain.Passenger{{Name: "Mallory"}}
+	st.SaveBooking(booking)
+
+	rs, err := NewSystemWithStore(st)
+	if err != nil {
+		t.Fatalf("Failed to load system: %v", err)
+	}
+	issues := rs.LoadIssues()
+	if len(issues) != 1 || issues[0].BookingID != "B0003" || issues[0].Code != ChecksumMismatch {
+		t.Errorf("Expected B0003 to fail its checksum on load, got %+v", issues)
+	}
+	if len(rs.allBookings()) != 3 {
+		t.Error("Expected the corrupt booking to be loaded so its seat stays held")
+	}
+}
+
+func TestSystem_AlertIntegrityOnChangeOnly(t *testing.T) {
+	rs, st := setupSealedSystem(t)
+
+	var alerts []ReconciliationReport
+	rs.Events().Subscribe(func(e events.Event) {
+		if report, ok := e.Data.(ReconciliationReport); ok && e.Type == EventIntegrityViolated {
+			alerts = append(alerts, report)
+		}
+	})
+
+	rs.AlertIntegrity()
+	st.DeleteBooking("B0001")
+	rs.AlertIntegrity()
+	rs.AlertIntegrity()
+
+	if len(alerts) != 1 {
+		t.Fatalf("Expected one alert for a standing problem, got %d", len(alerts))
+	}
+	if alerts[0].Issues[0].BookingID != "B0001" {
+		t.Errorf("Expected the alert to name B0001, got %+v", alerts[0].Issues)
+	}
+}
+
+func TestSystem_GetBookingVerifiesTheStoredCopy(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(st store.Store, booking domain.Booking)
+		code   string
+	}{
+		{"edited without resealing", func(st store.Store, booking domain.Booking) {
+			booking.Passengers = []domain.Passenger{{Name: "Mallory"}}
+			st.SaveBooking(booking)
+		}, ChecksumMismatch},
+		{"edited and resealed", func(st store.Store, booking domain.Booking) {
+			booking.Passengers = []domain.Passenger{{Name: "Mallory"}}
+			st.SaveBooking(booking.Seal())
+		}, DiffersFromStore},
+		{"deleted", func(st store.Store, booking domain.Booking) {
+			st.DeleteBooking(booking.ID)
+		}, MissingFromStore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, st := setupSealedSystem(t)
+			var alerts []ReconciliationReport
+			rs.Events().Subscribe(func(e events.Event) {
+				if report, ok := e.Data.(ReconciliationReport); ok && e.Type == EventIntegrityViolated {
+					alerts = append(alerts, report)
+				}
+			})
+			booking, _, _ := st.GetBooking("B0002")
+			tt.tamper(st, booking)
+
+			read, found, err := rs.GetBooking("B0002")
+			var reservationErr ReservationError
+			if !errors.As(err, &reservationErr) || reservationErr.Code != tt.code || found || read != nil {
+				t.Errorf("Expected %s and no booking, got %v (found %v)", tt.code, err, found)
+			}
+			if len(alerts) != 1 || alerts[0].Issues[0].BookingID != "B0002" {
+				t.Errorf("Expected one alert naming B0002, got %+v", alerts)
+			}
+			if _, found, err := rs.GetBooking("B0001"); !found || err != nil {
+				t.Errorf("Expected untouched bookings to read normally, got %v", err)
+			}
+		})
+	}
+}
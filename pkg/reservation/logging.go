@@ -0,0 +1,37 @@
+package reservation
+
+import (
+	"log/slog"
+	"ticketing-app/pkg/events"
+	"ticketing-app/pkg/redact"
+)
+
+// Logger returns the system's logger. Passenger data in any attribute is
+// masked before it reaches the handler, so the servers and jobs built on a
+// System can share it and log bookings without leaking them.
+func (rs *System) Logger() *slog.Logger {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	return rs.logger
+}
+
+// SetLogHandler sets where the system logs. Records still pass through
+// the masking handler first.
+func (rs *System) SetLogHandler(handler slog.Handler) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.logger = newLogger(handler)
+}
+
+// ForwardEvents subscribes a handler that passes events on outside the
+// system, such as a display stream or a webhook. The handler only ever
+// sees events with passenger data masked.
+func (rs *System) ForwardEvents(handler func(events.Event)) func() {
+	return redact.Subscribe(rs.events, redact.Policy{}, handler)
+}
+
+func newLogger(handler slog.Handler) *slog.Logger {
+	return slog.New(redact.NewHandler(handler, redact.Policy{}))
+}
@@ -0,0 +1,166 @@
+package reservation
+
+import (
+	"fmt"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"time"
+)
+
+// EventOccupancyChanged is published for every carriage whose occupancy a
+// booking, cancellation, check-in or no-show changed.
+const EventOccupancyChanged = "occupancy.changed"
+
+type OccupancyChanged struct {
+	ServiceID  string
+	Date       time.Time
+	CarriageID string
+	Segments   []domain.SegmentOccupancy
+}
+
+func (rs *System) CheckIn(serviceID, carriageID, seatNumber string, date time.Time) error {
+	return rs.setBoardingStatus(serviceID, carriageID, seatNumber, date, domain.BoardingCheckedIn)
+}
+
+func (rs *System) MarkNoShow(serviceID, carriageID, seatNumber string, date time.Time) error {
+	return rs.setBoardingStatus(serviceID, carriageID, seatNumber, date, domain.BoardingNoShow)
+}
+
+// setBoardingStatus records a conductor's check-in or no-show for the
+// ticket on a seat and publishes the carriage's updated occupancy.
+func (rs *System) setBoardingStatus(serviceID, carriageID, seatNumber string, date time.Time, status domain.BoardingStatus) error {
+	rs.lockForWrite()
+	defer rs.unlock()
+
+	for id, booking := range rs.bookings {
+		for i, ticket := range booking.Tickets {
+			if ticket.Service.ID == serviceID &&
+				ticket.Seat.CarriageID == carriageID &&
+				ticket.Seat.Number == seatNumber &&
+				rs.isSameDate(ticket.Service.DateTime, date) {
+				tickets := make([]domain.Ticket, len(booking.Tickets))
+				copy(tickets, booking.Tickets)
+				tickets[i].Boarding = status
+				booking.Tickets = tickets
+				booking = booking.Seal()
+
+				if err := rs.persist(func() error { return rs.store.SaveBooking(booking) }); err != nil {
+					return err
+				}
+				rs.bookings[id] = booking
+				rs.queueCarriageOccupancy(serviceID, carriageID, date)
+				return nil
+			}
+		}
+	}
+
+	return ReservationError{
+		Message: fmt.Sprintf("No ticket for seat %s in carriage %s on service %s", seatNumber, carriageID, serviceID),
+		Code:    "TICKET_NOT_FOUND",
+	}
+}
+
+// queueOccupancy queues an occupancy update for every carriage a booking
+// has seats in, for displays to pick up once the lock is released.
+func (rs *System) queueOccupancy(booking domain.Booking, date time.Time) {
+	queued := make(map[string]bool)
+	for _, ticket := range booking.Tickets {
+		key := ticket.Service.ID + "/" + ticket.Seat.CarriageID
+		if queued[key] {
+			continue
+		}
+		queued[key] = true
+		rs.queueCarriageOccupancy(ticket.Service.ID, ticket.Seat.CarriageID, date)
+	}
+}
+
+func (rs *System) queueCarriageOccupancy(serviceID, carriageID string, date time.Time) {
+	rs.pending = append(rs.pending, events.Event{Type: EventOccupancyChanged, Time: rs.now(), Data: OccupancyChanged{
+		ServiceID:  serviceID,
+		Date:       date,
+		CarriageID: carriageID,
+		Segments:   rs.carriageOccupancy(rs.services[serviceID], carriageID, date),
+	}})
+}
+
+// GetOccupancy reports, for every carriage, how full it is on each segment
+// it runs, combining bookings with conductor check-ins and no-shows.
+func (rs *System) GetOccupancy(serviceID string, date time.Time) []domain.SegmentOccupancy {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	var occupancy []domain.SegmentOccupancy
+
+	service, exists := rs.services[serviceID]
+	if !exists {
+		return occupancy
+	}
+
+	for _, carriage := range service.Carriages {
+		occupancy = append(occupancy, rs.carriageOccupancy(service, carriage.ID, date)...)
+	}
+	return occupancy
+}
+
+func (rs *System) carriageOccupancy(service domain.Service, carriageID string, date time.Time) []domain.SegmentOccupancy {
+	carriage, found := service.GetCarriage(carriageID)
+	if !found {
+		return nil
+	}
+	route := service.RouteForCarriage(carriageID)
+
+	segments := make([]domain.SegmentOccupancy, 0, len(route.Stops))
+	for i := 0; i+1 < len(route.Stops); i++ {
+		segments = append(segments, domain.SegmentOccupancy{
+			CarriageID: carriageID,
+			From:       route.Stops[i].Station.Name,
+			To:         route.Stops[i+1].Station.Name,
+			Seats:      len(carriage.Seats),
+		})
+	}
+
+	for _, booking := range rs.bookings {
+		for _, ticket := range booking.Tickets {
+			if ticket.Service.ID != service.ID ||
+				ticket.Seat.CarriageID != carriageID ||
+				!rs.isSameDate(ticket.Service.DateTime, date) {
+				continue
+			}
+			originIndex, foundOrigin := route.GetStopIndex(ticket.Origin.Name)
+			destIndex, foundDest := route.GetStopIndex(ticket.Destination.Name)
+			if !foundOrigin || !foundDest {
+				continue
+			}
+			for i := originIndex; i < destIndex; i++ {
+				switch ticket.Boarding {
+				case domain.BoardingCheckedIn:
+					segments[i].CheckedIn++
+				case domain.BoardingNoShow:
+					segments[i].NoShows++
+				default:
+					segments[i].Expected++
+				}
+			}
+		}
+	}
+
+	for i := range segments {
+		segments[i].Level = occupancyLevel(segments[i])
+	}
+	return segments
+}
+
+func occupancyLevel(segment domain.SegmentOccupancy) domain.OccupancyLevel {
+	if segment.Seats == 0 {
+		return domain.Busy
+	}
+	taken := float64(segment.CheckedIn+segment.Expected) / float64(segment.Seats)
+	switch {
+	case taken >= 0.85:
+		return domain.Busy
+	case taken >= 0.5:
+		return domain.FillingUp
+	default:
+		return domain.SeatsAvailable
+	}
+}
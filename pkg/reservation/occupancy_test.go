@@ -0,0 +1,104 @@
+package reservation
+
+import (
+	"testing"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"time"
+)
+
+func TestSystem_GetOccupancy(t *testing.T) {
+	rs := setupTestSystem()
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	
+	seats := []struct {
+		seat        string
+		destination string
+	}{
+		{"A1", "Amsterdam"},
+		{"A2", "Amsterdam"},
+		{"A3", "Amsterdam"},
+		{"A4", "Calais"},
+		{"A5", "Calais"},
+	}
+	for _, s := range seats {
+		_, err := rs.MakeReservation(domain.ReservationRequest{
+			ServiceID: "5160",
+			Origin:    "Paris",
+			Destination: s.destination,
+			Passengers: []domain.Passenger{{Name: "Passenger " + s.seat}},
+			SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: s.seat}},
+			Date: date,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test booking: %v", err)
+		}
+	}
+	
+	occupancy := rs.GetOccupancy("5160", date)
+	if len(occupancy) != 2 {
+		t.Fatalf("Expected 2 segments, got %d", len(occupancy))
+	}
+	if occupancy[0].Expected != 5 || occupancy[0].Level != domain.FillingUp {
+		t.Errorf("Expected Paris-Calais filling up with 5 expected, got %+v", occupancy[0])
+	}
+	if occupancy[1].Expected != 3 || occupancy[1].Level != domain.SeatsAvailable {
+		t.Errorf("Expected Calais-Amsterdam with seats available, got %+v", occupancy[1])
+	}
+	
+	var published []OccupancyChanged
+	rs.Events().Subscribe(func(e events.Event) {
+		if changed, ok := e.Data.(OccupancyChanged); ok {
+			published = append(published, changed)
+		}
+	})
+	
+	if err := rs.CheckIn("5160", "A", "A1", date); err != nil {
+		t.Fatalf("Failed to check in: %v", err)
+	}
+	if err := rs.MarkNoShow("5160", "A", "A4", date); err != nil {
+		t.Fatalf("Failed to mark no-show: %v", err)
+	}
+	
+	occupancy = rs.GetOccupancy("5160", date)
+	first := occupancy[0]
+	if first.CheckedIn != 1 || first.Expected != 3 || first.NoShows != 1 {
+		t.Errorf("Unexpected Paris-Calais occupancy %+v", first)
+	}
+	if first.Level != domain.FillingUp {
+		t.Errorf("Expected filling up, got %s", first.Level)
+	}
+	
+	if len(published) != 2 {
+		t.Fatalf("Expected 2 occupancy events, got %d", len(published))
+	}
+	if published[1].CarriageID != "A" || published[1].Segments[0].NoShows != 1 {
+		t.Errorf("Unexpected occupancy event %+v", published[1])
+	}
+	
+	err := rs.CheckIn("5160", "A", "A8", date)
+	if reservationErr, ok := err.(ReservationError); !ok || reservationErr.Code != "TICKET_NOT_FOUND" {
+		t.Errorf("Expected TICKET_NOT_FOUND for an empty seat, got %v", err)
+	}
+	
+	booking, err := rs.MakeReservation(domain.ReservationRequest{
+		ServiceID: "5160",
+		Origin:    "Paris",
+		Destination: "Amsterdam",
+		Passengers: []domain.Passenger{{Name: "Passenger A6"}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A6"}},
+		Date: date,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+	if _, err := rs.CancelBooking(booking.ID); err != nil {
+		t.Fatalf("Failed to cancel booking: %v", err)
+	}
+	if len(published) != 4 {
+		t.Fatalf("Expected booking and cancellation to publish occupancy, got %d events", len(published))
+	}
+	if published[2].Segments[0].Expected != 4 || published[3].Segments[0].Expected != 3 {
+		t.Errorf("Expected 4 then 3 expected on Paris-Calais, got %+v and %+v", published[2].Segments[0], published[3].Segments[0])
+	}
+}
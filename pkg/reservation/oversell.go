@@ -0,0 +1,235 @@
+package reservation
+
+import (
+	"fmt"
+	"sort"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"time"
+)
+
+const EventDepartureOversold = "departure.oversold"
+
+// OversoldSegment is a carriage segment holding more seated tickets than
+// the carriage has seats in the service's current composition.
+type OversoldSegment struct {
+	CarriageID string
+	From       string
+	To         string
+	Seats      int
+	Tickets    int
+}
+
+// Remediation is a ticket that has no seat of its own on the train as it
+// now runs, with a free seat it could be moved to if there is one. It
+// refers to the ticket by booking and position rather than carrying the
+// passenger, since reports are published as events; agents look the
+// passenger up through Queries.
+type Remediation struct {
+	BookingID   string
+	Ticket      int // index into the booking's tickets
+	Seat        domain.Seat
+	Origin      string
+	Destination string
+	Reason      string
+	Suggested   *domain.Seat
+}
+
+// OversellReport covers one departure, a service on a date.
+type OversellReport struct {
+	ServiceID    string
+	Date         time.Time
+	Segments     []OversoldSegment
+	Remediations []Remediation
+	DetectedAt   time.Time
+}
+
+// AuditOversell finds departures where seated tickets exceed physical
+// seats. Normal booking never oversells, but a recomposition that drops
+// carriages or seats, or a bug, can leave tickets without a seat. Tickets
+// marked no-show have released their seat and are not counted.
+func (rs *System) AuditOversell() []OversellReport {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	type departure struct {
+		serviceID string
+		date      time.Time
+	}
+	tickets := make(map[departure][]bookedTicket)
+	for _, booking := range rs.bookings {
+		for i, ticket := range booking.Tickets {
+			if ticket.Boarding == domain.BoardingNoShow {
+				continue
+			}
+			y, m, d := ticket.Service.DateTime.Date()
+			key := departure{ticket.Service.ID, time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+			tickets[key] = append(tickets[key], bookedTicket{booking: booking, ticket: ticket, index: i})
+		}
+	}
+
+	var reports []OversellReport
+	for key, departureTickets := range tickets {
+		service, exists := rs.services[key.serviceID]
+		if !exists {
+			continue
+		}
+		report := rs.auditDeparture(service, key.date, departureTickets)
+		if len(report.Segments) > 0 || len(report.Remediations) > 0 {
+			reports = append(reports, report)
+		}
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if !reports[i].Date.Equal(reports[j].Date) {
+			return reports[i].Date.Before(reports[j].Date)
+		}
+		return reports[i].ServiceID < reports[j].ServiceID
+	})
+	return reports
+}
+
+// AlertOversell audits and publishes an alert for every oversold
+// departure whose report changed since the last alert, so a standing
+// problem is raised once rather than on every run.
+func (rs *System) AlertOversell() []OversellReport {
+	reports := rs.AuditOversell()
+	now := rs.clock()
+
+	rs.mu.Lock()
+	var alerts []OversellReport
+	current := make(map[string]string)
+	for _, report := range reports {
+		key := report.ServiceID + "/" + report.Date.Format("2006-01-02")
+		fingerprint := fmt.Sprint(report.Segments, len(report.Remediations))
+		current[key] = fingerprint
+		if rs.oversold[key] != fingerprint {
+			report.DetectedAt = now
+			alerts = append(alerts, report)
+		}
+	}
+	rs.oversold = current
+	rs.mu.Unlock()
+
+	for _, report := range alerts {
+		rs.events.Publish(events.Event{Type: EventDepartureOversold, Time: now, Data: report})
+	}
+	return alerts
+}
+
+type bookedTicket struct {
+	booking domain.Booking
+	ticket  domain.Ticket
+	index   int // of the ticket in the booking
+}
+
+func (rs *System) auditDeparture(service domain.Service, date time.Time, tickets []bookedTicket) OversellReport {
+	report := OversellReport{ServiceID: service.ID, Date: date, DetectedAt: rs.now()}
+
+	// Earlier bookings keep their seat; later ones sharing it need moving
+	sort.Slice(tickets, func(i, j int) bool {
+		if !tickets[i].booking.CreatedAt.Equal(tickets[j].booking.CreatedAt) {
+			return tickets[i].booking.CreatedAt.Before(tickets[j].booking.CreatedAt)
+		}
+		return tickets[i].booking.ID < tickets[j].booking.ID
+	})
+
+	byCarriage := make(map[string][]bookedTicket)
+	var carriageIDs []string
+	for _, bt := range tickets {
+		id := bt.ticket.Seat.CarriageID
+		if _, seen := byCarriage[id]; !seen {
+			carriageIDs = append(carriageIDs, id)
+		}
+		byCarriage[id] = append(byCarriage[id], bt)
+	}
+	sort.Strings(carriageIDs)
+
+	for _, carriageID := range carriageIDs {
+		carriage, _ := service.GetCarriage(carriageID)
+		route := service.RouteForCarriage(carriageID)
+		for i := 0; i+1 < len(route.Stops); i++ {
+			count := 0
+			for _, bt := range byCarriage[carriageID] {
+				if coversSegment(route, bt.ticket, i) {
+					count++
+				}
+			}
+			if count > len(carriage.Seats) {
+				report.Segments = append(report.Segments, OversoldSegment{
+					CarriageID: carriageID,
+					From:       route.Stops[i].Station.Name,
+					To:         route.Stops[i+1].Station.Name,
+					Seats:      len(carriage.Seats),
+					Tickets:    count,
+				})
+			}
+		}
+	}
+
+	// Suggestions avoid booked seats and each other
+	suggested := rs.bookedSeats(service.ID, date)
+	var seated []bookedTicket
+	for _, bt := range tickets {
+		reason := ""
+		if _, exists := service.GetSeatByID(bt.ticket.Seat.CarriageID, bt.ticket.Seat.Number); !exists {
+			reason = "seat removed by recomposition"
+		} else {
+			for _, other := range seated {
+				if other.ticket.Seat == bt.ticket.Seat && journeysOverlap(service, other.ticket, bt.ticket) {
+					reason = fmt.Sprintf("seat also sold on booking %s", other.booking.ID)
+					break
+				}
+			}
+		}
+		if reason == "" {
+			seated = append(seated, bt)
+			continue
+		}
+
+		remediation := Remediation{
+			BookingID:   bt.booking.ID,
+			Ticket:      bt.index,
+			Seat:        bt.ticket.Seat,
+			Origin:      bt.ticket.Origin.Name,
+			Destination: bt.ticket.Destination.Name,
+			Reason:      reason,
+		}
+		if seat, found := rs.replacementSeat(service, date, bt.ticket, suggested); found {
+			suggested[seat.CarriageID+"/"+seat.Number] = true
+			remediation.Suggested = &seat
+		}
+		report.Remediations = append(report.Remediations, remediation)
+	}
+
+	return report
+}
+
+// replacementSeat finds a free seat in the same comfort zone on a carriage
+// that runs the ticket's whole journey. taken must include the seats booked
+// on the departure.
+func (rs *System) replacementSeat(service domain.Service, date time.Time, ticket domain.Ticket, taken map[string]bool) (domain.Seat, bool) {
+	req := domain.ReservationRequest{
+		ServiceID:   service.ID,
+		Origin:      ticket.Origin.Name,
+		Destination: ticket.Destination.Name,
+		Date:        date,
+	}
+	return rs.allocateSeat(service, req, domain.SeatRequest{ComfortZone: ticket.Seat.ComfortZone}, taken)
+}
+
+func coversSegment(route domain.Route, ticket domain.Ticket, segment int) bool {
+	originIndex, foundOrigin := route.GetStopIndex(ticket.Origin.Name)
+	destIndex, foundDest := route.GetStopIndex(ticket.Destination.Name)
+	return foundOrigin && foundDest && originIndex <= segment && segment < destIndex
+}
+
+func journeysOverlap(service domain.Service, a, b domain.Ticket) bool {
+	route := service.RouteForCarriage(a.Seat.CarriageID)
+	for i := 0; i+1 < len(route.Stops); i++ {
+		if coversSegment(route, a, i) && coversSegment(route, b, i) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package reservation
+
+import (
+	"testing"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"time"
+)
+
+func setupOversoldSystem(t *testing.T) (*System, time.Time) {
+	rs := setupTestSystem()
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	
+	seats := []struct {
+		seat        string
+		destination string
+	}{
+		{"A1", "Amsterdam"},
+		{"A2", "Amsterdam"},
+		{"A3", "Amsterdam"},
+		{"A4", "Calais"},
+		{"A5", "Calais"},
+	}
+	for _, s := range seats {
+		_, err := rs.MakeReservation(domain.ReservationRequest{
+			ServiceID:    "5160",
+			Origin:       "Paris",
+			Destination:  s.destination,
+			Passengers:   []domain.Passenger{{Name: "Passenger " + s.seat}},
+			SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: s.seat}},
+			Date:         date,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test booking: %v", err)
+		}
+	}
+	
+	// Recompose: carriage A loses seats A4-A8 and a smaller carriage B is added
+	service, _ := rs.GetService("5160")
+	service.Carriages = []domain.Carriage{
+		{ID: "A", Seats: service.Carriages[0].Seats[:3]},
+		{ID: "B", Seats: []domain.Seat{{Number: "B1", ComfortZone: domain.FirstClass, CarriageID: "B"}}},
+	}
+	rs.AddService(service)
+	
+	return rs, date
+}
+
+func TestSystem_AuditOversell(t *testing.T) {
+	rs, date := setupOversoldSystem(t)
+	
+	reports := rs.AuditOversell()
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 oversold departure, got %d", len(reports))
+	}
+	report := reports[0]
+	if report.ServiceID != "5160" || !rs.isSameDate(report.Date, date) {
+		t.Errorf("Expected service 5160 on %s, got %s on %s", date, report.ServiceID, report.Date)
+	}
+	
+	expected := []OversoldSegment{{CarriageID: "A", From: "Paris", To: "Calais", Seats: 3, Tickets: 5}}
+	if len(report.Segments) != 1 || report.Segments[0] != expected[0] {
+		t.Errorf("Expected %+v, got %+v", expected, report.Segments)
+	}
+	
+	if len(report.Remediations) != 2 {
+		t.Fatalf("Expected 2 tickets to move, got %+v", report.Remediations)
+	}
+	if r := report.Remediations[0]; r.Seat.Number != "A4" || r.Suggested == nil || r.Suggested.Number != "B1" {
+		t.Errorf("Expected A4 to be moved to B1, got %+v", r)
+	}
+	if r := report.Remediations[1]; r.Seat.Number != "A5" || r.Suggested != nil {
+		t.Errorf("Expected no seat left for A5, got %+v", r)
+	}
+}
+
+func TestSystem_AuditOversellFindsDoubleSoldSeats(t *testing.T) {
+	rs := setupTestSystem()
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	
+	req := domain.ReservationRequest{
+		ServiceID:    "5160",
+		Origin:       "Paris",
+		Destination:  "Amsterdam",
+		Passengers:   []domain.Passenger{{Name: "John Doe"}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A1"}},
+		Date:         date,
+	}
+	booking, err := rs.MakeReservation(req)
+	if err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+	
+	// A bug sells the same seat again
+	duplicate := *booking
+	duplicate.ID = "B9999"
+	duplicate.CreatedAt = booking.CreatedAt.Add(time.Minute)
+	rs.bookings[duplicate.ID] = duplicate
+	
+	reports := rs.AuditOversell()
+	if len(reports) != 1 || len(reports[0].Segments) != 0 || len(reports[0].Remediations) != 1 {
+		t.Fatalf("Expected one remediation and no oversold segment, got %+v", reports)
+	}
+	if r := reports[0].Remediations[0]; r.BookingID != "B9999" || r.Ticket != 0 || r.Suggested == nil || r.Suggested.Number != "A2" {
+		t.Errorf("Expected the later booking to be moved to A2, got %+v", r)
+	}
+}
+
+func TestSystem_AlertOversellOnChangeOnly(t *testing.T) {
+	rs, date := setupOversoldSystem(t)
+	
+	var alerts []OversellReport
+	rs.Events().Subscribe(func(e events.Event) {
+		if report, ok := e.Data.(OversellReport); ok && e.Type == EventDepartureOversold {
+			alerts = append(alerts, report)
+		}
+	})
+	
+	rs.AlertOversell()
+	rs.AlertOversell()
+	if len(alerts) != 1 {
+		t.Fatalf("Expected a standing oversell to be alerted once, got %d alerts", len(alerts))
+	}
+	
+	if err := rs.MarkNoShow("5160", "A", "A5", date); err != nil {
+		t.Fatalf("Failed to mark no-show: %v", err)
+	}
+	rs.AlertOversell()
+	if len(alerts) != 2 || alerts[1].Segments[0].Tickets != 4 {
+		t.Errorf("Expected a new alert with 4 tickets after the no-show, got %+v", alerts)
+	}
+	
+	if err := rs.MarkNoShow("5160", "A", "A4", date); err != nil {
+		t.Fatalf("Failed to mark no-show: %v", err)
+	}
+	if reports := rs.AlertOversell(); len(reports) != 0 || len(rs.AuditOversell()) != 0 {
+		t.Errorf("Expected the oversell to be resolved, got %+v", reports)
+	}
+}
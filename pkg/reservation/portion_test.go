@@ -0,0 +1,156 @@
+package reservation
+
+import (
+	"errors"
+	"testing"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+// setupPortionSystem builds a train running Paris-Brussels-Amsterdam whose
+// carriage K detaches at Brussels and continues to Cologne.
+func setupPortionSystem() *System {
+	rs := NewSystem()
+	
+	paris := domain.NewStation("Paris")
+	brussels := domain.NewStation("Brussels")
+	amsterdam := domain.NewStation("Amsterdam")
+	cologne := domain.NewStation("Cologne")
+	
+	mainRoute := domain.NewRoute("R010", "Paris-Amsterdam",
+		[]domain.Station{paris, brussels, amsterdam},
+		[]int{0, 310, 520})
+	cologneRoute := domain.NewRoute("R011", "Paris-Cologne",
+		[]domain.Station{paris, brussels, cologne},
+		[]int{0, 310, 520})
+	
+	carriages := []domain.Carriage{
+		{
+			ID: "A",
+			Seats: []domain.Seat{
+				{Number: "A1", ComfortZone: domain.FirstClass, CarriageID: "A"},
+				{Number: "A2", ComfortZone: domain.FirstClass, CarriageID: "A"},
+			},
+		},
+		{
+			ID:      "K",
+			Portion: "cologne",
+			Seats: []domain.Seat{
+				{Number: "K1", ComfortZone: domain.FirstClass, CarriageID: "K"},
+				{Number: "K2", ComfortZone: domain.SecondClass, CarriageID: "K"},
+			},
+		},
+	}
+	
+	service := domain.NewService("9400", mainRoute,
+		time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC), carriages)
+	service.Portions = []domain.Portion{{ID: "cologne", Route: cologneRoute}}
+	
+	rs.AddRoute(mainRoute)
+	rs.AddRoute(cologneRoute)
+	rs.AddService(service)
+	
+	return rs
+}
+
+func portionRequest(destination string, seat domain.SeatRequest) domain.ReservationRequest {
+	return domain.ReservationRequest{
+		ServiceID: "9400",
+		Origin:    "Paris",
+		Destination: destination,
+		Passengers: []domain.Passenger{{Name: "Test Passenger"}},
+		SeatRequests: []domain.SeatRequest{seat},
+		Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSystem_PortionSeatsOnlySellableOnServedSegments(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		seat        domain.SeatRequest
+		errCode     string
+	}{
+		{"Detaching carriage on shared section", "Brussels", domain.SeatRequest{CarriageID: "K", SeatNumber: "K1"}, ""},
+		{"Detaching carriage to its own destination", "Cologne", domain.SeatRequest{CarriageID: "K", SeatNumber: "K1"}, ""},
+		{"Detaching carriage past the split", "Amsterdam", domain.SeatRequest{CarriageID: "K", SeatNumber: "K1"}, "SEAT_NOT_ON_SEGMENT"},
+		{"Main carriage to the portion destination", "Cologne", domain.SeatRequest{CarriageID: "A", SeatNumber: "A1"}, "SEAT_NOT_ON_SEGMENT"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := setupPortionSystem()
+			_, err := rs.MakeReservation(portionRequest(tt.destination, tt.seat))
+			
+			if tt.errCode == "" {
+				if err != nil {
+					t.Errorf("Expected no error but got: %v", err)
+				}
+				return
+			}
+			var validationErrs ValidationErrors
+			if !errors.As(err, &validationErrs) || !validationErrs.HasCode(tt.errCode) {
+				t.Errorf("Expected error code %s, got %v", tt.errCode, err)
+			}
+		})
+	}
+}
+
+func TestSystem_AllocationUsesCorrectPortion(t *testing.T) {
+	rs := setupPortionSystem()
+	
+	booking, err := rs.MakeReservation(portionRequest("Cologne", domain.SeatRequest{}))
+	if err != nil {
+		t.Fatalf("Expected allocation to Cologne to succeed, got %v", err)
+	}
+	if booking.Tickets[0].Seat.CarriageID != "K" {
+		t.Errorf("Expected passenger to Cologne in carriage K, got %s", booking.Tickets[0].Seat.CarriageID)
+	}
+	if booking.Tickets[0].Destination.Name != "Cologne" {
+		t.Errorf("Expected ticket destination Cologne, got %s", booking.Tickets[0].Destination.Name)
+	}
+	
+	booking, err = rs.MakeReservation(portionRequest("Cologne", domain.SeatRequest{ComfortZone: domain.SecondClass}))
+	if err != nil {
+		t.Fatalf("Expected second-class allocation to succeed, got %v", err)
+	}
+	if booking.Tickets[0].Seat.Number != "K2" {
+		t.Errorf("Expected seat K2, got %s", booking.Tickets[0].Seat.Number)
+	}
+	
+	_, err = rs.MakeReservation(portionRequest("Cologne", domain.SeatRequest{}))
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) || !validationErrs.HasCode("NO_SEAT_AVAILABLE") {
+		t.Errorf("Expected NO_SEAT_AVAILABLE once the portion is full, got %v", err)
+	}
+	
+	booking, err = rs.MakeReservation(portionRequest("Amsterdam", domain.SeatRequest{}))
+	if err != nil {
+		t.Fatalf("Expected allocation to Amsterdam to succeed, got %v", err)
+	}
+	if booking.Tickets[0].Seat.CarriageID != "A" {
+		t.Errorf("Expected passenger to Amsterdam in carriage A, got %s", booking.Tickets[0].Seat.CarriageID)
+	}
+}
+
+func TestSystem_GetPassengersBetweenStations_Portion(t *testing.T) {
+	rs := setupPortionSystem()
+	
+	if _, err := rs.MakeReservation(portionRequest("Cologne", domain.SeatRequest{CarriageID: "K", SeatNumber: "K1"})); err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+	if _, err := rs.MakeReservation(portionRequest("Amsterdam", domain.SeatRequest{CarriageID: "A", SeatNumber: "A1"})); err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+	
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	if passengers := rs.passengersBetweenStations("9400", "Paris", "Brussels", date); len(passengers) != 2 {
+		t.Errorf("Expected 2 passengers on the shared section, got %d", len(passengers))
+	}
+	if passengers := rs.passengersBetweenStations("9400", "Brussels", "Cologne", date); len(passengers) != 1 {
+		t.Errorf("Expected 1 passenger between Brussels and Cologne, got %d", len(passengers))
+	}
+	if passengers := rs.passengersBetweenStations("9400", "Brussels", "Amsterdam", date); len(passengers) != 1 {
+		t.Errorf("Expected 1 passenger between Brussels and Amsterdam, got %d", len(passengers))
+	}
+}
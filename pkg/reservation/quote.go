@@ -0,0 +1,327 @@
+package reservation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"ticketing-app/pkg/currency"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"ticketing-app/pkg/pricing"
+	"time"
+)
+
+const (
+	EventQuoteExpired = "quote.expired"
+
+	// QuoteValidity is how long an unlocked quote can still be locked.
+	QuoteValidity = 15 * time.Minute
+	// MaxQuoteLock bounds how long a price can be guaranteed.
+	MaxQuoteLock = 30 * time.Minute
+)
+
+// Quote is what a booking would cost and whether it could be made now.
+// Nothing is held: the seats may be gone by the time the booking is made.
+// Locking a quote guarantees its price for its seats until ExpiresAt; a
+// booking on it gets those seats, or is refused if they have been taken.
+// Only available quotes are kept to be locked; unavailable ones have no ID.
+type Quote struct {
+	ID        string
+	ServiceID string
+	Price     domain.PriceBreakdown
+	Available bool
+	// Seats are the seats a booking would get right now, in request order.
+	// Unset when unavailable.
+	Seats []domain.Seat
+	// Placement tells how a party would be seated. Unset when unavailable
+	// or for a single passenger.
+	Placement *domain.Placement
+	// Unavailable lists why seats cannot be booked.
+	Unavailable ValidationErrors
+	// Converted is the total in the request's currency, if it asked for a
+	// different one than the tariff's, with the rate used.
+	Converted *currency.Conversion
+	QuotedAt  time.Time
+	Locked    bool
+	ExpiresAt time.Time
+}
+
+type issuedQuote struct {
+	quote   Quote
+	request domain.ReservationRequest
+	// seats are the seats the quote was priced for, copied so that callers
+	// changing the quote they were handed cannot change them.
+	seats []domain.Seat
+}
+
+// availabilityCodes are validation failures that make a request
+// unbookable now without making it unpriceable.
+var availabilityCodes = map[string]bool{
+	"SEAT_ALREADY_BOOKED": true,
+	"NO_SEAT_AVAILABLE":   true,
+}
+
+func (rs *System) SetTariff(tariff pricing.Tariff) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.tariff = tariff
+}
+
+// SetConverter enables quotes, refunds and revenue reports in currencies
+// other than the tariff's.
+func (rs *System) SetConverter(converter *currency.Converter) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.converter = converter
+}
+
+// Quote prices a request without booking it, so the seat map can show
+// prices before checkout. Passenger names are not needed. Requests that
+// could never be booked return an error: an unknown service, stations the
+// service does not run between, passengers and seat requests that do not
+// pair up, unknown seats or seats off the journey, unknown seat preferences,
+// assistance the stations cannot give, or a currency that cannot be
+// converted to. Requests that only lack free seats (SEAT_ALREADY_BOOKED,
+// NO_SEAT_AVAILABLE) return a quote marked unavailable.
+func (rs *System) Quote(req domain.ReservationRequest) (Quote, error) {
+	rs.mu.Lock()
+	quote, err := rs.quote(req)
+	rs.mu.Unlock()
+	if err != nil {
+		return Quote{}, err
+	}
+
+	// Converted without holding the lock, as the rates may be fetched
+	if quote.Converted, err = rs.convert(quote.Price.Total, req.Currency); err != nil {
+		return Quote{}, err
+	}
+
+	if !quote.Available {
+		return quote, nil
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.quotes[quote.ID] = issuedQuote{quote: quote, request: req, seats: append([]domain.Seat(nil), quote.Seats...)}
+	return quote, nil
+}
+
+func (rs *System) quote(req domain.ReservationRequest) (Quote, error) {
+	seats, placement, err := rs.validateRequest(req)
+	quote := Quote{
+		ServiceID: req.ServiceID,
+		Available: err == nil,
+		QuotedAt:  rs.now(),
+		ExpiresAt: rs.now().Add(QuoteValidity),
+	}
+	if err != nil {
+		var errs ValidationErrors
+		if !errors.As(err, &errs) {
+			return Quote{}, err
+		}
+		for _, e := range errs {
+			if !availabilityCodes[e.Code] {
+				return Quote{}, err
+			}
+		}
+		quote.Unavailable = errs
+	} else {
+		quote.ID = fmt.Sprintf("Q%06d", rs.nextQuoteID)
+		rs.nextQuoteID++
+		quote.Seats = seats
+		quote.Placement = placement
+	}
+
+	quote.Price = rs.price(rs.services[req.ServiceID], req, seats)
+	return quote, nil
+}
+
+// convert expresses an amount sold in the tariff's currency in another one
+// for a quote, refund or report, recording the rate used. It returns nil if
+// no other currency was asked for. It must be called without holding rs.mu.
+func (rs *System) convert(amount domain.Money, to string) (*currency.Conversion, error) {
+	if to == "" || strings.EqualFold(to, amount.Currency) {
+		return nil, nil
+	}
+
+	rs.mu.RLock()
+	converter := rs.converter
+	rs.mu.RUnlock()
+	if converter == nil {
+		return nil, ReservationError{
+			Message: "Amounts in other currencies are not enabled",
+			Code:    "CURRENCY_NOT_SUPPORTED",
+			Field:   "Currency",
+		}
+	}
+	conversion, err := converter.Convert(amount, to)
+	if err != nil {
+		return nil, err
+	}
+	return &conversion, nil
+}
+
+// LockQuote guarantees a quote's price for the given duration, even if the
+// tariff changes meanwhile. A booking consumes the lock by passing the
+// quote ID with the same request that was quoted, and gets the quoted seats.
+func (rs *System) LockQuote(quoteID string, duration time.Duration) (Quote, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if duration <= 0 || duration > MaxQuoteLock {
+		return Quote{}, ReservationError{
+			Message: fmt.Sprintf("Quotes can be locked for up to %s", MaxQuoteLock),
+			Code:    "INVALID_LOCK_DURATION",
+		}
+	}
+
+	issued, err := rs.issuedQuote(quoteID)
+	if err != nil {
+		return Quote{}, err
+	}
+	issued.quote.Locked = true
+	issued.quote.ExpiresAt = rs.now().Add(duration)
+	rs.quotes[quoteID] = issued
+	return issued.quote, nil
+}
+
+// ExpireQuotes drops quotes past their expiry and announces expired locks,
+// so a checkout can tell its user the price is no longer held. It is run
+// by the scheduler; expired quotes are refused even before it runs.
+func (rs *System) ExpireQuotes() int {
+	rs.mu.Lock()
+	now := rs.now()
+	var expired []Quote
+	for id, issued := range rs.quotes {
+		if !now.Before(issued.quote.ExpiresAt) {
+			delete(rs.quotes, id)
+			if issued.quote.Locked {
+				expired = append(expired, issued.quote)
+			}
+		}
+	}
+	rs.mu.Unlock()
+
+	for _, quote := range expired {
+		rs.events.Publish(events.Event{Type: EventQuoteExpired, Time: now, Data: quote})
+	}
+	return len(expired)
+}
+
+func (rs *System) issuedQuote(quoteID string) (issuedQuote, error) {
+	issued, exists := rs.quotes[quoteID]
+	if !exists {
+		return issuedQuote{}, ReservationError{
+			Message: fmt.Sprintf("Quote %s not found", quoteID),
+			Code:    "QUOTE_NOT_FOUND",
+			Field:   "QuoteID",
+		}
+	}
+	if !rs.now().Before(issued.quote.ExpiresAt) {
+		return issuedQuote{}, ReservationError{
+			Message: fmt.Sprintf("Quote %s expired at %s", quoteID, issued.quote.ExpiresAt.Format(time.RFC3339)),
+			Code:    "QUOTE_EXPIRED",
+			Field:   "QuoteID",
+		}
+	}
+	return issued, nil
+}
+
+// lockedQuote returns the locked quote a booking request references.
+func (rs *System) lockedQuote(req domain.ReservationRequest) (issuedQuote, error) {
+	issued, err := rs.issuedQuote(req.QuoteID)
+	if err != nil {
+		return issuedQuote{}, err
+	}
+	if !issued.quote.Locked {
+		return issuedQuote{}, ReservationError{
+			Message: fmt.Sprintf("Quote %s is not locked", req.QuoteID),
+			Code:    "QUOTE_NOT_LOCKED",
+			Field:   "QuoteID",
+		}
+	}
+	if !rs.sameJourney(issued.request, req) {
+		return issuedQuote{}, ReservationError{
+			Message: fmt.Sprintf("Quote %s was made for a different journey, passengers or seats", req.QuoteID),
+			Code:    "QUOTE_MISMATCH",
+			Field:   "QuoteID",
+		}
+	}
+	return issued, nil
+}
+
+// pin asks for the quoted seats, rather than whichever seats allocation
+// would pick now, so the booking gets the seats its price was worked out
+// for.
+func (issued issuedQuote) pin(req domain.ReservationRequest) domain.ReservationRequest {
+	pinned := req
+	pinned.SeatRequests = make([]domain.SeatRequest, len(req.SeatRequests))
+	for i, seatReq := range req.SeatRequests {
+		seatReq.CarriageID = issued.seats[i].CarriageID
+		seatReq.SeatNumber = issued.seats[i].Number
+		pinned.SeatRequests[i] = seatReq
+	}
+	return pinned
+}
+
+// seatsTaken reports a booking on a locked quote refused because another
+// booking took one of the quoted seats since. The lock cannot be honoured,
+// so the caller needs a new quote.
+func (issued issuedQuote) seatsTaken(err error) error {
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || !errs.HasCode("SEAT_ALREADY_BOOKED") {
+		return err
+	}
+	return ReservationError{
+		Message: fmt.Sprintf("Seats quoted in %s have been booked since; request a new quote", issued.quote.ID),
+		Code:    "QUOTE_SEATS_TAKEN",
+		Field:   "QuoteID",
+	}
+}
+
+// sameJourney reports whether a booking request asks for what was quoted.
+// Passenger names may differ; their fare categories and seats may not.
+func (rs *System) sameJourney(quoted, req domain.ReservationRequest) bool {
+	if quoted.ServiceID != req.ServiceID || quoted.Origin != req.Origin || quoted.Destination != req.Destination ||
+		!rs.isSameDate(quoted.Date, req.Date) ||
+		len(quoted.Passengers) != len(req.Passengers) || len(quoted.SeatRequests) != len(req.SeatRequests) {
+		return false
+	}
+	for i := range quoted.Passengers {
+		if quoted.Passengers[i].Category != req.Passengers[i].Category {
+			return false
+		}
+	}
+	for i := range quoted.SeatRequests {
+		if quoted.SeatRequests[i] != req.SeatRequests[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// price charges each passenger by the class and route of their seat. A
+// seat that could not be resolved is priced by the requested class.
+func (rs *System) price(service domain.Service, req domain.ReservationRequest, seats []domain.Seat) domain.PriceBreakdown {
+	legs := make([]pricing.Leg, len(req.Passengers))
+	for i, passenger := range req.Passengers {
+		var seat domain.Seat
+		if i < len(seats) {
+			seat = seats[i]
+		}
+		zone := seat.ComfortZone
+		if zone == "" && i < len(req.SeatRequests) {
+			zone = req.SeatRequests[i].ComfortZone
+		}
+		if zone == "" {
+			zone = domain.SecondClass
+		}
+		distance, _ := service.RouteForCarriage(seat.CarriageID).Distance(req.Origin, req.Destination)
+
+		carriage, _ := service.GetCarriage(seat.CarriageID)
+
+		legs[i] = pricing.Leg{Passenger: passenger, ComfortZone: zone, Distance: distance, Carriage: carriage}
+	}
+	return rs.tariff.Price(legs)
+}
@@ -0,0 +1,360 @@
+package reservation
+
+import (
+	"errors"
+	"testing"
+	"ticketing-app/pkg/currency"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"ticketing-app/pkg/pricing"
+	"ticketing-app/pkg/scheduler"
+	"time"
+)
+
+func quoteRequest(seat string) domain.ReservationRequest {
+	return domain.ReservationRequest{
+		ServiceID:   "5160",
+		Origin:      "Paris",
+		Destination: "Amsterdam",
+		Passengers: []domain.Passenger{
+			{Name: "John Doe"},
+			{Name: "Jane Doe", Category: domain.Child},
+		},
+		SeatRequests: []domain.SeatRequest{
+			{CarriageID: "A", SeatNumber: seat},
+			{ComfortZone: domain.FirstClass},
+		},
+		Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSystem_Quote(t *testing.T) {
+	rs := setupTestSystem()
+	
+	quote, err := rs.Quote(quoteRequest("A1"))
+	if err != nil {
+		t.Fatalf("Failed to quote: %v", err)
+	}
+	if !quote.Available || len(quote.Seats) != 2 || quote.Seats[1].Number != "A2" {
+		t.Errorf("Expected an available quote with a seat allocated for the child, got %+v", quote)
+	}
+	if quote.Price.Total.Amount != 22073 {
+		t.Errorf("Expected EUR 220.73, got %s", quote.Price.Total)
+	}
+	if discount := quote.Price.Sum(domain.DiscountComponent); discount.Amount != -6500 {
+		t.Errorf("Expected the child discount in the breakdown, got %s", discount)
+	}
+	if len(rs.allBookings()) != 0 {
+		t.Error("Expected quoting not to create a booking")
+	}
+	
+	booking, err := rs.MakeReservation(quoteRequest("A1"))
+	if err != nil {
+		t.Fatalf("Failed to book: %v", err)
+	}
+	if booking.Price.Total != quote.Price.Total || booking.Tickets[0].Price.Amount != 14497 || booking.Tickets[1].Price.Amount != 7412 {
+		t.Errorf("Expected the booking to be priced as quoted, got %s with tickets %s and %s",
+			booking.Price.Total, booking.Tickets[0].Price, booking.Tickets[1].Price)
+	}
+	
+	quote, err = rs.Quote(quoteRequest("A1"))
+	if err != nil {
+		t.Fatalf("Expected a booked seat to still be quoted, got %v", err)
+	}
+	if quote.Available || !quote.Unavailable.HasCode("SEAT_ALREADY_BOOKED") || quote.Price.Total.Amount != 22073 {
+		t.Errorf("Expected an unavailable quote with the same price, got %+v", quote)
+	}
+	if quote.ID != "" || len(rs.quotes) != 1 {
+		t.Errorf("Expected an unavailable quote not to be kept for locking, got ID %q and %d quotes", quote.ID, len(rs.quotes))
+	}
+}
+
+func TestSystem_QuoteErrors(t *testing.T) {
+	rs := setupTestSystem()
+	
+	req := quoteRequest("A1")
+	req.ServiceID = "9999"
+	_, err := rs.Quote(req)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || !errs.HasCode("SERVICE_NOT_FOUND") {
+		t.Errorf("Expected SERVICE_NOT_FOUND, got %v", err)
+	}
+	
+	req = quoteRequest("A1")
+	req.Origin = "Amsterdam"
+	if _, err := rs.Quote(req); err == nil {
+		t.Error("Expected an unbookable request not to be quoted")
+	}
+	
+	req = quoteRequest("A1")
+	req.Currency = "GBP"
+	_, err = rs.Quote(req)
+	var reservationErr ReservationError
+	if !errors.As(err, &reservationErr) || reservationErr.Code != "CURRENCY_NOT_SUPPORTED" {
+		t.Errorf("Expected CURRENCY_NOT_SUPPORTED without a converter, got %v", err)
+	}
+}
+
+func TestSystem_QuoteInOtherCurrency(t *testing.T) {
+	rs := setupTestSystem()
+	asOf := time.Date(2021, 3, 31, 16, 0, 0, 0, time.UTC)
+	rs.SetConverter(currency.NewConverter(currency.NewStaticProvider("EUR", asOf, map[string]float64{"GBP": 0.85})))
+	
+	req := quoteRequest("A1")
+	req.Currency = "GBP"
+	quote, err := rs.Quote(req)
+	if err != nil {
+		t.Fatalf("Failed to quote: %v", err)
+	}
+	if quote.Converted == nil || quote.Converted.Converted.Amount != 18762 || !quote.Converted.Rate.AsOf.Equal(asOf) {
+		t.Errorf("Expected GBP 187.62 at the 31 March rate, got %+v", quote.Converted)
+	}
+	if quote.Price.Total.Currency != "EUR" {
+		t.Errorf("Expected the breakdown to stay in the tariff currency, got %s", quote.Price.Total.Currency)
+	}
+}
+
+func TestSystem_RefundAndRevenueInOtherCurrency(t *testing.T) {
+	rs := setupTestSystem()
+	asOf := time.Date(2021, 3, 31, 16, 0, 0, 0, time.UTC)
+	
+	req := quoteRequest("A1")
+	booking, err := rs.MakeReservation(req)
+	if err != nil {
+		t.Fatalf("Failed to book: %v", err)
+	}
+	
+	var reservationErr ReservationError
+	if _, err := rs.QuoteRefund(booking.ID, "GBP"); !errors.As(err, &reservationErr) || reservationErr.Code != "CURRENCY_NOT_SUPPORTED" {
+		t.Errorf("Expected CURRENCY_NOT_SUPPORTED without a converter, got %v", err)
+	}
+	
+	rs.SetConverter(currency.NewConverter(currency.NewStaticProvider("EUR", asOf, map[string]float64{"GBP": 0.85})))
+	
+	refund, err := rs.QuoteRefund(booking.ID, "GBP")
+	if err != nil {
+		t.Fatalf("Failed to quote refund: %v", err)
+	}
+	if refund.Amount != booking.Price.Total || refund.Converted == nil ||
+		refund.Converted.Converted.Currency != "GBP" || !refund.Converted.Rate.AsOf.Equal(asOf) {
+		t.Errorf("Expected a refund of %s converted at the 31 March rate, got %+v", booking.Price.Total, refund)
+	}
+	if refund, _ := rs.QuoteRefund(booking.ID, "EUR"); refund.Converted != nil {
+		t.Errorf("Expected no conversion for a refund in the tariff currency, got %+v", refund.Converted)
+	}
+	
+	report, err := rs.RevenueIn("5160", req.Date, "GBP")
+	if err != nil {
+		t.Fatalf("Failed to report revenue: %v", err)
+	}
+	if report.Converted == nil || report.Converted.Original != report.Total || report.Converted.Rate.Value != 0.85 {
+		t.Errorf("Expected the report total converted at 0.85, got %+v", report.Converted)
+	}
+	if _, err := rs.RevenueIn("9999", req.Date, "GBP"); !errors.As(err, &reservationErr) || reservationErr.Code != "SERVICE_NOT_FOUND" {
+		t.Errorf("Expected SERVICE_NOT_FOUND, got %v", err)
+	}
+}
+
+func TestSystem_LockedQuoteKeepsPrice(t *testing.T) {
+	rs := setupTestSystem()
+	now := time.Date(2021, 3, 30, 12, 0, 0, 0, time.UTC)
+	rs.SetClock(func() time.Time { return now })
+	
+	quote, err := rs.Quote(quoteRequest("A1"))
+	if err != nil {
+		t.Fatalf("Failed to quote: %v", err)
+	}
+	locked, err := rs.LockQuote(quote.ID, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to lock quote: %v", err)
+	}
+	if !locked.Locked || !locked.ExpiresAt.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("Expected a lock until %s, got %+v", now.Add(10*time.Minute), locked)
+	}
+	
+	tariff := pricing.DefaultTariff()
+	tariff.PerKm[domain.FirstClass] = 40
+	rs.SetTariff(tariff)
+	
+	now = now.Add(5 * time.Minute)
+	req := quoteRequest("A1")
+	req.QuoteID = quote.ID
+	booking, err := rs.MakeReservation(req)
+	if err != nil {
+		t.Fatalf("Failed to book with locked quote: %v", err)
+	}
+	if booking.Price.Total != quote.Price.Total || booking.Tickets[0].Price.Amount != 14497 {
+		t.Errorf("Expected the locked price %s, got %s", quote.Price.Total, booking.Price.Total)
+	}
+	
+	req.SeatRequests[0].SeatNumber = "A3"
+	_, err = rs.MakeReservation(req)
+	var reservationErr ReservationError
+	if !errors.As(err, &reservationErr) || reservationErr.Code != "QUOTE_NOT_FOUND" {
+		t.Errorf("Expected the quote to be consumed by the booking, got %v", err)
+	}
+}
+
+func TestSystem_LockedQuoteKeepsItsSeats(t *testing.T) {
+	rs := setupTestSystem()
+	now := time.Date(2021, 3, 30, 12, 0, 0, 0, time.UTC)
+	rs.SetClock(func() time.Time { return now })
+	
+	lockedQuote := func() Quote {
+		quote, err := rs.Quote(quoteRequest("A1"))
+		if err != nil {
+			t.Fatalf("Failed to quote: %v", err)
+		}
+		if _, err := rs.LockQuote(quote.ID, 10*time.Minute); err != nil {
+			t.Fatalf("Failed to lock quote: %v", err)
+		}
+		return quote
+	}
+	book := func(quote Quote) (*domain.Booking, error) {
+		req := quoteRequest("A1")
+		req.QuoteID = quote.ID
+		return rs.MakeReservation(req)
+	}
+	takeSeat := func(seat string) (*domain.Booking, error) {
+		req := quoteRequest(seat)
+		req.Passengers = req.Passengers[:1]
+		req.SeatRequests = req.SeatRequests[:1]
+		return rs.MakeReservation(req)
+	}
+	
+	// A seat freed after quoting does not replace the quoted one
+	earlier, err := takeSeat("A2")
+	if err != nil {
+		t.Fatalf("Failed to book A2: %v", err)
+	}
+	quote := lockedQuote()
+	if _, err := rs.CancelBooking(earlier.ID); err != nil {
+		t.Fatalf("Failed to cancel A2: %v", err)
+	}
+	booking, err := book(quote)
+	if err != nil {
+		t.Fatalf("Failed to book with locked quote: %v", err)
+	}
+	if booking.Tickets[1].Seat != quote.Seats[1] || booking.Price.Total != quote.Price.Total {
+		t.Errorf("Expected seat %s at %s, got %s at %s",
+			quote.Seats[1].Number, quote.Price.Total, booking.Tickets[1].Seat.Number, booking.Price.Total)
+	}
+	if _, err := rs.CancelBooking(booking.ID); err != nil {
+		t.Fatalf("Failed to cancel booking: %v", err)
+	}
+	
+	// A quoted seat taken before checkout cannot be swapped for another
+	// one at the locked price
+	quote = lockedQuote()
+	if _, err := takeSeat(quote.Seats[1].Number); err != nil {
+		t.Fatalf("Failed to take the quoted seat: %v", err)
+	}
+	_, err = book(quote)
+	var reservationErr ReservationError
+	if !errors.As(err, &reservationErr) || reservationErr.Code != "QUOTE_SEATS_TAKEN" {
+		t.Errorf("Expected QUOTE_SEATS_TAKEN, got %v", err)
+	}
+}
+
+func TestSystem_LockQuoteErrors(t *testing.T) {
+	rs := setupTestSystem()
+	now := time.Date(2021, 3, 30, 12, 0, 0, 0, time.UTC)
+	rs.SetClock(func() time.Time { return now })
+	
+	quote, _ := rs.Quote(quoteRequest("A1"))
+	
+	tests := []struct {
+		name    string
+		prepare func() domain.ReservationRequest
+		errCode string
+	}{
+		{
+			name: "Not locked",
+			prepare: func() domain.ReservationRequest {
+				req := quoteRequest("A1")
+				req.QuoteID = quote.ID
+				return req
+			},
+			errCode: "QUOTE_NOT_LOCKED",
+		},
+		{
+			name: "Different seat",
+			prepare: func() domain.ReservationRequest {
+				rs.LockQuote(quote.ID, 10*time.Minute)
+				req := quoteRequest("A5")
+				req.QuoteID = quote.ID
+				return req
+			},
+			errCode: "QUOTE_MISMATCH",
+		},
+		{
+			name: "Expired",
+			prepare: func() domain.ReservationRequest {
+				now = now.Add(11 * time.Minute)
+				req := quoteRequest("A1")
+				req.QuoteID = quote.ID
+				return req
+			},
+			errCode: "QUOTE_EXPIRED",
+		},
+		{
+			name: "Unknown",
+			prepare: func() domain.ReservationRequest {
+				req := quoteRequest("A1")
+				req.QuoteID = "Q999999"
+				return req
+			},
+			errCode: "QUOTE_NOT_FOUND",
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := rs.MakeReservation(tt.prepare())
+			var reservationErr ReservationError
+			if !errors.As(err, &reservationErr) || reservationErr.Code != tt.errCode {
+				t.Errorf("Expected %s, got %v", tt.errCode, err)
+			}
+		})
+	}
+	
+	if _, err := rs.LockQuote(quote.ID, time.Hour); err == nil {
+		t.Error("Expected a lock longer than the maximum to be refused")
+	}
+}
+
+func TestSystem_SchedulerExpiresQuoteLocks(t *testing.T) {
+	rs := setupTestSystem()
+	now := time.Date(2021, 3, 30, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	rs.SetClock(clock)
+	s := scheduler.New()
+	s.SetClock(clock)
+	rs.ScheduleJobs(s)
+	
+	var expired []Quote
+	rs.Events().Subscribe(func(e events.Event) {
+		if quote, ok := e.Data.(Quote); ok && e.Type == EventQuoteExpired {
+			expired = append(expired, quote)
+		}
+	})
+	
+	lockedQuote, _ := rs.Quote(quoteRequest("A1"))
+	rs.LockQuote(lockedQuote.ID, 2*time.Minute)
+	rs.Quote(quoteRequest("A2"))
+	
+	now = now.Add(2 * time.Minute)
+	s.RunPending()
+	if len(expired) != 1 || expired[0].ID != lockedQuote.ID {
+		t.Errorf("Expected the locked quote to expire, got %+v", expired)
+	}
+	
+	now = now.Add(QuoteValidity)
+	s.RunPending()
+	if len(rs.quotes) != 0 {
+		t.Errorf("Expected unlocked quotes to be dropped after %s, %d left", QuoteValidity, len(rs.quotes))
+	}
+	if len(expired) != 1 {
+		t.Errorf("Expected no event for quotes that were never locked, got %d", len(expired))
+	}
+}
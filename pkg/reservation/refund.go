@@ -0,0 +1,39 @@
+package reservation
+
+import (
+	"fmt"
+	"ticketing-app/pkg/currency"
+	"ticketing-app/pkg/domain"
+)
+
+// Refund is what cancelling a booking pays back. The tariff charges no
+// cancellation fee, so it is the booking's full price.
+type Refund struct {
+	BookingID string
+	Amount    domain.Money
+	// Converted is the amount in the currency the customer is refunded in,
+	// if that is not the tariff's, with the rate used.
+	Converted *currency.Conversion
+}
+
+// QuoteRefund tells what cancelling a booking would refund, optionally in
+// another currency than the fare was sold in. It does not cancel anything.
+func (rs *System) QuoteRefund(bookingID, currency string) (Refund, error) {
+	rs.mu.RLock()
+	booking, exists := rs.bookings[bookingID]
+	rs.mu.RUnlock()
+	if !exists {
+		return Refund{}, ReservationError{
+			Message: fmt.Sprintf("Booking %s not found", bookingID),
+			Code:    "BOOKING_NOT_FOUND",
+		}
+	}
+
+	refund := Refund{BookingID: bookingID, Amount: booking.Price.Total}
+	conversion, err := rs.convert(refund.Amount, currency)
+	if err != nil {
+		return Refund{}, err
+	}
+	refund.Converted = conversion
+	return refund, nil
+}
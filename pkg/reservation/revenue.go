@@ -0,0 +1,132 @@
+package reservation
+
+import (
+	"fmt"
+	"ticketing-app/pkg/currency"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+// CarriageRevenue is what the tickets in one carriage brought in, by price
+// component, so premiums and discounts between carriages of the same
+// comfort zone show up on their own.
+type CarriageRevenue struct {
+	CarriageID  string
+	ComfortZone domain.ComfortZone // empty when the carriage mixes zones
+	Tickets     int
+	Components  map[domain.PriceComponent]domain.Money
+	Total       domain.Money
+}
+
+// RevenueReport breaks down a departure's revenue per carriage, in train
+// order. Booking-wide items such as booking fees belong to no carriage and
+// are reported apart.
+type RevenueReport struct {
+	ServiceID   string
+	Date        time.Time
+	Carriages   []CarriageRevenue
+	BookingWide map[domain.PriceComponent]domain.Money
+	Total       domain.Money
+	// Converted is the total in the currency the report was asked in, if
+	// that is not the tariff's, with the rate used.
+	Converted *currency.Conversion
+}
+
+// Revenue reports a departure's revenue from its bookings' price lines.
+func (rs *System) Revenue(serviceID string, date time.Time) (RevenueReport, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	service, exists := rs.services[serviceID]
+	if !exists {
+		return RevenueReport{}, false
+	}
+
+	currency := rs.tariff.Currency
+	report := RevenueReport{
+		ServiceID:   serviceID,
+		Date:        date,
+		BookingWide: make(map[domain.PriceComponent]domain.Money),
+		Total:       domain.Money{Currency: currency},
+	}
+	index := make(map[string]int, len(service.Carriages))
+	for i, carriage := range service.Carriages {
+		index[carriage.ID] = i
+		report.Carriages = append(report.Carriages, CarriageRevenue{
+			CarriageID:  carriage.ID,
+			ComfortZone: carriageZone(carriage),
+			Components:  make(map[domain.PriceComponent]domain.Money),
+			Total:       domain.Money{Currency: currency},
+		})
+	}
+
+	add := func(components map[domain.PriceComponent]domain.Money, total *domain.Money, line domain.PriceLine) {
+		sum := components[line.Component]
+		sum.Currency = line.Amount.Currency
+		sum.Amount += line.Amount.Amount
+		components[line.Component] = sum
+		total.Amount += line.Amount.Amount
+		report.Total.Amount += line.Amount.Amount
+	}
+
+	for _, booking := range rs.bookings {
+		onDeparture := false
+		for passenger, ticket := range booking.Tickets {
+			if ticket.Service.ID != serviceID || !rs.isSameDate(ticket.Service.DateTime, date) {
+				continue
+			}
+			i, known := index[ticket.Seat.CarriageID]
+			if !known {
+				continue
+			}
+			onDeparture = true
+			carriage := &report.Carriages[i]
+			carriage.Tickets++
+			for _, line := range booking.Price.Lines {
+				if line.Passenger == passenger {
+					add(carriage.Components, &carriage.Total, line)
+				}
+			}
+		}
+		if !onDeparture {
+			continue
+		}
+		for _, line := range booking.Price.Lines {
+			if line.Passenger < 0 {
+				var total domain.Money
+				add(report.BookingWide, &total, line)
+			}
+		}
+	}
+	return report, true
+}
+
+// RevenueIn is Revenue with the total also expressed in another currency,
+// for operators that report in a different currency than they sell in.
+func (rs *System) RevenueIn(serviceID string, date time.Time, currency string) (RevenueReport, error) {
+	report, found := rs.Revenue(serviceID, date)
+	if !found {
+		return RevenueReport{}, ReservationError{
+			Message: fmt.Sprintf("Service %s not found", serviceID),
+			Code:    "SERVICE_NOT_FOUND",
+		}
+	}
+
+	conversion, err := rs.convert(report.Total, currency)
+	if err != nil {
+		return RevenueReport{}, err
+	}
+	report.Converted = conversion
+	return report, nil
+}
+
+func carriageZone(carriage domain.Carriage) domain.ComfortZone {
+	var zone domain.ComfortZone
+	for _, seat := range carriage.Seats {
+		if zone != "" && seat.ComfortZone != zone {
+			return ""
+		}
+		zone = seat.ComfortZone
+	}
+	return zone
+}
@@ -0,0 +1,107 @@
+package reservation
+
+import (
+	"testing"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+// setupPricedCarriagesSystem runs two second class carriages over 500 km:
+// a panoramic carriage A at a 20% premium and carriage B, next to the bar,
+// at 10% off.
+func setupPricedCarriagesSystem() *System {
+	rs := NewSystem()
+	route := domain.NewRoute("R001", "Paris-Amsterdam",
+		[]domain.Station{domain.NewStation("Paris"), domain.NewStation("Amsterdam")},
+		[]int{0, 500})
+	carriages := []domain.Carriage{
+		{
+			ID:              "A",
+			Seats:           []domain.Seat{{Number: "A1", ComfortZone: domain.SecondClass, CarriageID: "A"}},
+			PriceAdjustment: 2000,
+			PriceNote:       "Panoramic carriage",
+		},
+		{
+			ID:              "B",
+			Seats:           []domain.Seat{{Number: "B1", ComfortZone: domain.SecondClass, CarriageID: "B"}},
+			PriceAdjustment: -1000,
+		},
+	}
+
+	rs.AddRoute(route)
+	rs.AddService(domain.NewService("5160", route,
+		time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC), carriages))
+	return rs
+}
+
+func TestSystem_CarriagePriceAdjustments(t *testing.T) {
+	rs := setupPricedCarriagesSystem()
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	request := func(carriageID string) domain.ReservationRequest {
+		return domain.ReservationRequest{
+			ServiceID:    "5160",
+			Origin:       "Paris",
+			Destination:  "Amsterdam",
+			Passengers:   []domain.Passenger{{Name: "John Doe"}},
+			SeatRequests: []domain.SeatRequest{{CarriageID: carriageID}},
+			Date:         date,
+		}
+	}
+
+	quote, err := rs.Quote(request("A"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if premium := quote.Price.Sum(domain.CarriageComponent).Amount; premium != 1500 {
+		t.Errorf("Expected a quoted premium of 1500, got %d: %+v", premium, quote.Price.Lines)
+	}
+
+	tests := []struct {
+		carriageID  string
+		description string
+		adjustment  int64
+		ticket      int64
+	}{
+		{"A", "Panoramic carriage", 1500, 7500 + 1500 + 300 + 837},
+		{"B", "Carriage B discount", -750, 7500 - 750 + 300 + 635},
+	}
+
+	for _, tt := range tests {
+		booking, err := rs.MakeReservation(request(tt.carriageID))
+		if err != nil {
+			t.Fatalf("Unexpected error booking carriage %s: %v", tt.carriageID, err)
+		}
+		var line domain.PriceLine
+		for _, l := range booking.Price.Lines {
+			if l.Component == domain.CarriageComponent {
+				line = l
+			}
+		}
+		if line.Description != tt.description || line.Amount.Amount != tt.adjustment {
+			t.Errorf("Expected %q of %d in carriage %s, got %+v", tt.description, tt.adjustment, tt.carriageID, line)
+		}
+		if price := booking.Tickets[0].Price.Amount; price != tt.ticket {
+			t.Errorf("Expected a ticket price of %d in carriage %s, got %d", tt.ticket, tt.carriageID, price)
+		}
+	}
+
+	report, found := rs.Revenue("5160", date)
+	if !found || len(report.Carriages) != 2 {
+		t.Fatalf("Expected revenue for 2 carriages, got %+v", report)
+	}
+	for i, tt := range tests {
+		carriage := report.Carriages[i]
+		if carriage.CarriageID != tt.carriageID || carriage.Tickets != 1 || carriage.ComfortZone != domain.SecondClass {
+			t.Errorf("Expected 1 second class ticket in carriage %s, got %+v", tt.carriageID, carriage)
+		}
+		if carriage.Components[domain.CarriageComponent].Amount != tt.adjustment || carriage.Total.Amount != tt.ticket {
+			t.Errorf("Expected adjustment %d and total %d in carriage %s, got %+v", tt.adjustment, tt.ticket, tt.carriageID, carriage)
+		}
+	}
+	if fees := report.BookingWide[domain.FeeComponent].Amount; fees != 300 {
+		t.Errorf("Expected 300 in booking fees, got %d", fees)
+	}
+	if report.Total.Amount != 10137+7685+300+28 {
+		t.Errorf("Expected a total of %d, got %d", 10137+7685+300+28, report.Total.Amount)
+	}
+}
@@ -0,0 +1,122 @@
+package reservation
+
+import (
+	"fmt"
+	"ticketing-app/pkg/audit"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/redact"
+)
+
+// fieldFilter decides what of a query result a role may see. Every Queries
+// method passes its result through the caller's filter, so endpoints built
+// on Queries cannot return more than the role allows.
+type fieldFilter struct {
+	passenger func(domain.Passenger) domain.Passenger
+	entry     func(domain.ManifestEntry) domain.ManifestEntry
+}
+
+var roleFilters = map[audit.Role]fieldFilter{
+	// Agents handle a passenger's own requests and see everything
+	audit.Agent: {
+		passenger: func(p domain.Passenger) domain.Passenger { return p },
+		entry:     func(e domain.ManifestEntry) domain.ManifestEntry { return e },
+	},
+	// Conductors see names and seats; contact details and documents stay
+	// masked
+	audit.Conductor: {
+		passenger: redact.Reveal(redact.Name).Passenger,
+		entry: func(e domain.ManifestEntry) domain.ManifestEntry {
+			e.Passenger = redact.Reveal(redact.Name).Passenger(e.Passenger)
+			return e
+		},
+	},
+	// Station staff see how many passengers there are and who needs
+	// assistance, and which carriage to meet them at
+	audit.StationStaff: {
+		passenger: assistanceOnly,
+		entry: func(e domain.ManifestEntry) domain.ManifestEntry {
+			e.BookingID = ""
+			e.Passenger = assistanceOnly(e.Passenger)
+			e.Seat = domain.Seat{CarriageID: e.Seat.CarriageID, ComfortZone: e.Seat.ComfortZone}
+			return e
+		},
+	},
+	// Analytics sees journeys and fare categories, with nothing that
+	// identifies a passenger or links records to a booking
+	audit.Analytics: {
+		passenger: anonymous,
+		entry: func(e domain.ManifestEntry) domain.ManifestEntry {
+			e.BookingID = ""
+			e.Passenger = anonymous(e.Passenger)
+			return e
+		},
+	},
+}
+
+func assistanceOnly(p domain.Passenger) domain.Passenger {
+	return domain.Passenger{Assistance: p.Assistance}
+}
+
+func anonymous(p domain.Passenger) domain.Passenger {
+	return domain.Passenger{Category: p.Category}
+}
+
+func filterFor(role audit.Role) (fieldFilter, error) {
+	filter, exists := roleFilters[role]
+	if !exists {
+		return fieldFilter{}, ReservationError{
+			Message: fmt.Sprintf("Role %q may not query passenger data", role),
+			Code:    "ACCESS_DENIED",
+		}
+	}
+	return filter, nil
+}
+
+func (f fieldFilter) passengers(passengers []domain.Passenger) []domain.Passenger {
+	filtered := make([]domain.Passenger, len(passengers))
+	for i, p := range passengers {
+		filtered[i] = f.passenger(p)
+	}
+	return filtered
+}
+
+func (f fieldFilter) entries(entries []domain.ManifestEntry) []domain.ManifestEntry {
+	filtered := make([]domain.ManifestEntry, len(entries))
+	for i, e := range entries {
+		filtered[i] = f.entry(e)
+	}
+	return filtered
+}
+
+// task filters an assistance task as the manifest entry for the same
+// passenger and seat.
+func (f fieldFilter) task(task domain.AssistanceTask) domain.AssistanceTask {
+	entry := f.entry(domain.ManifestEntry{
+		BookingID: task.BookingID,
+		Passenger: task.Passenger,
+		Seat:      domain.Seat{CarriageID: task.CarriageID, Number: task.SeatNumber},
+	})
+	task.BookingID = entry.BookingID
+	task.Passenger = entry.Passenger
+	task.CarriageID = entry.Seat.CarriageID
+	task.SeatNumber = entry.Seat.Number
+	return task
+}
+
+// booking filters the passengers on a booking. Roles that do not see
+// booking IDs in manifests do not see them here either, nor the checksum,
+// which would link the record to its booking just the same.
+func (f fieldFilter) booking(booking domain.Booking) domain.Booking {
+	if f.entry(domain.ManifestEntry{BookingID: booking.ID}).BookingID == "" {
+		booking.ID = ""
+		booking.Checksum = ""
+	}
+	booking.Passengers = f.passengers(booking.Passengers)
+	tickets := make([]domain.Ticket, len(booking.Tickets))
+	for i, ticket := range booking.Tickets {
+		ticket.Passenger = f.passenger(ticket.Passenger)
+		tickets[i] = ticket
+	}
+	booking.Tickets = tickets
+	return booking
+}
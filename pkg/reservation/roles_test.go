@@ -0,0 +1,150 @@
+package reservation
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"ticketing-app/pkg/audit"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"time"
+)
+
+func TestQueries_FilterFieldsByRole(t *testing.T) {
+	rs := setupAssistanceSystem()
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	_, err := rs.MakeReservation(domain.ReservationRequest{
+		ServiceID:   "5160",
+		Origin:      "Paris",
+		Destination: "Amsterdam",
+		Passengers: []domain.Passenger{{
+			Name:       "John Doe",
+			Email:      "john.doe@example.com",
+			Assistance: domain.WheelchairAssistance,
+			Category:   domain.Senior,
+		}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A2"}},
+		Date:         date,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+
+	tests := []struct {
+		role      audit.Role
+		name      string
+		email     string
+		bookingID string
+		seat      string
+		assist    domain.AssistanceType
+		category  domain.PassengerCategory
+	}{
+		{audit.Agent, "John Doe", "john.doe@example.com", "B0001", "A2", domain.WheelchairAssistance, domain.Senior},
+		{audit.Conductor, "John Doe", "j***@example.com", "B0001", "A2", domain.WheelchairAssistance, domain.Senior},
+		{audit.StationStaff, "", "", "", "", domain.WheelchairAssistance, domain.Adult},
+		{audit.Analytics, "", "", "", "A2", domain.NoAssistance, domain.Senior},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			queries := rs.QueryAs(audit.Caller{UserID: "u-1", Role: tt.role})
+
+			manifest, _, err := queries.GetManifest("5160", date)
+			if err != nil || len(manifest.Entries) != 1 {
+				t.Fatalf("Expected 1 manifest entry, got %d (err %v)", len(manifest.Entries), err)
+			}
+			entry := manifest.Entries[0]
+			p := entry.Passenger
+			if p.Name != tt.name || p.Email != tt.email || p.Assistance != tt.assist || p.Category != tt.category {
+				t.Errorf("Expected passenger %q %q %q %q, got %+v", tt.name, tt.email, tt.assist, tt.category, p)
+			}
+			if entry.BookingID != tt.bookingID || entry.Seat.Number != tt.seat || entry.Seat.CarriageID != "A" {
+				t.Errorf("Expected booking %q seat A/%q, got %q %s/%s", tt.bookingID, tt.seat, entry.BookingID, entry.Seat.CarriageID, entry.Seat.Number)
+			}
+
+			boarding, err := queries.GetPassengersBoardingAt("5160", "Paris", date)
+			if err != nil || len(boarding) != 1 || boarding[0].Name != tt.name {
+				t.Errorf("Expected 1 boarding passenger named %q, got %+v (err %v)", tt.name, boarding, err)
+			}
+
+			onSeat, found, err := queries.GetPassengerOnSeat("5160", "A", "A2", date)
+			if err != nil || !found || onSeat.Name != tt.name {
+				t.Errorf("Expected %q on A2, got %+v (err %v)", tt.name, onSeat, err)
+			}
+			
+			bookings, err := queries.GetAllBookings()
+			if err != nil || len(bookings) != 1 {
+				t.Fatalf("Expected 1 booking, got %d (err %v)", len(bookings), err)
+			}
+			if b := bookings[0]; b.ID != tt.bookingID || b.Passengers[0].Name != tt.name || b.Tickets[0].Passenger.Email != tt.email {
+				t.Errorf("Expected booking %q for %q %q, got %q %+v", tt.bookingID, tt.name, tt.email, b.ID, b.Tickets[0].Passenger)
+			}
+			
+			tasks, err := queries.GetAssistanceTasks("Paris", date)
+			if err != nil || len(tasks) != 1 {
+				t.Fatalf("Expected 1 assistance task, got %d (err %v)", len(tasks), err)
+			}
+			if task := tasks[0]; task.Type != domain.WheelchairAssistance || task.Passenger.Name != tt.name ||
+				task.BookingID != tt.bookingID || task.CarriageID != "A" || task.SeatNumber != tt.seat {
+				t.Errorf("Expected a wheelchair task for %q on booking %q seat A/%q, got %+v", tt.name, tt.bookingID, tt.seat, task)
+			}
+		})
+	}
+	
+	if lookups := rs.AuditLog().ByUser("u-1", time.Time{}, time.Now().Add(time.Hour)); len(lookups) != 20 || lookups[3].Query != "bookings" || lookups[4].Query != "assistance" {
+		t.Errorf("Expected every lookup recorded, got %+v", lookups)
+	}
+
+	stored, _, _ := rs.GetBooking("B0001")
+	if stored.Passengers[0].Name != "John Doe" || stored.Tickets[0].Passenger.Email != "john.doe@example.com" {
+		t.Error("Expected filtering to leave the booking itself unchanged")
+	}
+}
+
+func TestQueries_UnknownRoleDenied(t *testing.T) {
+	rs, date := setupAuditedSystem(t)
+
+	passengers, err := rs.QueryAs(audit.Caller{UserID: "u-1", Role: "marketing"}).GetPassengersBoardingAt("5160", "Paris", date)
+	var reservationErr ReservationError
+	if !errors.As(err, &reservationErr) || reservationErr.Code != "ACCESS_DENIED" {
+		t.Errorf("Expected ACCESS_DENIED, got %v", err)
+	}
+	if passengers != nil {
+		t.Errorf("Expected no passengers, got %+v", passengers)
+	}
+}
+
+func TestSystem_LoggerAndForwardedEventsMaskPassengers(t *testing.T) {
+	rs := setupAssistanceSystem()
+	var logged bytes.Buffer
+	rs.SetLogHandler(slog.NewTextHandler(&logged, nil))
+	var forwarded []events.Event
+	rs.ForwardEvents(func(e events.Event) {
+		forwarded = append(forwarded, e)
+	})
+
+	booking, err := rs.MakeReservation(domain.ReservationRequest{
+		ServiceID:    "5160",
+		Origin:       "Paris",
+		Destination:  "Amsterdam",
+		Passengers:   []domain.Passenger{{Name: "John Doe", Email: "john.doe@example.com"}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A2"}},
+		Date:         time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+	
+	rs.Logger().Info("booked", "booking", booking)
+	if !strings.Contains(logged.String(), "booked") || strings.Contains(logged.String(), "John") || strings.Contains(logged.String(), "john.doe") {
+		t.Errorf("Expected the logged booking to be masked, got %s", logged.String())
+	}
+	
+	rs.Events().Publish(events.Event{Type: "booking.confirmed", Data: *booking})
+	last := forwarded[len(forwarded)-1]
+	if b, ok := last.Data.(domain.Booking); !ok || b.Passengers[0].Name == "John Doe" || b.Passengers[0].Email == "john.doe@example.com" {
+		t.Errorf("Expected the forwarded booking to be masked, got %+v", last.Data)
+	}
+}
@@ -2,86 +2,280 @@ package reservation
 
 import (
 	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"ticketing-app/pkg/audit"
+	"ticketing-app/pkg/currency"
 	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/events"
+	"ticketing-app/pkg/pricing"
+	"ticketing-app/pkg/scheduler"
+	"ticketing-app/pkg/store"
 	"time"
 )
 
 type ReservationError struct {
 	Message string
 	Code    string
+	Field   string
 }
 
 func (e ReservationError) Error() string {
 	return e.Message
 }
 
+// ValidationErrors collects every problem found in a request. It unwraps
+// to the individual ReservationErrors, so errors.As still finds them.
+//
+// MakeReservation and Quote return ValidationErrors even for a single
+// problem. Callers that asserted err.(ReservationError) must switch to
+// errors.As, which finds the first ReservationError in either form.
+type ValidationErrors []ReservationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+func (e ValidationErrors) HasCode(code string) bool {
+	for _, err := range e {
+		if err.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// System is safe for concurrent use. Events are published after its lock
+// is released, so subscribers may call back into it.
 type System struct {
+	mu            sync.RWMutex
 	bookings      map[string]domain.Booking
 	services      map[string]domain.Service
+	seatGraphs    map[string]map[string]domain.SeatGraph // by service, then carriage
 	routes        map[string]domain.Route
+	stations      map[string]domain.Station
+	couplings     []domain.Coupling
 	nextBookingID int
+	now           func() time.Time
+	events        *events.Bus
+	store         store.Store
+	tariff        pricing.Tariff
+	converter     *currency.Converter
+	quotes        map[string]issuedQuote
+	nextQuoteID   int
+	oversold      map[string]string // last alerted oversell report per departure
+	status        StoreStatus
+	degraded      atomic.Bool // mirrors status.Degraded, readable without the lock
+	loadIssues    []IntegrityIssue
+	integrity     string // last alerted reconciliation issues
+	auditLog      *audit.Log
+	logger        *slog.Logger
+	pending       []events.Event // published by unlock
 }
 
 func NewSystem() *System {
-	return &System{
+	rs := &System{
 		bookings:      make(map[string]domain.Booking),
 		services:      make(map[string]domain.Service),
+		seatGraphs:    make(map[string]map[string]domain.SeatGraph),
 		routes:        make(map[string]domain.Route),
+		stations:      make(map[string]domain.Station),
 		nextBookingID: 1,
+		now:           time.Now,
+		events:        events.NewBus(),
+		store:         store.NewMemory(),
+		tariff:        pricing.DefaultTariff(),
+		quotes:        make(map[string]issuedQuote),
+		nextQuoteID:   1,
+		logger:        newLogger(slog.Default().Handler()),
+	}
+	rs.auditLog = newAuditLog(rs)
+	return rs
+}
+
+// NewSystemWithStore loads routes, stations, services, couplings and
+// bookings from a persistent store and writes every later change through
+// to it.
+func NewSystemWithStore(st store.Store) (*System, error) {
+	rs := NewSystem()
+	rs.store = st
+
+	routes, err := st.Routes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routes: %w", err)
+	}
+	for _, route := range routes {
+		rs.routes[route.ID] = route
+	}
+
+	stations, err := st.Stations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stations: %w", err)
+	}
+	for _, station := range stations {
+		rs.stations[station.Name] = station
+	}
+
+	services, err := st.Services()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load services: %w", err)
+	}
+	for _, service := range services {
+		rs.setService(service)
+	}
+
+	if rs.couplings, err = st.Couplings(); err != nil {
+		return nil, fmt.Errorf("failed to load couplings: %w", err)
+	}
+
+	bookings, unreadable, err := storedBookings(st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bookings: %w", err)
 	}
+	rs.loadIssues = append(verifyLoaded(bookings), unreadable...)
+	for _, booking := range bookings {
+		rs.bookings[booking.ID] = booking
+		var n int
+		if _, err := fmt.Sscanf(booking.ID, "B%d", &n); err == nil && n >= rs.nextBookingID {
+			rs.nextBookingID = n + 1
+		}
+	}
+
+	return rs, nil
+}
+
+func (rs *System) Events() *events.Bus {
+	return rs.events
+}
+
+// SetClock replaces the clock used for time-dependent rules such as
+// assistance lead times.
+func (rs *System) SetClock(now func() time.Time) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.now = now
+}
+
+// ScheduleJobs registers the reservation system's housekeeping. Whatever
+// serves a System runs a scheduler with these jobs; the HTTP handlers built
+// on it (osdm, displays) do not start one themselves.
+func (rs *System) ScheduleJobs(s *scheduler.Scheduler) {
+	s.Every("expire-quotes", time.Minute, func(time.Time) { rs.ExpireQuotes() })
+	s.Every("audit-oversell", 5*time.Minute, func(time.Time) { rs.AlertOversell() })
+	s.Every("store-health", StoreHealthInterval, func(time.Time) { rs.CheckStore() })
+	s.Every("reconcile-bookings", time.Hour, func(time.Time) { rs.AlertIntegrity() })
 }
 
-func (rs *System) AddRoute(route domain.Route) {
+// clock reads the current time for callers not holding the lock.
+func (rs *System) clock() time.Time {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	return rs.now()
+}
+
+func (rs *System) AddRoute(route domain.Route) error {
+	rs.lockForWrite()
+	defer rs.unlock()
+
+	if err := rs.persist(func() error { return rs.store.SaveRoute(route) }); err != nil {
+		return err
+	}
 	rs.routes[route.ID] = route
+	return nil
 }
 
-func (rs *System) AddService(service domain.Service) {
+// AddStation registers station metadata. It takes precedence over the
+// copy of the station embedded in routes, so accessibility details can be
+// updated without rebuilding routes.
+func (rs *System) AddStation(station domain.Station) error {
+	rs.lockForWrite()
+	defer rs.unlock()
+
+	if err := rs.persist(func() error { return rs.store.SaveStation(station) }); err != nil {
+		return err
+	}
+	rs.stations[station.Name] = station
+	return nil
+}
+
+func (rs *System) AddService(service domain.Service) error {
+	rs.lockForWrite()
+	defer rs.unlock()
+
+	if err := rs.persist(func() error { return rs.store.SaveService(service) }); err != nil {
+		return err
+	}
+	rs.setService(service)
+	return nil
+}
+
+// setService keeps a service along with the seat graphs of its carriages,
+// built once here rather than for every party seated on it.
+func (rs *System) setService(service domain.Service) {
+	graphs := make(map[string]domain.SeatGraph, len(service.Carriages))
+	for _, carriage := range service.Carriages {
+		graphs[carriage.ID] = domain.NewSeatGraph(carriage)
+	}
 	rs.services[service.ID] = service
+	rs.seatGraphs[service.ID] = graphs
 }
 
 func (rs *System) MakeReservation(req domain.ReservationRequest) (*domain.Booking, error) {
-	service, exists := rs.services[req.ServiceID]
-	if !exists {
-		return nil, ReservationError{
-			Message: fmt.Sprintf("Service %s not found", req.ServiceID),
-			Code:    "SERVICE_NOT_FOUND",
-		}
+	rs.lockForWrite()
+	defer rs.unlock()
+
+	if err := rs.writable(); err != nil {
+		return nil, err
 	}
 
-	if !service.Route.IsValidOriginDestination(req.Origin, req.Destination) {
-		return nil, ReservationError{
-			Message: fmt.Sprintf("Invalid route from %s to %s for service %s", req.Origin, req.Destination, req.ServiceID),
-			Code:    "INVALID_ROUTE",
+	var locked *issuedQuote
+	if req.QuoteID != "" {
+		issued, err := rs.lockedQuote(req)
+		if err != nil {
+			return nil, err
 		}
+		locked = &issued
+		req = issued.pin(req)
 	}
 
-	if len(req.Passengers) != len(req.SeatRequests) {
-		return nil, ReservationError{
-			Message: "Number of passengers must match number of seat requests",
-			Code:    "PASSENGER_SEAT_MISMATCH",
+	seats, placement, err := rs.validateRequest(req)
+	if err != nil {
+		if locked != nil {
+			err = locked.seatsTaken(err)
 		}
+		return nil, err
 	}
 
-	originStation, _ := service.Route.GetStationByName(req.Origin)
-	destStation, _ := service.Route.GetStationByName(req.Destination)
+	service := rs.services[req.ServiceID]
+	price := rs.price(service, req, seats)
+	if locked != nil {
+		price = locked.quote.Price
+		placement = locked.quote.Placement
+	}
 	
 	tickets := make([]domain.Ticket, len(req.Passengers))
 	
-	for i, seatReq := range req.SeatRequests {
-		seat, exists := service.GetSeatByID(seatReq.CarriageID, seatReq.SeatNumber)
-		if !exists {
-			return nil, ReservationError{
-				Message: fmt.Sprintf("Seat %s in carriage %s not found in service %s", seatReq.SeatNumber, seatReq.CarriageID, req.ServiceID),
-				Code:    "SEAT_NOT_FOUND",
-			}
-		}
-
-		if rs.isSeatBooked(req.ServiceID, seatReq.CarriageID, seatReq.SeatNumber, req.Date) {
-			return nil, ReservationError{
-				Message: fmt.Sprintf("Seat %s in carriage %s is already booked for service %s", seatReq.SeatNumber, seatReq.CarriageID, req.ServiceID),
-				Code:    "SEAT_ALREADY_BOOKED",
-			}
-		}
+	for i, seat := range seats {
+		route := service.RouteForCarriage(seat.CarriageID)
+		originStation, _ := route.GetStationByName(req.Origin)
+		destStation, _ := route.GetStationByName(req.Destination)
 
 		tickets[i] = domain.Ticket{
 			Seat:        seat,
@@ -89,30 +283,221 @@ func (rs *System) MakeReservation(req domain.ReservationRequest) (*domain.Bookin
 			Destination: destStation,
 			Service:     service,
 			Passenger:   req.Passengers[i],
+			Price:       price.PassengerTotal(i),
 		}
 	}
 
 	bookingID := fmt.Sprintf("B%04d", rs.nextBookingID)
-	rs.nextBookingID++
 	
 	booking := domain.NewBooking(bookingID, req.Passengers, tickets)
+	booking.Price = price
+	booking.Placement = placement
+	booking = booking.Seal()
+	if err := rs.persist(func() error { return rs.store.SaveBooking(booking) }); err != nil {
+		return nil, err
+	}
+	rs.nextBookingID++
 	rs.bookings[bookingID] = booking
+	delete(rs.quotes, req.QuoteID)
+	rs.queueOccupancy(booking, req.Date)
 
 	return &booking, nil
 }
 
-func (rs *System) isSeatBooked(serviceID, carriageID, seatNumber string, date time.Time) bool {
+// validateRequest checks every field of the request and reports all
+// problems at once, so callers can fix their request in one round trip.
+// It returns the seat for each seat request, allocating seats for requests
+// that leave the seat number open; on failure seats that could not be
+// resolved are left zero.
+func (rs *System) validateRequest(req domain.ReservationRequest) ([]domain.Seat, *domain.Placement, error) {
+	var errs ValidationErrors
+
+	if len(req.Passengers) != len(req.SeatRequests) {
+		errs = append(errs, ReservationError{
+			Message: "Number of passengers must match number of seat requests",
+			Code:    "PASSENGER_SEAT_MISMATCH",
+			Field:   "SeatRequests",
+		})
+	}
+
+	service, exists := rs.services[req.ServiceID]
+	if !exists {
+		errs = append(errs, ReservationError{
+			Message: fmt.Sprintf("Service %s not found", req.ServiceID),
+			Code:    "SERVICE_NOT_FOUND",
+			Field:   "ServiceID",
+		})
+		return nil, nil, errs
+	}
+
+	validRoute := service.IsValidOriginDestination(req.Origin, req.Destination)
+	if !validRoute {
+		errs = append(errs, ReservationError{
+			Message: fmt.Sprintf("Invalid route from %s to %s for service %s", req.Origin, req.Destination, req.ServiceID),
+			Code:    "INVALID_ROUTE",
+			Field:   "Origin/Destination",
+		})
+	}
+
+	seats := make([]domain.Seat, len(req.SeatRequests))
+	booked := rs.bookedSeats(req.ServiceID, req.Date)
+	taken := make(map[string]bool)
+	for i, seatReq := range req.SeatRequests {
+		field := fmt.Sprintf("SeatRequests[%d]", i)
+		if !validPreferences[seatReq.Preference] {
+			errs = append(errs, ReservationError{
+				Message: fmt.Sprintf("Unknown seat preference %q", seatReq.Preference),
+				Code:    "INVALID_SEAT_PREFERENCE",
+				Field:   field + ".Preference",
+			})
+		}
+		if seatReq.SeatNumber == "" {
+			continue
+		}
+
+		seat, exists := service.GetSeatByID(seatReq.CarriageID, seatReq.SeatNumber)
+		if !exists {
+			errs = append(errs, ReservationError{
+				Message: fmt.Sprintf("Seat %s in carriage %s not found in service %s", seatReq.SeatNumber, seatReq.CarriageID, req.ServiceID),
+				Code:    "SEAT_NOT_FOUND",
+				Field:   field,
+			})
+			continue
+		}
+
+		taken[seatReq.CarriageID+"/"+seatReq.SeatNumber] = true
+		seats[i] = seat
+
+		if validRoute && !service.CarriageServes(seatReq.CarriageID, req.Origin, req.Destination) {
+			errs = append(errs, ReservationError{
+				Message: fmt.Sprintf("Carriage %s does not run between %s and %s on service %s", seatReq.CarriageID, req.Origin, req.Destination, req.ServiceID),
+				Code:    "SEAT_NOT_ON_SEGMENT",
+				Field:   field,
+			})
+		}
+
+		if booked[seatReq.CarriageID+"/"+seatReq.SeatNumber] {
+			errs = append(errs, ReservationError{
+				Message: fmt.Sprintf("Seat %s in carriage %s is already booked for service %s", seatReq.SeatNumber, seatReq.CarriageID, req.ServiceID),
+				Code:    "SEAT_ALREADY_BOOKED",
+				Field:   field,
+			})
+		}
+	}
+
+	// strategy is how the allocator seated the party, when it chose every
+	// seat; otherwise describePlacement works it out from the seats
+	var strategy domain.PlacementStrategy
+	if validRoute {
+		for key := range booked {
+			taken[key] = true
+		}
+		var group map[int]domain.Seat
+		group, strategy = rs.allocateGroup(service, req, taken)
+		if len(group) < len(req.SeatRequests) {
+			strategy = ""
+		}
+		for i, seatReq := range req.SeatRequests {
+			if seatReq.SeatNumber != "" {
+				continue
+			}
+			seat, found := group[i]
+			if !found {
+				seat, found = rs.allocateSeat(service, req, seatReq, taken)
+			}
+			if !found {
+				errs = append(errs, ReservationError{
+					Message: fmt.Sprintf("No free seat matching the request between %s and %s on service %s", req.Origin, req.Destination, req.ServiceID),
+					Code:    "NO_SEAT_AVAILABLE",
+					Field:   fmt.Sprintf("SeatRequests[%d]", i),
+				})
+				continue
+			}
+			taken[seat.CarriageID+"/"+seat.Number] = true
+			seats[i] = seat
+		}
+
+		errs = append(errs, rs.validateAssistance(req, service)...)
+	}
+
+	if len(errs) > 0 {
+		return seats, nil, errs
+	}
+	return seats, describePlacement(service, rs.seatGraphs[service.ID], seats, strategy), nil
+}
+
+// allocateSeat picks the first free seat, in train order, that satisfies
+// the request and whose carriage runs the whole journey, preferring one
+// that meets the request's seat preference. Carriages in a portion that
+// splits off elsewhere are never offered.
+func (rs *System) allocateSeat(service domain.Service, req domain.ReservationRequest, seatReq domain.SeatRequest, taken map[string]bool) (domain.Seat, bool) {
+	var fallback domain.Seat
+	found := false
+	for _, carriage := range rs.carriagesFor(service, req, seatReq) {
+		for _, seat := range carriage.Seats {
+			if !isFree(seat, seatReq, taken) {
+				continue
+			}
+			if carriage.Satisfies(seat, seatReq.Preference) {
+				return seat, true
+			}
+			if !found {
+				fallback, found = seat, true
+			}
+		}
+	}
+	return fallback, found
+}
+
+// carriagesFor lists the carriages, in train order, a seat request may be
+// allocated in.
+func (rs *System) carriagesFor(service domain.Service, req domain.ReservationRequest, seatReq domain.SeatRequest) []domain.Carriage {
+	var carriages []domain.Carriage
+	for _, carriage := range service.Carriages {
+		if seatReq.CarriageID != "" && carriage.ID != seatReq.CarriageID {
+			continue
+		}
+		if !service.CarriageServes(carriage.ID, req.Origin, req.Destination) {
+			continue
+		}
+		carriages = append(carriages, carriage)
+	}
+	return carriages
+}
+
+// freeSeats lists a carriage's seats in the requested comfort zone that are
+// not taken. taken holds carriage/number keys and must include the seats
+// booked on the departure.
+func (rs *System) freeSeats(carriage domain.Carriage, seatReq domain.SeatRequest, taken map[string]bool) []domain.Seat {
+	var seats []domain.Seat
+	for _, seat := range carriage.Seats {
+		if isFree(seat, seatReq, taken) {
+			seats = append(seats, seat)
+		}
+	}
+	return seats
+}
+
+func isFree(seat domain.Seat, seatReq domain.SeatRequest, taken map[string]bool) bool {
+	if seatReq.ComfortZone != "" && seat.ComfortZone != seatReq.ComfortZone {
+		return false
+	}
+	return !taken[seat.CarriageID+"/"+seat.Number]
+}
+
+// bookedSeats returns the carriage/number keys of the seats booked on a
+// departure, so checking a whole carriage takes one pass over the bookings
+// rather than one per seat.
+func (rs *System) bookedSeats(serviceID string, date time.Time) map[string]bool {
+	booked := make(map[string]bool)
 	for _, booking := range rs.bookings {
 		for _, ticket := range booking.Tickets {
-			if ticket.Service.ID == serviceID &&
-				ticket.Seat.CarriageID == carriageID &&
-				ticket.Seat.Number == seatNumber &&
-				rs.isSameDate(ticket.Service.DateTime, date) {
-				return true
+			if ticket.Service.ID == serviceID && rs.isSameDate(ticket.Service.DateTime, date) {
+				booked[ticket.Seat.CarriageID+"/"+ticket.Seat.Number] = true
 			}
 		}
 	}
-	return false
+	return booked
 }
 
 func (rs *System) isSameDate(date1, date2 time.Time) bool {
@@ -121,12 +506,100 @@ func (rs *System) isSameDate(date1, date2 time.Time) bool {
 	return y1 == y2 && m1 == m2 && d1 == d2
 }
 
-func (rs *System) GetBooking(bookingID string) (*domain.Booking, bool) {
+func (rs *System) GetService(serviceID string) (domain.Service, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	service, exists := rs.services[serviceID]
+	return service, exists
+}
+
+// GetBooking returns a booking once it has passed verifyRead. A booking
+// that fails is not returned; the error tells why, and the failure is
+// published as EventIntegrityViolated.
+func (rs *System) GetBooking(bookingID string) (*domain.Booking, bool, error) {
+	rs.mu.RLock()
 	booking, exists := rs.bookings[bookingID]
-	return &booking, exists
+	var issues []IntegrityIssue
+	if exists {
+		issues = rs.verifyRead(booking)
+	}
+	now := rs.now()
+	rs.mu.RUnlock()
+
+	if !exists {
+		return nil, false, nil
+	}
+	if len(issues) > 0 {
+		report := ReconciliationReport{CheckedAt: now, Checked: 1, Issues: issues}
+		rs.Logger().Error("reservation: booking failed its integrity check on read",
+			"booking", bookingID, "code", issues[0].Code, "detail", issues[0].Detail)
+		rs.events.Publish(events.Event{Type: EventIntegrityViolated, Time: now, Data: report})
+		return nil, false, ReservationError{
+			Message: fmt.Sprintf("Booking %s failed its integrity check: %s", bookingID, issues[0].Detail),
+			Code:    issues[0].Code,
+		}
+	}
+	return &booking, true, nil
 }
 
-func (rs *System) GetAllBookings() []domain.Booking {
+// CancelBooking removes a booking and releases its seats.
+func (rs *System) CancelBooking(bookingID string) (domain.Booking, error) {
+	rs.lockForWrite()
+	defer rs.unlock()
+
+	booking, exists := rs.bookings[bookingID]
+	if !exists {
+		return domain.Booking{}, ReservationError{
+			Message: fmt.Sprintf("Booking %s not found", bookingID),
+			Code:    "BOOKING_NOT_FOUND",
+		}
+	}
+	if err := rs.persist(func() error { return rs.store.DeleteBooking(bookingID) }); err != nil {
+		return domain.Booking{}, err
+	}
+	delete(rs.bookings, bookingID)
+	if len(booking.Tickets) > 0 {
+		rs.queueOccupancy(booking, booking.Tickets[0].Service.DateTime)
+	}
+	return booking, nil
+}
+
+// GetAvailableSeats lists the free seats, in train order, that could be
+// sold for a journey, optionally restricted to one comfort zone.
+func (rs *System) GetAvailableSeats(serviceID, origin, destination string, zone domain.ComfortZone, date time.Time) []domain.Seat {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	var seats []domain.Seat
+	
+	service, exists := rs.services[serviceID]
+	if !exists {
+		return seats
+	}
+	
+	booked := rs.bookedSeats(serviceID, date)
+	for _, carriage := range service.Carriages {
+		if !service.CarriageServes(carriage.ID, origin, destination) {
+			continue
+		}
+		for _, seat := range carriage.Seats {
+			if zone != "" && seat.ComfortZone != zone {
+				continue
+			}
+			if !booked[seat.CarriageID+"/"+seat.Number] {
+				seats = append(seats, seat)
+			}
+		}
+	}
+	
+	return seats
+}
+
+func (rs *System) allBookings() []domain.Booking {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
 	bookings := make([]domain.Booking, 0, len(rs.bookings))
 	for _, booking := range rs.bookings {
 		bookings = append(bookings, booking)
@@ -134,7 +607,10 @@ func (rs *System) GetAllBookings() []domain.Booking {
 	return bookings
 }
 
-func (rs *System) GetPassengersBoardingAt(serviceID, stationName string, date time.Time) []domain.Passenger {
+func (rs *System) passengersBoardingAt(serviceID, stationName string, date time.Time) []domain.Passenger {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
 	var passengers []domain.Passenger
 	
 	for _, booking := range rs.bookings {
@@ -150,7 +626,10 @@ func (rs *System) GetPassengersBoardingAt(serviceID, stationName string, date ti
 	return passengers
 }
 
-func (rs *System) GetPassengersAlightingAt(serviceID, stationName string, date time.Time) []domain.Passenger {
+func (rs *System) passengersAlightingAt(serviceID, stationName string, date time.Time) []domain.Passenger {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
 	var passengers []domain.Passenger
 	
 	for _, booking := range rs.bookings {
@@ -166,7 +645,10 @@ func (rs *System) GetPassengersAlightingAt(serviceID, stationName string, date t
 	return passengers
 }
 
-func (rs *System) GetPassengersBetweenStations(serviceID, station1, station2 string, date time.Time) []domain.Passenger {
+func (rs *System) passengersBetweenStations(serviceID, station1, station2 string, date time.Time) []domain.Passenger {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
 	var passengers []domain.Passenger
 	
 	service, exists := rs.services[serviceID]
@@ -174,22 +656,23 @@ func (rs *System) GetPassengersBetweenStations(serviceID, station1, station2 str
 		return passengers
 	}
 	
-	stop1Index, found1 := service.Route.GetStopIndex(station1)
-	stop2Index, found2 := service.Route.GetStopIndex(station2)
-	
-	if !found1 || !found2 {
-		return passengers
-	}
-	
-	if stop1Index >= stop2Index {
-		stop1Index, stop2Index = stop2Index, stop1Index
-	}
-	
 	for _, booking := range rs.bookings {
 		for _, ticket := range booking.Tickets {
 			if ticket.Service.ID == serviceID && rs.isSameDate(ticket.Service.DateTime, date) {
-				originIndex, _ := service.Route.GetStopIndex(ticket.Origin.Name)
-				destIndex, _ := service.Route.GetStopIndex(ticket.Destination.Name)
+				// Stop positions differ per portion, so compare on the
+				// route the passenger's carriage actually runs
+				route := service.RouteForCarriage(ticket.Seat.CarriageID)
+				stop1Index, found1 := route.GetStopIndex(station1)
+				stop2Index, found2 := route.GetStopIndex(station2)
+				if !found1 || !found2 {
+					continue
+				}
+				if stop1Index >= stop2Index {
+					stop1Index, stop2Index = stop2Index, stop1Index
+				}
+				
+				originIndex, _ := route.GetStopIndex(ticket.Origin.Name)
+				destIndex, _ := route.GetStopIndex(ticket.Destination.Name)
 				
 				if originIndex <= stop1Index && destIndex >= stop2Index {
 					passengers = append(passengers, ticket.Passenger)
@@ -201,7 +684,54 @@ func (rs *System) GetPassengersBetweenStations(serviceID, station1, station2 str
 	return passengers
 }
 
-func (rs *System) GetPassengerOnSeat(serviceID, carriageID, seatNumber string, date time.Time) (*domain.Passenger, bool) {
+func (rs *System) departureManifest(serviceID string, date time.Time) (domain.Manifest, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	return rs.manifest(serviceID, date)
+}
+
+func (rs *System) manifest(serviceID string, date time.Time) (domain.Manifest, bool) {
+	service, exists := rs.services[serviceID]
+	if !exists {
+		return domain.Manifest{}, false
+	}
+	
+	manifest := domain.Manifest{Service: service, Date: date}
+	for _, booking := range rs.bookings {
+		for _, ticket := range booking.Tickets {
+			if ticket.Service.ID == serviceID && rs.isSameDate(ticket.Service.DateTime, date) {
+				manifest.Entries = append(manifest.Entries, domain.ManifestEntry{
+					ServiceID:   serviceID,
+					BookingID:   booking.ID,
+					Passenger:   ticket.Passenger,
+					Seat:        ticket.Seat,
+					Origin:      ticket.Origin,
+					Destination: ticket.Destination,
+				})
+			}
+		}
+	}
+	
+	// Order entries as the seats appear in the train, carriage by carriage
+	position := make(map[string]int)
+	for _, carriage := range service.Carriages {
+		for _, seat := range carriage.Seats {
+			position[seat.CarriageID+"/"+seat.Number] = len(position)
+		}
+	}
+	sort.SliceStable(manifest.Entries, func(i, j int) bool {
+		a, b := manifest.Entries[i].Seat, manifest.Entries[j].Seat
+		return position[a.CarriageID+"/"+a.Number] < position[b.CarriageID+"/"+b.Number]
+	})
+	
+	return manifest, true
+}
+
+func (rs *System) passengerOnSeat(serviceID, carriageID, seatNumber string, date time.Time) (*domain.Passenger, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
 	for _, booking := range rs.bookings {
 		for _, ticket := range booking.Tickets {
 			if ticket.Service.ID == serviceID &&
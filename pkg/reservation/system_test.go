@@ -1,6 +1,7 @@
 package reservation
 
 import (
+	"errors"
 	"testing"
 	"ticketing-app/pkg/domain"
 	"time"
@@ -139,10 +140,13 @@ func TestSystem_MakeReservation(t *testing.T) {
 					t.Errorf("Expected error but got none")
 					return
 				}
-				if reservationErr, ok := err.(ReservationError); ok {
-					if reservationErr.Code != tt.errCode {
-						t.Errorf("Expected error code %s, got %s", tt.errCode, reservationErr.Code)
-					}
+				var validationErrs ValidationErrors
+				if !errors.As(err, &validationErrs) {
+					t.Errorf("Expected ValidationErrors, got %T", err)
+					return
+				}
+				if !validationErrs.HasCode(tt.errCode) {
+					t.Errorf("Expected error code %s, got %v", tt.errCode, validationErrs)
 				}
 			} else {
 				if err != nil {
@@ -161,6 +165,47 @@ func TestSystem_MakeReservation(t *testing.T) {
 	}
 }
 
+func TestSystem_MakeReservation_ReportsAllErrors(t *testing.T) {
+	rs := setupTestSystem()
+	
+	_, err := rs.MakeReservation(domain.ReservationRequest{
+		ServiceID: "5160",
+		Origin:    "Amsterdam",
+		Destination: "Paris",
+		Passengers: []domain.Passenger{{Name: "John Doe"}, {Name: "Eve Johnson"}},
+		SeatRequests: []domain.SeatRequest{
+			{CarriageID: "Z", SeatNumber: "Z1"},
+			{CarriageID: "A", SeatNumber: "A1"},
+			{CarriageID: "A", SeatNumber: "A2"},
+		},
+		Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	
+	expectedCodes := []string{
+		"PASSENGER_SEAT_MISMATCH",
+		"INVALID_ROUTE",
+		"SEAT_NOT_FOUND",
+	}
+	if len(validationErrs) != len(expectedCodes) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expectedCodes), len(validationErrs), validationErrs)
+	}
+	for i, code := range expectedCodes {
+		if validationErrs[i].Code != code {
+			t.Errorf("Expected error %d to be %s, got %s", i, code, validationErrs[i].Code)
+		}
+	}
+	
+	var reservationErr ReservationError
+	if !errors.As(err, &reservationErr) || reservationErr.Field != "SeatRequests" {
+		t.Errorf("Expected errors.As to find the first field error, got %+v", reservationErr)
+	}
+}
+
 func TestSystem_GetPassengersBoardingAt(t *testing.T) {
 	rs := setupTestSystem()
 	
@@ -176,7 +221,7 @@ func TestSystem_GetPassengersBoardingAt(t *testing.T) {
 		t.Fatalf("Failed to create test booking: %v", err)
 	}
 	
-	passengers := rs.GetPassengersBoardingAt("5160", "Paris", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	passengers := rs.passengersBoardingAt("5160", "Paris", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
 	if len(passengers) != 1 {
 		t.Errorf("Expected 1 passenger boarding at Paris, got %d", len(passengers))
 	}
@@ -184,7 +229,7 @@ func TestSystem_GetPassengersBoardingAt(t *testing.T) {
 		t.Errorf("Expected passenger 'Test Passenger', got '%s'", passengers[0].Name)
 	}
 	
-	passengers = rs.GetPassengersBoardingAt("5160", "Amsterdam", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	passengers = rs.passengersBoardingAt("5160", "Amsterdam", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
 	if len(passengers) != 0 {
 		t.Errorf("Expected 0 passengers boarding at Amsterdam, got %d", len(passengers))
 	}
@@ -205,7 +250,7 @@ func TestSystem_GetPassengersAlightingAt(t *testing.T) {
 		t.Fatalf("Failed to create test booking: %v", err)
 	}
 	
-	passengers := rs.GetPassengersAlightingAt("5160", "Amsterdam", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	passengers := rs.passengersAlightingAt("5160", "Amsterdam", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
 	if len(passengers) != 1 {
 		t.Errorf("Expected 1 passenger alighting at Amsterdam, got %d", len(passengers))
 	}
@@ -213,7 +258,7 @@ func TestSystem_GetPassengersAlightingAt(t *testing.T) {
 		t.Errorf("Expected passenger 'Test Passenger', got '%s'", passengers[0].Name)
 	}
 	
-	passengers = rs.GetPassengersAlightingAt("5160", "Paris", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	passengers = rs.passengersAlightingAt("5160", "Paris", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
 	if len(passengers) != 0 {
 		t.Errorf("Expected 0 passengers alighting at Paris, got %d", len(passengers))
 	}
@@ -234,12 +279,12 @@ func TestSystem_GetPassengersBetweenStations(t *testing.T) {
 		t.Fatalf("Failed to create test booking: %v", err)
 	}
 	
-	passengers := rs.GetPassengersBetweenStations("5160", "Calais", "Amsterdam", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	passengers := rs.passengersBetweenStations("5160", "Calais", "Amsterdam", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
 	if len(passengers) != 1 {
 		t.Errorf("Expected 1 passenger between Calais and Amsterdam, got %d", len(passengers))
 	}
 	
-	passengers = rs.GetPassengersBetweenStations("5160", "Paris", "Calais", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	passengers = rs.passengersBetweenStations("5160", "Paris", "Calais", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
 	if len(passengers) != 1 {
 		t.Errorf("Expected 1 passenger between Paris and Calais, got %d", len(passengers))
 	}
@@ -260,7 +305,7 @@ func TestSystem_GetPassengerOnSeat(t *testing.T) {
 		t.Fatalf("Failed to create test booking: %v", err)
 	}
 	
-	passenger, found := rs.GetPassengerOnSeat("5160", "A", "A8", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	passenger, found := rs.passengerOnSeat("5160", "A", "A8", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
 	if !found {
 		t.Errorf("Expected to find passenger on seat A8")
 	}
@@ -268,8 +313,77 @@ func TestSystem_GetPassengerOnSeat(t *testing.T) {
 		t.Errorf("Expected passenger 'Test Passenger', got '%s'", passenger.Name)
 	}
 	
-	_, found = rs.GetPassengerOnSeat("5160", "A", "A9", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	_, found = rs.passengerOnSeat("5160", "A", "A9", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
 	if found {
 		t.Errorf("Expected not to find passenger on empty seat A9")
 	}
 }
+
+func TestSystem_GetManifest(t *testing.T) {
+	rs := setupTestSystem()
+	
+	for _, seat := range []string{"A3", "A1"} {
+		_, err := rs.MakeReservation(domain.ReservationRequest{
+			ServiceID: "5160",
+			Origin:    "Paris",
+			Destination: "Calais",
+			Passengers: []domain.Passenger{{Name: "Passenger " + seat}},
+			SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: seat}},
+			Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test booking: %v", err)
+		}
+	}
+	
+	manifest, found := rs.departureManifest("5160", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	if !found {
+		t.Fatalf("Expected manifest for service 5160")
+	}
+	if len(manifest.Entries) != 2 {
+		t.Fatalf("Expected 2 manifest entries, got %d", len(manifest.Entries))
+	}
+	if manifest.Entries[0].Seat.Number != "A1" || manifest.Entries[1].Seat.Number != "A3" {
+		t.Errorf("Expected entries in seat order, got %s, %s", manifest.Entries[0].Seat.Number, manifest.Entries[1].Seat.Number)
+	}
+	
+	if _, found := rs.departureManifest("9999", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)); found {
+		t.Errorf("Expected no manifest for unknown service")
+	}
+}
+
+func TestSystem_CancelBooking(t *testing.T) {
+	rs := setupTestSystem()
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	
+	booking, err := rs.MakeReservation(domain.ReservationRequest{
+		ServiceID: "5160",
+		Origin:    "Paris",
+		Destination: "Amsterdam",
+		Passengers: []domain.Passenger{{Name: "Test Passenger"}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A1"}},
+		Date: date,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test booking: %v", err)
+	}
+	
+	if seats := rs.GetAvailableSeats("5160", "Paris", "Amsterdam", domain.FirstClass, date); len(seats) != 7 {
+		t.Errorf("Expected 7 available seats, got %d", len(seats))
+	}
+	
+	if _, err := rs.CancelBooking(booking.ID); err != nil {
+		t.Fatalf("Failed to cancel booking: %v", err)
+	}
+	if _, found := rs.passengerOnSeat("5160", "A", "A1", date); found {
+		t.Errorf("Expected seat A1 to be released")
+	}
+	if seats := rs.GetAvailableSeats("5160", "Paris", "Amsterdam", domain.FirstClass, date); len(seats) != 8 {
+		t.Errorf("Expected 8 available seats after cancellation, got %d", len(seats))
+	}
+	
+	_, err = rs.CancelBooking(booking.ID)
+	if reservationErr, ok := err.(ReservationError); !ok || reservationErr.Code != "BOOKING_NOT_FOUND" {
+		t.Errorf("Expected BOOKING_NOT_FOUND, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job is periodic housekeeping such as expiring quote locks. It receives
+// the scheduler's clock reading for the run.
+type Job func(now time.Time)
+
+type job struct {
+	name     string
+	interval time.Duration
+	next     time.Time
+	fn       Job
+}
+
+// Scheduler runs jobs at fixed intervals on a single goroutine, so jobs
+// never overlap each other. Run drives it in production; tests call
+// RunPending with a controlled clock instead.
+type Scheduler struct {
+	mu     sync.Mutex
+	jobs   []*job
+	now    func() time.Time
+	logger *slog.Logger
+	// Resolution is how often Run checks for due jobs.
+	Resolution time.Duration
+}
+
+func New() *Scheduler {
+	return &Scheduler{now: time.Now, logger: slog.Default(), Resolution: time.Second}
+}
+
+// SetLogger sets where panicking jobs are reported. A job's panic value
+// can carry whatever it was working on, so pass a masking logger such as
+// reservation.System.Logger.
+func (s *Scheduler) SetLogger(logger *slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logger = logger
+}
+
+func (s *Scheduler) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// Every registers a job that first runs one interval from now.
+func (s *Scheduler) Every(name string, interval time.Duration, fn Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &job{name: name, interval: interval, next: s.now().Add(interval), fn: fn})
+}
+
+// RunPending runs every job that is due, in registration order, and
+// returns their names. A job that falls several intervals behind runs once
+// and is rescheduled from now rather than catching up.
+func (s *Scheduler) RunPending() []string {
+	s.mu.Lock()
+	now := s.now()
+	var due []*job
+	for _, j := range s.jobs {
+		if !now.Before(j.next) {
+			due = append(due, j)
+			j.next = now.Add(j.interval)
+		}
+	}
+	s.mu.Unlock()
+
+	names := make([]string, 0, len(due))
+	for _, j := range due {
+		s.run(j, now)
+		names = append(names, j.name)
+	}
+	return names
+}
+
+func (s *Scheduler) run(j *job, now time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.mu.Lock()
+			logger := s.logger
+			s.mu.Unlock()
+			logger.Error("scheduler: job panicked", "job", j.name, "panic", r)
+		}
+	}()
+	j.fn(now)
+}
+
+// Run checks for due jobs until the context is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Resolution)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RunPending()
+		}
+	}
+}
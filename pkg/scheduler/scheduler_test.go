@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler_RunPending(t *testing.T) {
+	now := time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC)
+	s := New()
+	s.SetClock(func() time.Time { return now })
+	
+	var runs []time.Time
+	s.Every("minutely", time.Minute, func(at time.Time) { runs = append(runs, at) })
+	s.Every("hourly", time.Hour, func(time.Time) {})
+	
+	if ran := s.RunPending(); len(ran) != 0 {
+		t.Errorf("Expected nothing to be due yet, ran %v", ran)
+	}
+	
+	now = now.Add(time.Minute)
+	if ran := s.RunPending(); !reflect.DeepEqual(ran, []string{"minutely"}) {
+		t.Errorf("Expected the minutely job to run, ran %v", ran)
+	}
+	
+	now = now.Add(time.Hour)
+	if ran := s.RunPending(); !reflect.DeepEqual(ran, []string{"minutely", "hourly"}) {
+		t.Errorf("Expected both jobs to run, ran %v", ran)
+	}
+	if len(runs) != 2 || !runs[1].Equal(now) {
+		t.Errorf("Expected a late job to run once with the current time, got %v", runs)
+	}
+}
+
+func TestScheduler_RecoversFromPanics(t *testing.T) {
+	now := time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC)
+	s := New()
+	s.SetClock(func() time.Time { return now })
+	
+	ran := false
+	s.Every("broken", time.Minute, func(time.Time) { panic("boom") })
+	s.Every("healthy", time.Minute, func(time.Time) { ran = true })
+	
+	now = now.Add(time.Minute)
+	s.RunPending()
+	if !ran {
+		t.Error("Expected a panicking job not to stop the others")
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	s := New()
+	s.Resolution = time.Millisecond
+	
+	var runs int32
+	s.Every("fast", time.Millisecond, func(time.Time) { atomic.AddInt32(&runs, 1) })
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+	
+	if atomic.LoadInt32(&runs) == 0 {
+		t.Error("Expected the job to run before the context was cancelled")
+	}
+}
@@ -0,0 +1,246 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"ticketing-app/pkg/audit"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/reservation"
+	"time"
+)
+
+// OnSale simulates a popular departure going on sale: many clients asking
+// for seats on the same service within a short window, far more than it
+// can seat. Request parameters come from Seed; the interleaving is up to
+// the scheduler, as it would be in production.
+type OnSale struct {
+	ServiceID   string
+	Origin      string
+	Destination string
+	Date        time.Time
+	Zones       []domain.ComfortZone
+	Clients     int
+	// Window spreads client arrivals; zero sends them all at once.
+	Window       time.Duration
+	MaxPartySize int
+	Seed         int64
+	// FairnessTolerance is how much later a request may arrive than one
+	// that was turned away and still be served without counting as unfair.
+	// Requests arriving that close together race for the lock.
+	FairnessTolerance time.Duration
+}
+
+type OnSaleReport struct {
+	Requests  int
+	Booked    int
+	SoldOut   int
+	Failed    int
+	Errors    []string
+	Seats     int
+	SeatsSold int
+	// DoubleBooked lists seats sold to more than one ticket.
+	DoubleBooked []string
+	// Inversions counts requests served although an earlier request for
+	// the same class, for no more seats, had been turned away.
+	Inversions int
+	Latency    LatencyStats
+	Duration   time.Duration
+}
+
+type attempt struct {
+	party   int
+	zone    domain.ComfortZone
+	delay   time.Duration
+	started time.Time
+	latency time.Duration
+	booked  bool
+	soldOut bool
+	err     error
+}
+
+func (s OnSale) Run(rs *reservation.System) OnSaleReport {
+	attempts := s.plan()
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	release := make(chan struct{})
+	for i := range attempts {
+		wg.Add(1)
+		go func(a *attempt, client int) {
+			defer wg.Done()
+			<-release
+			time.Sleep(a.delay)
+			s.book(rs, a, client)
+		}(&attempts[i], i)
+	}
+	close(release)
+	wg.Wait()
+
+	report := OnSaleReport{Requests: len(attempts), Duration: time.Since(start)}
+	latencies := make([]time.Duration, len(attempts))
+	for i, a := range attempts {
+		latencies[i] = a.latency
+		switch {
+		case a.booked:
+			report.Booked++
+		case a.soldOut:
+			report.SoldOut++
+		default:
+			report.Failed++
+			report.Errors = append(report.Errors, a.err.Error())
+		}
+	}
+	report.Latency = latencyStats(latencies)
+	report.Inversions = s.inversions(attempts)
+	s.countSeats(rs, &report)
+	return report
+}
+
+// Check reports everything that makes the on-sale unacceptable: what
+// CheckSales reports, unfair ordering and a p99 latency above the limit.
+func (r OnSaleReport) Check(maxP99 time.Duration) error {
+	problems := r.salesProblems()
+	if r.Inversions > 0 {
+		problems = append(problems, fmt.Sprintf("%d requests served out of arrival order", r.Inversions))
+	}
+	if r.Latency.P99 > maxP99 {
+		problems = append(problems, fmt.Sprintf("p99 latency %s exceeds %s", r.Latency.P99, maxP99))
+	}
+	return joinProblems(problems)
+}
+
+// CheckSales reports the problems that do not depend on timing: seats sold
+// twice or beyond capacity and unexpected errors. It is what still holds
+// on a machine too slow or too instrumented for latency to mean anything.
+func (r OnSaleReport) CheckSales() error {
+	return joinProblems(r.salesProblems())
+}
+
+func (r OnSaleReport) salesProblems() []string {
+	var problems []string
+	if len(r.DoubleBooked) > 0 {
+		problems = append(problems, fmt.Sprintf("seats sold twice: %s", strings.Join(r.DoubleBooked, ", ")))
+	}
+	if r.SeatsSold > r.Seats {
+		problems = append(problems, fmt.Sprintf("%d seats sold on a %d-seat departure", r.SeatsSold, r.Seats))
+	}
+	if r.Failed > 0 {
+		problems = append(problems, fmt.Sprintf("%d requests failed unexpectedly, first: %s", r.Failed, r.Errors[0]))
+	}
+	return problems
+}
+
+func joinProblems(problems []string) error {
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s OnSale) plan() []attempt {
+	rng := rand.New(rand.NewSource(s.Seed))
+	zones := s.zones()
+	maxParty := s.MaxPartySize
+	if maxParty < 1 {
+		maxParty = 1
+	}
+
+	attempts := make([]attempt, s.Clients)
+	for i := range attempts {
+		attempts[i].party = 1 + rng.Intn(maxParty)
+		attempts[i].zone = zones[rng.Intn(len(zones))]
+		if s.Window > 0 {
+			attempts[i].delay = time.Duration(rng.Int63n(int64(s.Window)))
+		}
+	}
+	return attempts
+}
+
+func (s OnSale) book(rs *reservation.System, a *attempt, client int) {
+	req := domain.ReservationRequest{
+		ServiceID:   s.ServiceID,
+		Origin:      s.Origin,
+		Destination: s.Destination,
+		Date:        s.Date,
+	}
+	for p := 0; p < a.party; p++ {
+		req.Passengers = append(req.Passengers, domain.Passenger{Name: fmt.Sprintf("Client %d passenger %d", client, p+1)})
+		req.SeatRequests = append(req.SeatRequests, domain.SeatRequest{ComfortZone: a.zone})
+	}
+
+	a.started = time.Now()
+	_, err := rs.MakeReservation(req)
+	a.latency = time.Since(a.started)
+
+	var errs reservation.ValidationErrors
+	switch {
+	case err == nil:
+		a.booked = true
+	case errors.As(err, &errs) && errs.HasCode("NO_SEAT_AVAILABLE"):
+		a.soldOut = true
+	default:
+		a.err = err
+	}
+}
+
+// inversions counts served requests that arrived more than the tolerance
+// after a turned-away request for the same class asking for no more seats.
+func (s OnSale) inversions(attempts []attempt) int {
+	count := 0
+	for _, served := range attempts {
+		if !served.booked {
+			continue
+		}
+		for _, refused := range attempts {
+			if refused.soldOut && refused.zone == served.zone && refused.party <= served.party &&
+				served.started.Sub(refused.started) > s.FairnessTolerance {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+func (s OnSale) countSeats(rs *reservation.System, report *OnSaleReport) {
+	service, _ := rs.GetService(s.ServiceID)
+	for _, carriage := range service.Carriages {
+		if !service.CarriageServes(carriage.ID, s.Origin, s.Destination) {
+			continue
+		}
+		for _, seat := range carriage.Seats {
+			for _, zone := range s.zones() {
+				if seat.ComfortZone == zone {
+					report.Seats++
+				}
+			}
+		}
+	}
+
+	// The manifest is read as analytics, which sees seats but no one on them
+	manifest, _, err := rs.QueryAs(audit.Caller{UserID: "onsale-simulator", Role: audit.Analytics}).GetManifest(s.ServiceID, s.Date)
+	if err != nil {
+		report.Failed++
+		report.Errors = append(report.Errors, fmt.Sprintf("counting seats sold: %v", err))
+		return
+	}
+	sold := make(map[string]int)
+	for _, entry := range manifest.Entries {
+		key := entry.Seat.CarriageID + "/" + entry.Seat.Number
+		sold[key]++
+		report.SeatsSold++
+		if sold[key] == 2 {
+			report.DoubleBooked = append(report.DoubleBooked, key)
+		}
+	}
+}
+
+func (s OnSale) zones() []domain.ComfortZone {
+	if len(s.Zones) == 0 {
+		return []domain.ComfortZone{domain.FirstClass, domain.SecondClass}
+	}
+	return s.Zones
+}
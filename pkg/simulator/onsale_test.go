@@ -0,0 +1,111 @@
+package simulator
+
+import (
+	"flag"
+	"sort"
+	"strings"
+	"testing"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/testdata"
+	"time"
+)
+
+// The timing limits hold on a developer machine; slower CI runners can
+// relax them, e.g. go test ./pkg/simulator -args -onsale.p99=1s
+var (
+	maxP99    = flag.Duration("onsale.p99", 250*time.Millisecond, "p99 booking latency allowed during the on-sale spike")
+	tolerance = flag.Duration("onsale.fairness", 20*time.Millisecond, "how close together requests may arrive and still be served out of order")
+)
+
+func TestOnSale_PopularDeparture(t *testing.T) {
+	if testing.Short() {
+		t.Skip("On-sale spike is slow in short mode")
+	}
+	rs := testdata.SetupTestData()
+	if err := testdata.AddOnSaleService(rs); err != nil {
+		t.Fatalf("Failed to add on-sale service: %v", err)
+	}
+	
+	scenario := OnSale{
+		ServiceID:         testdata.OnSaleServiceID,
+		Origin:            "Paris",
+		Destination:       "Amsterdam",
+		Date:              time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+		Clients:           3000,
+		Window:            500 * time.Millisecond,
+		MaxPartySize:      4,
+		Seed:              2021,
+		FairnessTolerance: *tolerance,
+	}
+	report := scenario.Run(rs)
+	
+	// Latency and arrival order say nothing under the race detector
+	check := report.Check(*maxP99)
+	if raceEnabled {
+		check = report.CheckSales()
+	}
+	if check != nil {
+		t.Error(check)
+	}
+	if report.Booked+report.SoldOut != report.Requests {
+		t.Errorf("Expected every request to be booked or turned away, got %+v", report)
+	}
+	// Parties of up to four cannot always fill the last seats of a class
+	if report.SeatsSold < report.Seats-6 || report.Seats != 408 {
+		t.Errorf("Expected the 408-seat departure to sell out, sold %d of %d seats", report.SeatsSold, report.Seats)
+	}
+	t.Logf("%d requests in %s: %d booked, %d sold out, p50 %s, p99 %s",
+		report.Requests, report.Duration, report.Booked, report.SoldOut, report.Latency.P50, report.Latency.P99)
+}
+
+func TestOnSale_ServiceSeatsPartiesByItsLayout(t *testing.T) {
+	rs := testdata.SetupTestData()
+	if err := testdata.AddOnSaleService(rs); err != nil {
+		t.Fatalf("Failed to add on-sale service: %v", err)
+	}
+	
+	// Each side of a second class bay seats four: two side by side, facing
+	// the other two
+	for _, expected := range []string{"3/1 3/2 3/5 3/6", "3/3 3/4 3/7 3/8"} {
+		party := make([]domain.Passenger, 4)
+		seatRequests := make([]domain.SeatRequest, 4)
+		for i := range party {
+			party[i] = domain.Passenger{Name: "Passenger"}
+			seatRequests[i] = domain.SeatRequest{ComfortZone: domain.SecondClass}
+		}
+		booking, err := rs.MakeReservation(domain.ReservationRequest{
+			ServiceID:    testdata.OnSaleServiceID,
+			Origin:       "Paris",
+			Destination:  "Amsterdam",
+			Passengers:   party,
+			SeatRequests: seatRequests,
+			Date:         time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("Failed to book a party of four: %v", err)
+		}
+		var seats []string
+		for _, ticket := range booking.Tickets {
+			seats = append(seats, ticket.Seat.CarriageID+"/"+ticket.Seat.Number)
+		}
+		sort.Strings(seats)
+		if strings.Join(seats, " ") != expected || booking.Placement.Strategy != domain.PlacedTogether {
+			t.Errorf("Expected %s together, got %v (%s)", expected, seats, booking.Placement.Strategy)
+		}
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	
+	stats := latencyStats(latencies)
+	if stats.P50 != 50*time.Millisecond || stats.P99 != 99*time.Millisecond || stats.Max != 100*time.Millisecond {
+		t.Errorf("Unexpected percentiles %+v", stats)
+	}
+	if (latencyStats(nil) != LatencyStats{}) {
+		t.Error("Expected zero stats without samples")
+	}
+}
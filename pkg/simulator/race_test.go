@@ -0,0 +1,7 @@
+//go:build race
+
+package simulator
+
+// raceEnabled tells tests that timing means nothing under the race
+// detector, which slows the reservation lock down by an order of magnitude.
+const raceEnabled = true
@@ -0,0 +1,533 @@
+package boltstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/store"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	routesBucket    = []byte("routes")
+	stationsBucket  = []byte("stations")
+	servicesBucket  = []byte("services")
+	couplingsBucket = []byte("couplings")
+	bookingsBucket  = []byte("bookings")
+	auditBucket     = []byte("audit")
+	// distributionBucket holds distribution API records as they were
+	// encoded; like the audit trail it is not part of snapshots.
+	distributionBucket = []byte("distribution")
+)
+
+type Options struct {
+	// ReadOnly opens the file with a shared lock so several processes on a
+	// conductor device can read it while a sync agent holds no writer.
+	ReadOnly bool
+	// LockTimeout bounds how long Open waits for another process to
+	// release the file; zero waits for one second.
+	LockTimeout time.Duration
+}
+
+// Store is a single-file embedded store for conductor devices and other
+// edge deployments. Every write is its own fsynced bbolt transaction, so a
+// crash or power loss leaves the last committed state intact, and reads
+// run concurrently without locking writers out.
+//
+// Tickets are stored with a reference to their service, its ID and
+// departure, rather than a copy of it; reads fill the service in from the
+// services bucket.
+type Store struct {
+	db *bolt.DB
+}
+
+// storedBooking is a booking as written to the bookings bucket.
+type storedBooking struct {
+	domain.Booking
+	Tickets []storedTicket
+}
+
+type storedTicket struct {
+	domain.Ticket
+	Service serviceRef
+}
+
+type serviceRef struct {
+	ID       string
+	DateTime time.Time
+}
+
+func Open(path string, opts Options) (*Store, error) {
+	timeout := opts.LockTimeout
+	if timeout == 0 {
+		timeout = time.Second
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{
+		Timeout:        timeout,
+		ReadOnly:       opts.ReadOnly,
+		FreelistType:   bolt.FreelistMapType,
+		NoFreelistSync: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store %s: %w", path, err)
+	}
+
+	if !opts.ReadOnly {
+		err = db.Update(func(tx *bolt.Tx) error {
+			for _, name := range [][]byte{routesBucket, stationsBucket, servicesBucket, couplingsBucket, bookingsBucket, auditBucket, distributionBucket} {
+				if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to initialise store %s: %w", path, err)
+		}
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) SaveRoute(route domain.Route) error {
+	return s.put(routesBucket, route.ID, route)
+}
+
+func (s *Store) SaveStation(station domain.Station) error {
+	return s.put(stationsBucket, station.Name, station)
+}
+
+func (s *Store) SaveService(service domain.Service) error {
+	return s.put(servicesBucket, service.ID, service)
+}
+
+func (s *Store) SaveCoupling(coupling domain.Coupling) error {
+	return s.put(couplingsBucket, coupling.Key(), coupling)
+}
+
+func (s *Store) SaveBooking(booking domain.Booking) error {
+	return s.put(bookingsBucket, booking.ID, toStored(booking))
+}
+
+func (s *Store) DeleteRoute(routeID string) error {
+	return s.delete(routesBucket, routeID)
+}
+
+func (s *Store) DeleteStation(name string) error {
+	return s.delete(stationsBucket, name)
+}
+
+func (s *Store) DeleteService(serviceID string) error {
+	return s.delete(servicesBucket, serviceID)
+}
+
+func (s *Store) DeleteCoupling(key string) error {
+	return s.delete(couplingsBucket, key)
+}
+
+func (s *Store) DeleteBooking(bookingID string) error {
+	return s.delete(bookingsBucket, bookingID)
+}
+
+func (s *Store) GetBooking(bookingID string) (domain.Booking, bool, error) {
+	var (
+		booking domain.Booking
+		found   bool
+	)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bookingsBucket)
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get([]byte(bookingID))
+		if data == nil {
+			return nil
+		}
+		services, err := readServices(tx)
+		if err != nil {
+			return err
+		}
+		found = true
+		booking, err = decodeBooking(data, services)
+		return err
+	})
+	if err != nil {
+		return domain.Booking{}, false, fmt.Errorf("failed to read booking %s: %w", bookingID, err)
+	}
+	return booking, found, nil
+}
+
+func (s *Store) Routes() ([]domain.Route, error) {
+	var routes []domain.Route
+	err := s.each(routesBucket, func(data []byte) error {
+		var route domain.Route
+		if err := json.Unmarshal(data, &route); err != nil {
+			return err
+		}
+		routes = append(routes, route)
+		return nil
+	})
+	return routes, err
+}
+
+func (s *Store) Stations() ([]domain.Station, error) {
+	var stations []domain.Station
+	err := s.each(stationsBucket, func(data []byte) error {
+		var station domain.Station
+		if err := json.Unmarshal(data, &station); err != nil {
+			return err
+		}
+		stations = append(stations, station)
+		return nil
+	})
+	return stations, err
+}
+
+func (s *Store) Services() ([]domain.Service, error) {
+	var services []domain.Service
+	err := s.each(servicesBucket, func(data []byte) error {
+		var service domain.Service
+		if err := json.Unmarshal(data, &service); err != nil {
+			return err
+		}
+		services = append(services, service)
+		return nil
+	})
+	return services, err
+}
+
+func (s *Store) Couplings() ([]domain.Coupling, error) {
+	var couplings []domain.Coupling
+	err := s.each(couplingsBucket, func(data []byte) error {
+		var coupling domain.Coupling
+		if err := json.Unmarshal(data, &coupling); err != nil {
+			return err
+		}
+		couplings = append(couplings, coupling)
+		return nil
+	})
+	return couplings, err
+}
+
+func (s *Store) Bookings() ([]domain.Booking, error) {
+	var bookings []domain.Booking
+	err := s.db.View(func(tx *bolt.Tx) error {
+		services, err := readServices(tx)
+		if err != nil {
+			return err
+		}
+		b := tx.Bucket(bookingsBucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, data []byte) error {
+			booking, err := decodeBooking(data, services)
+			bookings = append(bookings, booking)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", bookingsBucket, err)
+	}
+	return bookings, nil
+}
+
+// Snapshot reads every bucket in a single read transaction, so writes
+// committed meanwhile are either all included or all left out.
+func (s *Store) Snapshot() (store.Snapshot, error) {
+	var snapshot store.Snapshot
+	err := s.db.View(func(tx *bolt.Tx) error {
+		services, err := readServices(tx)
+		if err != nil {
+			return err
+		}
+		buckets := []struct {
+			name   []byte
+			decode func(data []byte) error
+		}{
+			{routesBucket, func(data []byte) error {
+				var route domain.Route
+				err := json.Unmarshal(data, &route)
+				snapshot.Routes = append(snapshot.Routes, route)
+				return err
+			}},
+			{stationsBucket, func(data []byte) error {
+				var station domain.Station
+				err := json.Unmarshal(data, &station)
+				snapshot.Stations = append(snapshot.Stations, station)
+				return err
+			}},
+			{servicesBucket, func(data []byte) error {
+				var service domain.Service
+				err := json.Unmarshal(data, &service)
+				snapshot.Services = append(snapshot.Services, service)
+				return err
+			}},
+			{couplingsBucket, func(data []byte) error {
+				var coupling domain.Coupling
+				err := json.Unmarshal(data, &coupling)
+				snapshot.Couplings = append(snapshot.Couplings, coupling)
+				return err
+			}},
+			{bookingsBucket, func(data []byte) error {
+				booking, err := decodeBooking(data, services)
+				snapshot.Bookings = append(snapshot.Bookings, booking)
+				return err
+			}},
+		}
+		for _, bucket := range buckets {
+			b := tx.Bucket(bucket.name)
+			if b == nil {
+				continue
+			}
+			err := b.ForEach(func(_, data []byte) error {
+				return bucket.decode(data)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", bucket.name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return store.Snapshot{}, fmt.Errorf("failed to snapshot store %s: %w", s.db.Path(), err)
+	}
+	return snapshot, nil
+}
+
+// Restore replaces every bucket's contents with the snapshot in a single
+// write transaction, so a failure leaves the store as it was.
+func (s *Store) Restore(snapshot store.Snapshot) error {
+	type record struct {
+		key   string
+		value interface{}
+	}
+	buckets := []struct {
+		name    []byte
+		records []record
+	}{
+		{name: routesBucket},
+		{name: stationsBucket},
+		{name: servicesBucket},
+		{name: couplingsBucket},
+		{name: bookingsBucket},
+	}
+	for _, route := range snapshot.Routes {
+		buckets[0].records = append(buckets[0].records, record{route.ID, route})
+	}
+	for _, station := range snapshot.Stations {
+		buckets[1].records = append(buckets[1].records, record{station.Name, station})
+	}
+	for _, service := range snapshot.Services {
+		buckets[2].records = append(buckets[2].records, record{service.ID, service})
+	}
+	for _, coupling := range snapshot.Couplings {
+		buckets[3].records = append(buckets[3].records, record{coupling.Key(), coupling})
+	}
+	for _, booking := range snapshot.Bookings {
+		buckets[4].records = append(buckets[4].records, record{booking.ID, toStored(booking)})
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range buckets {
+			if err := tx.DeleteBucket(bucket.name); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+			b, err := tx.CreateBucket(bucket.name)
+			if err != nil {
+				return err
+			}
+			for _, r := range bucket.records {
+				data, err := json.Marshal(r.value)
+				if err != nil {
+					return fmt.Errorf("failed to encode %s %s: %w", bucket.name, r.key, err)
+				}
+				if err := b.Put([]byte(r.key), data); err != nil {
+					return fmt.Errorf("failed to save %s %s: %w", bucket.name, r.key, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore store %s: %w", s.db.Path(), err)
+	}
+	return nil
+}
+
+// AppendAudit keys each entry by a sequence number, so the audit bucket
+// reads back in the order entries were appended. Restores leave it alone.
+func (s *Store) AppendAudit(entry []byte) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(auditBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		return b.Put(key, entry)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to append to %s: %w", auditBucket, err)
+	}
+	return nil
+}
+
+// AuditTrail returns the audit entries appended so far, oldest first.
+func (s *Store) AuditTrail() ([][]byte, error) {
+	var entries [][]byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(auditBucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, data []byte) error {
+			entries = append(entries, append([]byte(nil), data...))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", auditBucket, err)
+	}
+	return entries, nil
+}
+
+func (s *Store) SaveDistributionBooking(bookingID string, record []byte) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(distributionBucket).Put([]byte(bookingID), record)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save %s %s: %w", distributionBucket, bookingID, err)
+	}
+	return nil
+}
+
+func (s *Store) DistributionBookings() (map[string][]byte, error) {
+	records := make(map[string][]byte)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(distributionBucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(key, data []byte) error {
+			records[string(key)] = append([]byte(nil), data...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", distributionBucket, err)
+	}
+	return records, nil
+}
+
+// Ping opens a read transaction, which fails once the database is closed.
+func (s *Store) Ping() error {
+	if err := s.db.View(func(*bolt.Tx) error { return nil }); err != nil {
+		return fmt.Errorf("store %s is unavailable: %w", s.db.Path(), err)
+	}
+	return nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) put(bucket []byte, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s %s: %w", bucket, key, err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save %s %s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+func (s *Store) delete(bucket []byte, key string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s %s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// toStored replaces each ticket's service with a reference to it.
+func toStored(booking domain.Booking) storedBooking {
+	stored := storedBooking{Booking: booking, Tickets: make([]storedTicket, len(booking.Tickets))}
+	for i, ticket := range booking.Tickets {
+		stored.Tickets[i] = storedTicket{Ticket: ticket, Service: serviceRef{ID: ticket.Service.ID, DateTime: ticket.Service.DateTime}}
+	}
+	return stored
+}
+
+// readServices reads the services bucket for filling in tickets' services.
+func readServices(tx *bolt.Tx) (map[string]domain.Service, error) {
+	services := make(map[string]domain.Service)
+	b := tx.Bucket(servicesBucket)
+	if b == nil {
+		return services, nil
+	}
+	err := b.ForEach(func(_, data []byte) error {
+		var service domain.Service
+		if err := json.Unmarshal(data, &service); err != nil {
+			return err
+		}
+		services[service.ID] = service
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", servicesBucket, err)
+	}
+	return services, nil
+}
+
+// decodeBooking fills each ticket's service in from its reference. A
+// service no longer in the store is left as the bare reference. Bookings
+// written with a full copy of the service decode the same way.
+func decodeBooking(data []byte, services map[string]domain.Service) (domain.Booking, error) {
+	var stored storedBooking
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return domain.Booking{}, err
+	}
+	booking := stored.Booking
+	booking.Tickets = make([]domain.Ticket, len(stored.Tickets))
+	for i, ticket := range stored.Tickets {
+		booking.Tickets[i] = ticket.Ticket
+		service, found := services[ticket.Service.ID]
+		if !found {
+			service = domain.Service{ID: ticket.Service.ID}
+		}
+		service.DateTime = ticket.Service.DateTime
+		booking.Tickets[i].Service = service
+	}
+	return booking, nil
+}
+
+// each visits records in key order. Keys are compared as bytes, so booking
+// IDs past B9999 (B10000 sorts before B9999) are not in creation order.
+func (s *Store) each(bucket []byte, fn func(data []byte) error) error {
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, data []byte) error {
+			return fn(data)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", bucket, err)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package boltstore
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"ticketing-app/pkg/audit"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/reservation"
+	"ticketing-app/pkg/store"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func setupRouteAndService(t *testing.T, rs *reservation.System) {
+	route := domain.NewRoute("R002", "Paris-Amsterdam",
+		[]domain.Station{domain.NewStation("Paris"), domain.NewStation("Amsterdam")},
+		[]int{0, 520})
+	service := domain.NewService("5160", route, time.Date(2021, 4, 1, 8, 0, 0, 0, time.UTC),
+		[]domain.Carriage{{
+			ID: "A",
+			Seats: []domain.Seat{
+				{Number: "A1", ComfortZone: domain.FirstClass, CarriageID: "A"},
+				{Number: "A2", ComfortZone: domain.FirstClass, CarriageID: "A"},
+			},
+		}})
+	
+	if err := rs.AddRoute(route); err != nil {
+		t.Fatalf("Failed to add route: %v", err)
+	}
+	if err := rs.AddService(service); err != nil {
+		t.Fatalf("Failed to add service: %v", err)
+	}
+}
+
+func book(t *testing.T, rs *reservation.System, seat string) *domain.Booking {
+	booking, err := rs.MakeReservation(domain.ReservationRequest{
+		ServiceID: "5160",
+		Origin:    "Paris",
+		Destination: "Amsterdam",
+		Passengers: []domain.Passenger{{Name: "Passenger " + seat}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: seat}},
+		Date: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create booking: %v", err)
+	}
+	return booking
+}
+
+func TestStore_SystemSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conductor.db")
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	
+	st, err := Open(path, Options{})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	rs, err := reservation.NewSystemWithStore(st)
+	if err != nil {
+		t.Fatalf("Failed to create system: %v", err)
+	}
+	setupRouteAndService(t, rs)
+	first := book(t, rs, "A1")
+	if err := rs.CheckIn("5160", "A", "A1", date); err != nil {
+		t.Fatalf("Failed to check in: %v", err)
+	}
+	st.Close()
+	
+	st, err = Open(path, Options{})
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer st.Close()
+	rs, err = reservation.NewSystemWithStore(st)
+	if err != nil {
+		t.Fatalf("Failed to reload system: %v", err)
+	}
+	
+	conductor := rs.QueryAs(audit.Caller{UserID: "c-042", Role: audit.Conductor})
+	passenger, found, err := conductor.GetPassengerOnSeat("5160", "A", "A1", date)
+	if err != nil || !found || passenger.Name != "Passenger A1" {
+		t.Errorf("Expected booking to survive restart, got %v (found %v)", passenger, found)
+	}
+	
+	stored, found, err := st.GetBooking(first.ID)
+	if err != nil || !found {
+		t.Fatalf("Expected to read booking %s back, got found=%v err=%v", first.ID, found, err)
+	}
+	if stored.Tickets[0].Boarding != domain.BoardingCheckedIn {
+		t.Errorf("Expected check-in to be persisted, got %q", stored.Tickets[0].Boarding)
+	}
+	if !stored.ChecksumValid() {
+		t.Errorf("Expected the booking's checksum to survive encoding")
+	}
+	if issues := rs.LoadIssues(); len(issues) != 0 {
+		t.Errorf("Expected no integrity issues on reload, got %+v", issues)
+	}
+	
+	second := book(t, rs, "A2")
+	if second.ID == first.ID {
+		t.Errorf("Expected booking IDs to continue after restart, got %s twice", second.ID)
+	}
+	
+	if _, err := rs.CancelBooking(first.ID); err != nil {
+		t.Fatalf("Failed to cancel booking: %v", err)
+	}
+	if _, found, _ := st.GetBooking(first.ID); found {
+		t.Errorf("Expected cancelled booking to be removed from the store")
+	}
+}
+
+func TestStore_ReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conductor.db")
+	
+	st, err := Open(path, Options{})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	rs, _ := reservation.NewSystemWithStore(st)
+	setupRouteAndService(t, rs)
+	book(t, rs, "A1")
+	st.Close()
+	
+	st, err = Open(path, Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to open store read-only: %v", err)
+	}
+	defer st.Close()
+	
+	bookings, err := st.Bookings()
+	if err != nil || len(bookings) != 1 {
+		t.Errorf("Expected 1 booking, got %d (err %v)", len(bookings), err)
+	}
+	if err := st.SaveBooking(bookings[0]); err == nil {
+		t.Errorf("Expected writes to a read-only store to fail")
+	}
+}
+
+func TestStore_TicketsReferToTheirService(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "conductor.db"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer st.Close()
+	rs, _ := reservation.NewSystemWithStore(st)
+	setupRouteAndService(t, rs)
+	booking := book(t, rs, "A1")
+	
+	var raw []byte
+	st.db.View(func(tx *bolt.Tx) error {
+		raw = append(raw, tx.Bucket(bookingsBucket).Get([]byte(booking.ID))...)
+		return nil
+	})
+	if strings.Contains(string(raw), "Carriages") {
+		t.Errorf("Expected the stored ticket to refer to its service, got %s", raw)
+	}
+	
+	stored, _, err := st.GetBooking(booking.ID)
+	if err != nil {
+		t.Fatalf("Failed to read booking: %v", err)
+	}
+	if len(stored.Tickets[0].Service.Carriages) != 1 || !stored.ChecksumValid() {
+		t.Errorf("Expected the service to be filled in and the checksum to hold, got %+v", stored.Tickets[0].Service)
+	}
+	
+	// Bookings written with a full copy of the service still read
+	legacy, _ := json.Marshal(booking)
+	st.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bookingsBucket).Put([]byte(booking.ID), legacy)
+	})
+	stored, _, err = st.GetBooking(booking.ID)
+	if err != nil || stored.Tickets[0].Service.ID != "5160" || !stored.ChecksumValid() {
+		t.Errorf("Expected a booking with an embedded service to read, got %+v (err %v)", stored, err)
+	}
+}
+
+func TestStore_StationsAndCouplingsSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conductor.db")
+	st, err := Open(path, Options{})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	rs, _ := reservation.NewSystemWithStore(st)
+	setupRouteAndService(t, rs)
+	if err := rs.AddStation(domain.Station{Name: "Paris", TimeZone: "Europe/Paris"}); err != nil {
+		t.Fatalf("Failed to add station: %v", err)
+	}
+	if err := st.SaveCoupling(domain.Coupling{ServiceIDs: []string{"5160", "5161"}, From: "Paris", To: "Amsterdam"}); err != nil {
+		t.Fatalf("Failed to save coupling: %v", err)
+	}
+	st.Close()
+	
+	st, err = Open(path, Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer st.Close()
+	stations, _ := st.Stations()
+	couplings, _ := st.Couplings()
+	if len(stations) != 1 || stations[0].TimeZone != "Europe/Paris" {
+		t.Errorf("Expected station Paris to be persisted, got %+v", stations)
+	}
+	if len(couplings) != 1 || couplings[0].Key() != "5160+5161" {
+		t.Errorf("Expected coupling to be persisted, got %+v", couplings)
+	}
+}
+
+func TestStore_AuditTrailSurvivesRestartAndRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conductor.db")
+	date := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	
+	st, err := Open(path, Options{})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	rs, err := reservation.NewSystemWithStore(st)
+	if err != nil {
+		t.Fatalf("Failed to create system: %v", err)
+	}
+	setupRouteAndService(t, rs)
+	book(t, rs, "A1")
+	conductor := rs.QueryAs(audit.Caller{UserID: "c-042", Role: audit.Conductor})
+	if _, _, err := conductor.GetPassengerOnSeat("5160", "A", "A1", date); err != nil {
+		t.Fatalf("Failed to look up seat: %v", err)
+	}
+	st.Close()
+	
+	st, err = Open(path, Options{})
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer st.Close()
+	if err := st.Restore(store.Snapshot{}); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	
+	trail, err := st.AuditTrail()
+	if err != nil {
+		t.Fatalf("Failed to read the audit trail: %v", err)
+	}
+	var entry audit.Entry
+	if len(trail) != 1 || json.Unmarshal(trail[0], &entry) != nil || entry.UserID != "c-042" || entry.Detail != "A/A1" {
+		t.Errorf("Expected the conductor's lookup to survive, got %q", trail)
+	}
+}
+
+func TestStore_DistributionBookingsSurviveRestartAndRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conductor.db")
+	
+	st, err := Open(path, Options{})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	st.SaveDistributionBooking("BO000001", []byte(`{"status":"CONFIRMED"}`))
+	st.SaveDistributionBooking("BO000001", []byte(`{"status":"REFUNDED"}`))
+	st.Close()
+	
+	st, err = Open(path, Options{})
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer st.Close()
+	if err := st.Restore(store.Snapshot{}); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	
+	records, err := st.DistributionBookings()
+	if err != nil {
+		t.Fatalf("Failed to read distribution bookings: %v", err)
+	}
+	if len(records) != 1 || string(records["BO000001"]) != `{"status":"REFUNDED"}` {
+		t.Errorf("Expected the latest record to survive, got %q", records)
+	}
+}
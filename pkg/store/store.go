@@ -0,0 +1,345 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"ticketing-app/pkg/domain"
+)
+
+// Store persists the reservation system's routes, stations, services,
+// couplings and bookings.
+// The reservation System keeps its working set in memory and writes every
+// change through to the store, so implementations only need simple
+// key-value semantics.
+type Store interface {
+	SaveRoute(route domain.Route) error
+	SaveStation(station domain.Station) error
+	SaveService(service domain.Service) error
+	SaveCoupling(coupling domain.Coupling) error
+	SaveBooking(booking domain.Booking) error
+	DeleteRoute(routeID string) error
+	DeleteStation(name string) error
+	DeleteService(serviceID string) error
+	// DeleteCoupling takes the coupling's Key.
+	DeleteCoupling(key string) error
+	DeleteBooking(bookingID string) error
+	GetBooking(bookingID string) (domain.Booking, bool, error)
+	Routes() ([]domain.Route, error)
+	Stations() ([]domain.Station, error)
+	Services() ([]domain.Service, error)
+	Couplings() ([]domain.Coupling, error)
+	Bookings() ([]domain.Booking, error)
+	// AppendAudit keeps one entry of the audit trail of passenger data
+	// lookups, next to the data it covers. Entries are never rewritten.
+	AppendAudit(entry []byte) error
+	// SaveDistributionBooking keeps a distribution API's own record of a
+	// booking, such as an OSDM booking and the reservations behind it,
+	// encoded by the API. A record replaces the one saved before it.
+	SaveDistributionBooking(bookingID string, record []byte) error
+	// DistributionBookings returns the records kept, by booking ID.
+	DistributionBookings() (map[string][]byte, error)
+	// Ping reports whether the store can currently be reached.
+	Ping() error
+	Close() error
+}
+
+// UnreadableError is returned by Bookings, alongside the bookings that
+// could be read, when some stored bookings could not be, as when an
+// encrypting store cannot decrypt them. Callers can carry on with the
+// bookings returned and report the rest one by one.
+type UnreadableError struct {
+	Bookings map[string]error // by booking ID
+}
+
+func (e *UnreadableError) Error() string {
+	ids := make([]string, 0, len(e.Bookings))
+	for id := range e.Bookings {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprintf("%s: %v", id, e.Bookings[id])
+	}
+	return fmt.Sprintf("%d stored bookings could not be read: %s", len(ids), strings.Join(parts, "; "))
+}
+
+// Snapshot is the full contents of a store at one moment.
+type Snapshot struct {
+	Routes    []domain.Route
+	Stations  []domain.Station
+	Services  []domain.Service
+	Couplings []domain.Coupling
+	Bookings  []domain.Booking
+}
+
+// Snapshotter is implemented by stores that can read all their contents in
+// one consistent view. Stores without it are read one collection at a time.
+type Snapshotter interface {
+	Snapshot() (Snapshot, error)
+}
+
+// Restorer is implemented by stores that can replace all their contents
+// with a snapshot in one transaction: afterwards the store holds exactly
+// the snapshot, or, if Restore fails, exactly what it held before.
+type Restorer interface {
+	Restore(snapshot Snapshot) error
+}
+
+// Memory is the default store; it keeps nothing beyond the process.
+type Memory struct {
+	mu        sync.RWMutex
+	routes    map[string]domain.Route
+	stations  map[string]domain.Station
+	services  map[string]domain.Service
+	couplings map[string]domain.Coupling
+	bookings  map[string]domain.Booking
+	audit     [][]byte
+	// distribution holds records of distribution API bookings
+	distribution map[string][]byte
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		routes:       make(map[string]domain.Route),
+		stations:     make(map[string]domain.Station),
+		services:     make(map[string]domain.Service),
+		couplings:    make(map[string]domain.Coupling),
+		bookings:     make(map[string]domain.Booking),
+		distribution: make(map[string][]byte),
+	}
+}
+
+func (m *Memory) SaveRoute(route domain.Route) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes[route.ID] = route
+	return nil
+}
+
+func (m *Memory) SaveStation(station domain.Station) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stations[station.Name] = station
+	return nil
+}
+
+func (m *Memory) SaveService(service domain.Service) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.services[service.ID] = service
+	return nil
+}
+
+func (m *Memory) SaveCoupling(coupling domain.Coupling) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.couplings[coupling.Key()] = coupling
+	return nil
+}
+
+func (m *Memory) SaveBooking(booking domain.Booking) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bookings[booking.ID] = booking
+	return nil
+}
+
+func (m *Memory) DeleteRoute(routeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.routes, routeID)
+	return nil
+}
+
+func (m *Memory) DeleteStation(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.stations, name)
+	return nil
+}
+
+func (m *Memory) DeleteService(serviceID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.services, serviceID)
+	return nil
+}
+
+func (m *Memory) DeleteCoupling(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.couplings, key)
+	return nil
+}
+
+func (m *Memory) DeleteBooking(bookingID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.bookings, bookingID)
+	return nil
+}
+
+func (m *Memory) GetBooking(bookingID string) (domain.Booking, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	booking, exists := m.bookings[bookingID]
+	return booking, exists, nil
+}
+
+func (m *Memory) Routes() ([]domain.Route, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	routes := make([]domain.Route, 0, len(m.routes))
+	for _, route := range m.routes {
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].ID < routes[j].ID })
+	return routes, nil
+}
+
+func (m *Memory) Stations() ([]domain.Station, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sortedStations(), nil
+}
+
+func (m *Memory) Services() ([]domain.Service, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	services := make([]domain.Service, 0, len(m.services))
+	for _, service := range m.services {
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].ID < services[j].ID })
+	return services, nil
+}
+
+func (m *Memory) Couplings() ([]domain.Coupling, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sortedCouplings(), nil
+}
+
+func (m *Memory) Bookings() ([]domain.Booking, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	bookings := make([]domain.Booking, 0, len(m.bookings))
+	for _, booking := range m.bookings {
+		bookings = append(bookings, booking)
+	}
+	sort.Slice(bookings, func(i, j int) bool { return bookings[i].ID < bookings[j].ID })
+	return bookings, nil
+}
+
+func (m *Memory) Snapshot() (Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	snapshot := Snapshot{
+		Routes:    make([]domain.Route, 0, len(m.routes)),
+		Stations:  m.sortedStations(),
+		Services:  make([]domain.Service, 0, len(m.services)),
+		Couplings: m.sortedCouplings(),
+		Bookings:  make([]domain.Booking, 0, len(m.bookings)),
+	}
+	for _, route := range m.routes {
+		snapshot.Routes = append(snapshot.Routes, route)
+	}
+	for _, service := range m.services {
+		snapshot.Services = append(snapshot.Services, service)
+	}
+	for _, booking := range m.bookings {
+		snapshot.Bookings = append(snapshot.Bookings, booking)
+	}
+	sort.Slice(snapshot.Routes, func(i, j int) bool { return snapshot.Routes[i].ID < snapshot.Routes[j].ID })
+	sort.Slice(snapshot.Services, func(i, j int) bool { return snapshot.Services[i].ID < snapshot.Services[j].ID })
+	sort.Slice(snapshot.Bookings, func(i, j int) bool { return snapshot.Bookings[i].ID < snapshot.Bookings[j].ID })
+	return snapshot, nil
+}
+
+func (m *Memory) Restore(snapshot Snapshot) error {
+	routes := make(map[string]domain.Route, len(snapshot.Routes))
+	for _, route := range snapshot.Routes {
+		routes[route.ID] = route
+	}
+	stations := make(map[string]domain.Station, len(snapshot.Stations))
+	for _, station := range snapshot.Stations {
+		stations[station.Name] = station
+	}
+	services := make(map[string]domain.Service, len(snapshot.Services))
+	for _, service := range snapshot.Services {
+		services[service.ID] = service
+	}
+	couplings := make(map[string]domain.Coupling, len(snapshot.Couplings))
+	for _, coupling := range snapshot.Couplings {
+		couplings[coupling.Key()] = coupling
+	}
+	bookings := make(map[string]domain.Booking, len(snapshot.Bookings))
+	for _, booking := range snapshot.Bookings {
+		bookings[booking.ID] = booking
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes, m.stations, m.services, m.couplings, m.bookings = routes, stations, services, couplings, bookings
+	return nil
+}
+
+func (m *Memory) sortedStations() []domain.Station {
+	stations := make([]domain.Station, 0, len(m.stations))
+	for _, station := range m.stations {
+		stations = append(stations, station)
+	}
+	sort.Slice(stations, func(i, j int) bool { return stations[i].Name < stations[j].Name })
+	return stations
+}
+
+func (m *Memory) sortedCouplings() []domain.Coupling {
+	couplings := make([]domain.Coupling, 0, len(m.couplings))
+	for _, coupling := range m.couplings {
+		couplings = append(couplings, coupling)
+	}
+	sort.Slice(couplings, func(i, j int) bool { return couplings[i].Key() < couplings[j].Key() })
+	return couplings
+}
+
+func (m *Memory) AppendAudit(entry []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.audit = append(m.audit, append([]byte(nil), entry...))
+	return nil
+}
+
+// AuditTrail returns the audit entries appended so far, oldest first.
+func (m *Memory) AuditTrail() ([][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([][]byte(nil), m.audit...), nil
+}
+
+func (m *Memory) SaveDistributionBooking(bookingID string, record []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.distribution[bookingID] = append([]byte(nil), record...)
+	return nil
+}
+
+func (m *Memory) DistributionBookings() (map[string][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	records := make(map[string][]byte, len(m.distribution))
+	for id, record := range m.distribution {
+		records[id] = append([]byte(nil), record...)
+	}
+	return records, nil
+}
+
+func (m *Memory) Ping() error {
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
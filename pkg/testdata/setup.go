@@ -1,14 +1,31 @@
 package testdata
 
 import (
+	"fmt"
 	"ticketing-app/pkg/domain"
 	"ticketing-app/pkg/reservation"
+	"ticketing-app/pkg/store"
 	"time"
 )
 
 func SetupTestData() *reservation.System {
 	rs := reservation.NewSystem()
-	
+	addTestData(rs)
+	return rs
+}
+
+// SetupTestDataWithStore loads the test data into a system backed by the
+// given store.
+func SetupTestDataWithStore(st store.Store) (*reservation.System, error) {
+	rs, err := reservation.NewSystemWithStore(st)
+	if err != nil {
+		return nil, err
+	}
+	addTestData(rs)
+	return rs, nil
+}
+
+func addTestData(rs *reservation.System) {
 	paris := domain.NewStation("Paris")
 	london := domain.NewStation("London")
 	calais := domain.NewStation("Calais")
@@ -19,6 +36,9 @@ func SetupTestData() *reservation.System {
 	berlin := domain.NewStation("Berlin")
 	hannover := domain.NewStation("Hannover")
 	
+	paris.Names = map[string]string{"fr": "Paris Nord", "nl": "Parijs-Noord", "de": "Paris Nord"}
+	london.Names = map[string]string{"en": "London St Pancras", "fr": "Londres Saint-Pancras", "nl": "Londen St Pancras"}
+	
 	routeParisLondon := domain.NewRoute("R001", "Paris-London", 
 		[]domain.Station{paris, calais, dover, london},
 		[]int{0, 300, 380, 450})
@@ -49,8 +69,6 @@ func SetupTestData() *reservation.System {
 	rs.AddService(service5160)
 	rs.AddService(service5161)
 	rs.AddService(service5162)
-	
-	return rs
 }
 
 func createCarriages() []domain.Carriage {
@@ -125,3 +143,64 @@ func createCarriages() []domain.Carriage {
 	
 	return carriages
 }
+
+// OnSaleServiceID is a departure sized like a real high-speed train, for
+// load tests that need more seats than the sample services have.
+const OnSaleServiceID = "9340"
+
+// AddOnSaleService adds OnSaleServiceID on the Paris-Amsterdam route, the
+// same day as 5160: two first class carriages of 36 seats laid out 2+1 and
+// six second class carriages of 56 laid out 2+2, 408 seats in all. Rows
+// face each other in bays of two; first class bays have tables.
+func AddOnSaleService(rs *reservation.System) error {
+	template, found := rs.GetService("5160")
+	if !found {
+		return fmt.Errorf("service 5160 not found")
+	}
+	
+	var carriages []domain.Carriage
+	for i := 1; i <= 8; i++ {
+		zone, seats, columns := domain.SecondClass, 56, 4
+		if i <= 2 {
+			zone, seats, columns = domain.FirstClass, 36, 3
+		}
+		carriages = append(carriages, generateCarriage(fmt.Sprint(i), zone, seats, columns))
+	}
+	
+	return rs.AddService(domain.NewService(OnSaleServiceID, template.Route,
+		time.Date(2021, 4, 1, 7, 13, 0, 0, time.UTC), carriages))
+}
+
+// generateCarriage lays seats out row by row, columns to a row with the
+// aisle after the second column, numbering them in that order so
+// consecutive numbers cross the aisle as they do on real trains. Odd rows
+// face backward and even rows forward, so each pair of rows is a bay of
+// facing seats; first class bays have a table on each side of the aisle.
+func generateCarriage(id string, zone domain.ComfortZone, seats, columns int) domain.Carriage {
+	carriage := domain.Carriage{ID: id, Aisle: 2}
+	for n := 1; n <= seats; n++ {
+		row, column := (n-1)/columns+1, (n-1)%columns+1
+		bay := (row + 1) / 2
+		seat := domain.Seat{
+			Number:      fmt.Sprint(n),
+			ComfortZone: zone,
+			CarriageID:  id,
+			Row:         row,
+			Column:      column,
+			Facing:      domain.FacingForward,
+			Bay:         fmt.Sprintf("B%d", bay),
+		}
+		if row%2 == 1 {
+			seat.Facing = domain.FacingBackward
+		}
+		if zone == domain.FirstClass {
+			side := "L"
+			if column > carriage.Aisle {
+				side = "R"
+			}
+			seat.Table = fmt.Sprintf("T%d%s", bay, side)
+		}
+		carriage.Seats = append(carriage.Seats, seat)
+	}
+	return carriage
+}
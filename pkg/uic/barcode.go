@@ -0,0 +1,397 @@
+package uic
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// The barcode payload defined by UIC leaflet 918-3. The encoded bytes are
+// what goes into the Aztec symbol printed on the ticket:
+//
+//	"#UT" | version (2) | RICS code (4) | key ID (5) | signature (50 or 64)
+//	| compressed length (4) | zlib-compressed records
+//
+// Each record is an ID (6), a version (2) and a total length (4) followed
+// by its data.
+const (
+	magic          = "#UT"
+	headerRecordID = "U_HEAD"
+	layoutRecordID = "U_TLAY"
+	recordVersion  = "01"
+	layoutRCT2     = "RCT2"
+	timeLayout     = "020120061504" // DDMMYYYYHHMM
+)
+
+var signatureSizes = map[int]int{1: 50, 2: 64}
+
+// maxRecordsSize bounds the decompressed record data. At most 9999
+// compressed bytes fit a barcode, but a crafted payload can inflate far
+// beyond what any real ticket needs.
+const maxRecordsSize = 64 << 10
+
+type BarcodeError struct {
+	Message string
+	Code    string
+}
+
+func (e BarcodeError) Error() string {
+	return e.Message
+}
+
+type Signer interface {
+	Sign(data []byte) ([]byte, error)
+}
+
+type Verifier interface {
+	Verify(data, signature []byte) error
+}
+
+// Versioned is implemented by signers and verifiers that only work with
+// one barcode version, such as DSA keys whose size fixes the digest.
+type Versioned interface {
+	Version() int
+}
+
+type Header struct {
+	CompanyCode    string // RICS code of the issuer
+	TicketKey      string // unique ticket reference, at most 20 characters
+	IssuedAt       time.Time
+	Flags          int
+	Language       string
+	SecondLanguage string
+}
+
+// LayoutField places one text on the RCT2 ticket grid (15 lines of 72
+// columns) that inspection devices display.
+type LayoutField struct {
+	Line       int
+	Column     int
+	Height     int
+	Width      int
+	Formatting int
+	Text       string
+}
+
+type Ticket struct {
+	Version    int    // zero takes the version of a Versioned signer
+	SignerCode string // RICS code of the company holding the signing key
+	KeyID      string
+	Head       Header
+	Layout     []LayoutField
+	Signature  []byte
+}
+
+// Encode serializes and signs a ticket. The signature is computed over the
+// compressed record data, as required by the standard.
+func Encode(ticket Ticket, signer Signer) ([]byte, error) {
+	if versioned, ok := signer.(Versioned); ok {
+		if ticket.Version == 0 {
+			ticket.Version = versioned.Version()
+		}
+		if ticket.Version != versioned.Version() {
+			return nil, versionMismatch(ticket.Version, versioned.Version())
+		}
+	}
+	signatureSize, supported := signatureSizes[ticket.Version]
+	if !supported {
+		return nil, BarcodeError{
+			Message: fmt.Sprintf("Unsupported barcode version %d", ticket.Version),
+			Code:    "UNSUPPORTED_VERSION",
+		}
+	}
+
+	records, err := encodeRecords(ticket)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > maxRecordsSize {
+		return nil, BarcodeError{
+			Message: fmt.Sprintf("Ticket data is %d bytes, the maximum is %d", len(records), maxRecordsSize),
+			Code:    "TICKET_TOO_LARGE",
+		}
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(records); err != nil {
+		return nil, fmt.Errorf("failed to compress barcode records: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress barcode records: %w", err)
+	}
+	if compressed.Len() > 9999 {
+		return nil, BarcodeError{
+			Message: fmt.Sprintf("Compressed ticket data is %d bytes, the maximum is 9999", compressed.Len()),
+			Code:    "TICKET_TOO_LARGE",
+		}
+	}
+
+	signature, err := signer.Sign(compressed.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign barcode: %w", err)
+	}
+	if len(signature) > signatureSize {
+		return nil, BarcodeError{
+			Message: fmt.Sprintf("Signature is %d bytes, version %d allows %d", len(signature), ticket.Version, signatureSize),
+			Code:    "SIGNATURE_TOO_LARGE",
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString(magic)
+	out.WriteString(fmt.Sprintf("%02d", ticket.Version))
+	out.WriteString(fixed(ticket.SignerCode, 4, '0', true))
+	out.WriteString(fixed(ticket.KeyID, 5, '0', true))
+	out.Write(signature)
+	out.Write(make([]byte, signatureSize-len(signature)))
+	out.WriteString(fmt.Sprintf("%04d", compressed.Len()))
+	out.Write(compressed.Bytes())
+
+	return out.Bytes(), nil
+}
+
+// Decode parses a barcode payload and checks its signature. A nil verifier
+// only validates the structure, which is useful when the issuer's public
+// key is not available.
+func Decode(data []byte, verifier Verifier) (Ticket, error) {
+	r := &reader{data: data}
+
+	if r.str(3) != magic {
+		return Ticket{}, malformed("missing #UT header")
+	}
+	version, err := strconv.Atoi(r.str(2))
+	if err != nil {
+		return Ticket{}, malformed("invalid version")
+	}
+	signatureSize, supported := signatureSizes[version]
+	if !supported {
+		return Ticket{}, BarcodeError{
+			Message: fmt.Sprintf("Unsupported barcode version %d", version),
+			Code:    "UNSUPPORTED_VERSION",
+		}
+	}
+
+	ticket := Ticket{
+		Version:    version,
+		SignerCode: r.str(4),
+		KeyID:      r.str(5),
+		Signature:  r.bytes(signatureSize),
+	}
+	length, err := strconv.Atoi(r.str(4))
+	if err != nil {
+		return Ticket{}, malformed("invalid compressed data length")
+	}
+	compressed := r.bytes(length)
+	if r.err != nil {
+		return Ticket{}, r.err
+	}
+
+	if versioned, ok := verifier.(Versioned); ok && versioned.Version() != version {
+		return Ticket{}, versionMismatch(version, versioned.Version())
+	}
+	if verifier != nil {
+		if err := verifier.Verify(compressed, ticket.Signature); err != nil {
+			return Ticket{}, BarcodeError{
+				Message: fmt.Sprintf("Barcode signature is invalid: %v", err),
+				Code:    "INVALID_SIGNATURE",
+			}
+		}
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return Ticket{}, malformed("compressed data is corrupt")
+	}
+	records, err := io.ReadAll(io.LimitReader(zr, maxRecordsSize+1))
+	if err != nil {
+		return Ticket{}, malformed("compressed data is corrupt")
+	}
+	if len(records) > maxRecordsSize {
+		return Ticket{}, malformed(fmt.Sprintf("ticket data inflates beyond %d bytes", maxRecordsSize))
+	}
+
+	if err := decodeRecords(records, &ticket); err != nil {
+		return Ticket{}, err
+	}
+	return ticket, nil
+}
+
+func encodeRecords(ticket Ticket) ([]byte, error) {
+	if len(ticket.Head.TicketKey) > 20 {
+		return nil, BarcodeError{
+			Message: fmt.Sprintf("Ticket key %s is longer than 20 characters", ticket.Head.TicketKey),
+			Code:    "INVALID_TICKET_KEY",
+		}
+	}
+
+	var head bytes.Buffer
+	head.WriteString(fixed(ticket.Head.CompanyCode, 4, '0', true))
+	head.WriteString(fixed(ticket.Head.TicketKey, 20, ' ', false))
+	head.WriteString(ticket.Head.IssuedAt.UTC().Format(timeLayout))
+	head.WriteString(strconv.Itoa(ticket.Head.Flags % 10))
+	head.WriteString(fixed(ticket.Head.Language, 2, ' ', false))
+	head.WriteString(fixed(ticket.Head.SecondLanguage, 2, ' ', false))
+
+	var layout bytes.Buffer
+	layout.WriteString(layoutRCT2)
+	layout.WriteString(fmt.Sprintf("%04d", len(ticket.Layout)))
+	for _, field := range ticket.Layout {
+		if !fitsGrid(field.Line) || !fitsGrid(field.Column) || !fitsGrid(field.Height) || !fitsGrid(field.Width) ||
+			field.Formatting < 0 || len(field.Text) > 9999 {
+			return nil, BarcodeError{
+				Message: fmt.Sprintf("Layout field %q does not fit the RCT2 grid", field.Text),
+				Code:    "INVALID_LAYOUT",
+			}
+		}
+		layout.WriteString(fmt.Sprintf("%02d%02d%02d%02d%d%04d", field.Line, field.Column, field.Height, field.Width, field.Formatting%10, len(field.Text)))
+		layout.WriteString(field.Text)
+	}
+
+	var records bytes.Buffer
+	writeRecord(&records, headerRecordID, head.Bytes())
+	writeRecord(&records, layoutRecordID, layout.Bytes())
+	return records.Bytes(), nil
+}
+
+// fitsGrid reports whether a layout value fits its two-digit field.
+func fitsGrid(value int) bool {
+	return value >= 0 && value <= 99
+}
+
+func writeRecord(w *bytes.Buffer, id string, data []byte) {
+	w.WriteString(id)
+	w.WriteString(recordVersion)
+	w.WriteString(fmt.Sprintf("%04d", 12+len(data)))
+	w.Write(data)
+}
+
+// decodeRecords reads the U_HEAD and U_TLAY records. Records from other
+// issuers' extensions are skipped.
+func decodeRecords(data []byte, ticket *Ticket) error {
+	r := &reader{data: data}
+	for r.remaining() > 0 {
+		id := r.str(6)
+		r.str(2)
+		length, err := strconv.Atoi(r.str(4))
+		if r.err != nil {
+			return r.err
+		}
+		if err != nil || length < 12 {
+			return malformed(fmt.Sprintf("invalid length for record %s", id))
+		}
+		record := &reader{data: r.bytes(length - 12)}
+		if r.err != nil {
+			return r.err
+		}
+
+		switch id {
+		case headerRecordID:
+			ticket.Head.CompanyCode = record.str(4)
+			ticket.Head.TicketKey = trimRight(record.str(20))
+			issuedAt, err := time.Parse(timeLayout, record.str(12))
+			if err != nil {
+				return malformed("invalid issuing time")
+			}
+			ticket.Head.IssuedAt = issuedAt
+			ticket.Head.Flags = record.int(1)
+			ticket.Head.Language = trimRight(record.str(2))
+			ticket.Head.SecondLanguage = trimRight(record.str(2))
+		case layoutRecordID:
+			if standard := record.str(4); standard != layoutRCT2 {
+				return BarcodeError{
+					Message: fmt.Sprintf("Unsupported ticket layout %s", standard),
+					Code:    "UNSUPPORTED_LAYOUT",
+				}
+			}
+			count := record.int(4)
+			ticket.Layout = nil
+			for i := 0; i < count && record.err == nil; i++ {
+				field := LayoutField{
+					Line:       record.int(2),
+					Column:     record.int(2),
+					Height:     record.int(2),
+					Width:      record.int(2),
+					Formatting: record.int(1),
+				}
+				field.Text = record.str(record.int(4))
+				ticket.Layout = append(ticket.Layout, field)
+			}
+		}
+		if record.err != nil {
+			return record.err
+		}
+	}
+	return nil
+}
+
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.remaining() < n {
+		r.err = malformed("unexpected end of data")
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) str(n int) string {
+	return string(r.bytes(n))
+}
+
+func (r *reader) int(n int) int {
+	s := r.str(n)
+	if r.err != nil {
+		return 0
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		r.err = malformed(fmt.Sprintf("expected a number, got %q", s))
+	}
+	return v
+}
+
+func versionMismatch(barcode, key int) error {
+	return BarcodeError{
+		Message: fmt.Sprintf("Barcode version %d does not match the key's version %d", barcode, key),
+		Code:    "VERSION_MISMATCH",
+	}
+}
+
+func malformed(reason string) error {
+	return BarcodeError{
+		Message: fmt.Sprintf("Malformed UIC barcode: %s", reason),
+		Code:    "MALFORMED_BARCODE",
+	}
+}
+
+func fixed(s string, width int, pad byte, left bool) string {
+	if len(s) >= width {
+		return s[:width]
+	}
+	padding := bytes.Repeat([]byte{pad}, width-len(s))
+	if left {
+		return string(padding) + s
+	}
+	return s + string(padding)
+}
+
+func trimRight(s string) string {
+	return string(bytes.TrimRight([]byte(s), " "))
+}
@@ -0,0 +1,180 @@
+package uic
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/dsa"
+	"crypto/rand"
+	"fmt"
+	"testing"
+	"ticketing-app/pkg/domain"
+	"ticketing-app/pkg/testdata"
+	"time"
+)
+
+func generateKey(t *testing.T) *dsa.PrivateKey {
+	key := &dsa.PrivateKey{}
+	if err := dsa.GenerateParameters(&key.Parameters, rand.Reader, dsa.L1024N160); err != nil {
+		t.Fatalf("Failed to generate DSA parameters: %v", err)
+	}
+	if err := dsa.GenerateKey(key, rand.Reader); err != nil {
+		t.Fatalf("Failed to generate DSA key: %v", err)
+	}
+	return key
+}
+
+// testBooking books one first class seat on the test data's service 5160.
+func testBooking(t *testing.T) *domain.Booking {
+	booking, err := testdata.SetupTestData().MakeReservation(domain.ReservationRequest{
+		ServiceID:    "5160",
+		Origin:       "Paris",
+		Destination:  "Amsterdam",
+		Passengers:   []domain.Passenger{{Name: "John Doe", Locale: "en"}},
+		SeatRequests: []domain.SeatRequest{{CarriageID: "A", SeatNumber: "A11"}},
+		Date:         time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Failed to book test ticket: %v", err)
+	}
+	return booking
+}
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	key := generateKey(t)
+	booking := testBooking(t)
+	issuedAt := time.Date(2021, 3, 1, 9, 30, 0, 0, time.UTC)
+	
+	ticket := FromTicket(booking.ID, 0, booking.Tickets[0], "1187", "00001", issuedAt)
+	
+	data, err := Encode(ticket, DSASigner{Key: key})
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	if string(data[:5]) != "#UT01" {
+		t.Errorf("Expected #UT01 header, got %q", data[:5])
+	}
+	
+	decoded, err := Decode(data, DSAVerifier{Key: &key.PublicKey})
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	
+	if decoded.SignerCode != "1187" || decoded.KeyID != "00001" {
+		t.Errorf("Unexpected signer %s/%s", decoded.SignerCode, decoded.KeyID)
+	}
+	if decoded.Head.TicketKey != "B0001-1" {
+		t.Errorf("Expected ticket key B0001-1, got %q", decoded.Head.TicketKey)
+	}
+	if !decoded.Head.IssuedAt.Equal(issuedAt) {
+		t.Errorf("Expected issue time %v, got %v", issuedAt, decoded.Head.IssuedAt)
+	}
+	if decoded.Head.Language != "en" {
+		t.Errorf("Expected language en, got %q", decoded.Head.Language)
+	}
+	if len(decoded.Layout) != len(ticket.Layout) {
+		t.Fatalf("Expected %d layout fields, got %d", len(ticket.Layout), len(decoded.Layout))
+	}
+	for i := range ticket.Layout {
+		if decoded.Layout[i] != ticket.Layout[i] {
+			t.Errorf("Layout field %d: expected %+v, got %+v", i, ticket.Layout[i], decoded.Layout[i])
+		}
+	}
+}
+
+func TestDecode_RejectsTamperedData(t *testing.T) {
+	key := generateKey(t)
+	booking := testBooking(t)
+	ticket := FromTicket(booking.ID, 0, booking.Tickets[0], "1187", "00001", time.Now())
+	
+	data, err := Encode(ticket, DSASigner{Key: key})
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	data[len(data)-1] ^= 0xff
+	
+	_, err = Decode(data, DSAVerifier{Key: &key.PublicKey})
+	if barcodeErr, ok := err.(BarcodeError); !ok || barcodeErr.Code != "INVALID_SIGNATURE" {
+		t.Errorf("Expected INVALID_SIGNATURE, got %v", err)
+	}
+}
+
+func TestDecode_Malformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		errCode string
+	}{
+		{"Empty", []byte{}, "MALFORMED_BARCODE"},
+		{"Wrong magic", []byte("#XX01"), "MALFORMED_BARCODE"},
+		{"Unknown version", []byte("#UT09"), "UNSUPPORTED_VERSION"},
+		{"Truncated", []byte("#UT0111870000"), "MALFORMED_BARCODE"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.data, nil)
+			if barcodeErr, ok := err.(BarcodeError); !ok || barcodeErr.Code != tt.errCode {
+				t.Errorf("Expected %s, got %v", tt.errCode, err)
+			}
+		})
+	}
+}
+
+type fixedSigner []byte
+
+func (s fixedSigner) Sign(data []byte) ([]byte, error) {
+	return s, nil
+}
+
+func TestEncode_RejectsOversizedSignature(t *testing.T) {
+	_, err := Encode(Ticket{Version: 1}, fixedSigner(make([]byte, 51)))
+	if barcodeErr, ok := err.(BarcodeError); !ok || barcodeErr.Code != "SIGNATURE_TOO_LARGE" {
+		t.Errorf("Expected SIGNATURE_TOO_LARGE, got %v", err)
+	}
+}
+
+func TestEncode_RejectsLayoutOutsideGrid(t *testing.T) {
+	tests := []struct {
+		name  string
+		field LayoutField
+	}{
+		{"Line above 99", LayoutField{Line: 100, Width: 1, Text: "x"}},
+		{"Negative column", LayoutField{Column: -1, Width: 1, Text: "x"}},
+		{"Negative width", LayoutField{Width: -5, Text: "x"}},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Encode(Ticket{Version: 1, Layout: []LayoutField{tt.field}}, fixedSigner(nil))
+			if barcodeErr, ok := err.(BarcodeError); !ok || barcodeErr.Code != "INVALID_LAYOUT" {
+				t.Errorf("Expected INVALID_LAYOUT, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEncode_VersionFollowsKey(t *testing.T) {
+	key := generateKey(t)
+	
+	_, err := Encode(Ticket{Version: 2}, DSASigner{Key: key})
+	if barcodeErr, ok := err.(BarcodeError); !ok || barcodeErr.Code != "VERSION_MISMATCH" {
+		t.Errorf("Expected VERSION_MISMATCH for a 1024-bit key, got %v", err)
+	}
+}
+
+func TestDecode_RejectsDecompressionBomb(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(make([]byte, maxRecordsSize+1))
+	zw.Close()
+	
+	data := []byte("#UT01118700001")
+	data = append(data, make([]byte, 50)...)
+	data = append(data, fmt.Sprintf("%04d", compressed.Len())...)
+	data = append(data, compressed.Bytes()...)
+	
+	_, err := Decode(data, nil)
+	if barcodeErr, ok := err.(BarcodeError); !ok || barcodeErr.Code != "MALFORMED_BARCODE" {
+		t.Errorf("Expected MALFORMED_BARCODE, got %v", err)
+	}
+}
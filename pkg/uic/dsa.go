@@ -0,0 +1,74 @@
+package uic
+
+import (
+	"crypto/dsa"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"hash"
+	"math/big"
+)
+
+// UIC 918-3 barcodes are signed with DSA: SHA-1 with 1024-bit keys for
+// version 1 and SHA-256 with 2048-bit keys for version 2. The signature is
+// the DER encoding of (r, s), padded with zero bytes to the field size.
+type dsaSignature struct {
+	R, S *big.Int
+}
+
+type DSASigner struct {
+	Key *dsa.PrivateKey
+}
+
+// Version is 2 for keys of 2048 bits or more and 1 otherwise.
+func (s DSASigner) Version() int {
+	return keyVersion(&s.Key.PublicKey)
+}
+
+func (s DSASigner) Sign(data []byte) ([]byte, error) {
+	r, sig, err := dsa.Sign(rand.Reader, s.Key, digest(s.Version(), data))
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(dsaSignature{R: r, S: sig})
+}
+
+type DSAVerifier struct {
+	Key *dsa.PublicKey
+}
+
+func (v DSAVerifier) Version() int {
+	return keyVersion(v.Key)
+}
+
+func (v DSAVerifier) Verify(data, signature []byte) error {
+	var sig dsaSignature
+	// The remainder after the DER value is the zero padding
+	if _, err := asn1.Unmarshal(signature, &sig); err != nil {
+		return errors.New("signature is not a DER-encoded DSA signature")
+	}
+	if !dsa.Verify(v.Key, digest(v.Version(), data), sig.R, sig.S) {
+		return errors.New("signature does not match ticket data")
+	}
+	return nil
+}
+
+func keyVersion(key *dsa.PublicKey) int {
+	if key.P.BitLen() >= 2048 {
+		return 2
+	}
+	return 1
+}
+
+func digest(version int, data []byte) []byte {
+	var h hash.Hash
+	if version >= 2 {
+		h = sha256.New()
+	} else {
+		h = sha1.New()
+	}
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,48 @@
+package uic
+
+import (
+	"fmt"
+	"ticketing-app/pkg/domain"
+	"time"
+)
+
+// FromTicket builds the barcode content for one ticket of a booking. The
+// ticket key combines the booking ID and the ticket's position so every
+// passenger's barcode is unique.
+func FromTicket(bookingID string, index int, ticket domain.Ticket, companyCode, keyID string, issuedAt time.Time) Ticket {
+	departure := ticket.Service.DateTime
+	if at, found := ticket.Service.TimeAt(ticket.Origin.Name); found {
+		departure = at
+	}
+
+	class := "2"
+	if ticket.Seat.ComfortZone == domain.FirstClass {
+		class = "1"
+	}
+
+	language := ticket.Passenger.Locale
+	if len(language) > 2 {
+		language = language[:2]
+	}
+
+	return Ticket{
+		SignerCode: companyCode,
+		KeyID:      keyID,
+		Head: Header{
+			CompanyCode: companyCode,
+			TicketKey:   fmt.Sprintf("%s-%d", bookingID, index+1),
+			IssuedAt:    issuedAt,
+			Language:    language,
+		},
+		Layout: []LayoutField{
+			{Line: 0, Column: 0, Height: 1, Width: 72, Text: "RESERVATION"},
+			{Line: 2, Column: 0, Height: 1, Width: 72, Text: ticket.Passenger.Name},
+			{Line: 6, Column: 1, Height: 1, Width: 12, Text: departure.Format("02.01 15:04")},
+			{Line: 6, Column: 13, Height: 1, Width: 20, Text: ticket.Origin.Name},
+			{Line: 6, Column: 34, Height: 1, Width: 20, Text: ticket.Destination.Name},
+			{Line: 6, Column: 66, Height: 1, Width: 5, Text: class},
+			{Line: 8, Column: 1, Height: 1, Width: 20, Text: "Train " + ticket.Service.ID},
+			{Line: 8, Column: 22, Height: 1, Width: 20, Text: fmt.Sprintf("Coach %s Seat %s", ticket.Seat.CarriageID, ticket.Seat.Number)},
+		},
+	}
+}
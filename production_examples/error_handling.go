@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -32,6 +33,25 @@ func (e ValidationError) Error() string {
 	return fmt.Sprintf("validation error on field %s: %s (value: %v)", e.Field, e.Message, e.Value)
 }
 
+// Multiple validation errors reported together
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d validation errors: %s", len(e), strings.Join(messages, "; "))
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
 type BusinessRuleError struct {
 	Rule    string
 	Message string
@@ -92,7 +112,7 @@ func (s *ProductionBookingService) MakeReservation(ctx context.Context,
 
 // Input validation with detailed error reporting
 func (s *ProductionBookingService) validateBookingRequest(req BookingRequest) error {
-	var validationErrors []ValidationError
+	var validationErrors ValidationErrors
 	
 	if req.ServiceID == "" {
 		validationErrors = append(validationErrors, ValidationError{
@@ -146,9 +166,8 @@ func (s *ProductionBookingService) validateBookingRequest(req BookingRequest) er
 	}
 	
 	if len(validationErrors) > 0 {
-		// Return the first validation error with context about total errors
-		return fmt.Errorf("validation failed (%d errors): %w", 
-			len(validationErrors), validationErrors[0])
+		// Return every validation error so the caller can fix them all at once
+		return validationErrors
 	}
 	
 	return nil